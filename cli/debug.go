@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/forrest/go-flow/execution"
+)
+
+const debugHelp = `Commands:
+  break before <component>               pause before a component runs
+  break after <component>                pause after a component runs
+  break conn <from.port> <to.port> [if <text>]
+                                         pause when a packet crosses a connection,
+                                         optionally only if it contains <text>
+  delete <id>                            remove a breakpoint
+  list                                   list breakpoints
+  run                                    start the pipeline
+  continue | c                           resume until the next breakpoint
+  step | s                               resume until the next pause point
+  inputs | outputs | packet              inspect data at the current stop
+  set <port> <value>                     replace an input (before) or output (after) value
+  set packet <value>                     replace the packet on the current connection
+  abort                                  stop the run
+  quit | q                               exit
+`
+
+// runDebug starts an interactive debugging session for an example or spec pipeline.
+func runDebug(args []string) int {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	source := addPipelineFlags(fs, "Example pipeline to debug")
+	step := fs.Bool("step", false, "Pause before the first component")
+	fs.Parse(args)
+
+	p, err := source.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	engine := execution.NewDebugEngine(*step)
+	repl := &debugREPL{engine: engine, out: os.Stdout}
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Fprintf(repl.out, "Debugging pipeline %q. Type 'help' for commands.\n", p.Name())
+	for {
+		fmt.Fprint(repl.out, "(goflow) ")
+		if !scanner.Scan() {
+			return 0
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "quit", "q":
+			return 0
+		case "run":
+			if repl.done != nil {
+				fmt.Fprintln(repl.out, "pipeline is already running")
+				continue
+			}
+			repl.done = make(chan error, 1)
+			go func() { repl.done <- engine.Run(context.Background(), p, nil, nil) }()
+			repl.wait()
+		default:
+			repl.exec(fields)
+		}
+	}
+}
+
+type debugREPL struct {
+	engine *execution.DebugEngine
+	stop   *execution.Stop
+	done   chan error
+	out    io.Writer
+}
+
+// wait blocks until the running pipeline pauses or finishes.
+func (r *debugREPL) wait() {
+	select {
+	case stop := <-r.engine.Stops():
+		r.stop = stop
+		fmt.Fprintf(r.out, "stopped: %s\n", stop)
+	case err := <-r.done:
+		r.done = nil
+		if err != nil {
+			fmt.Fprintf(r.out, "run failed: %v\n", err)
+		} else {
+			fmt.Fprintln(r.out, "run complete")
+		}
+	}
+}
+
+func (r *debugREPL) exec(fields []string) {
+	switch fields[0] {
+	case "help", "h":
+		fmt.Fprint(r.out, debugHelp)
+	case "break", "b":
+		r.addBreakpoint(fields[1:])
+	case "delete":
+		if len(fields) != 2 {
+			fmt.Fprintln(r.out, "usage: delete <id>")
+			return
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			fmt.Fprintln(r.out, "usage: delete <id>")
+			return
+		}
+		if !r.engine.RemoveBreakpoint(id) {
+			fmt.Fprintf(r.out, "no breakpoint %d\n", id)
+		}
+	case "list":
+		for _, bp := range r.engine.Breakpoints() {
+			if bp.Reason == execution.StopOnConnection {
+				fmt.Fprintf(r.out, "%d: %s %s.%s -> %s.%s\n", bp.ID, bp.Reason, bp.FromComponent, bp.FromPort, bp.ToComponent, bp.ToPort)
+			} else {
+				fmt.Fprintf(r.out, "%d: %s %s\n", bp.ID, bp.Reason, bp.Component)
+			}
+		}
+	case "continue", "c", "step", "s", "abort":
+		if r.stop == nil {
+			fmt.Fprintln(r.out, "not stopped")
+			return
+		}
+		stop := r.stop
+		r.stop = nil
+		switch fields[0] {
+		case "continue", "c":
+			stop.Continue()
+		case "step", "s":
+			stop.Step()
+		default:
+			stop.Abort()
+		}
+		r.wait()
+	case "inputs", "outputs", "packet":
+		if r.stop == nil {
+			fmt.Fprintln(r.out, "not stopped")
+			return
+		}
+		switch fields[0] {
+		case "inputs":
+			printData(r.out, r.stop.Inputs)
+		case "outputs":
+			printData(r.out, r.stop.Outputs)
+		default:
+			fmt.Fprintf(r.out, "%#v\n", r.stop.Packet)
+		}
+	case "set":
+		r.set(fields[1:])
+	default:
+		fmt.Fprintf(r.out, "unknown command %q\n", fields[0])
+	}
+}
+
+func (r *debugREPL) addBreakpoint(args []string) {
+	if len(args) < 2 {
+		fmt.Fprintln(r.out, "usage: break before|after <component> | break conn <from.port> <to.port> [if <text>]")
+		return
+	}
+	var bp *execution.Breakpoint
+	switch args[0] {
+	case "before":
+		bp = r.engine.BreakBefore(args[1], nil)
+	case "after":
+		bp = r.engine.BreakAfter(args[1], nil)
+	case "conn":
+		if len(args) < 3 {
+			fmt.Fprintln(r.out, "usage: break conn <from.port> <to.port> [if <text>]")
+			return
+		}
+		fromComponent, fromPort := splitEndpoint(args[1])
+		toComponent, toPort := splitEndpoint(args[2])
+		var cond execution.Condition
+		if len(args) > 4 && args[3] == "if" {
+			text := strings.Join(args[4:], " ")
+			cond = func(data interface{}) bool { return strings.Contains(fmt.Sprint(data), text) }
+		}
+		bp = r.engine.BreakOnConnection(fromComponent, fromPort, toComponent, toPort, cond)
+	default:
+		fmt.Fprintf(r.out, "unknown breakpoint kind %q\n", args[0])
+		return
+	}
+	fmt.Fprintf(r.out, "breakpoint %d set\n", bp.ID)
+}
+
+func (r *debugREPL) set(args []string) {
+	if r.stop == nil {
+		fmt.Fprintln(r.out, "not stopped")
+		return
+	}
+	if len(args) < 2 {
+		fmt.Fprintln(r.out, "usage: set <port> <value> | set packet <value>")
+		return
+	}
+	value := strings.Join(args[1:], " ")
+	switch {
+	case args[0] == "packet" && r.stop.Reason == execution.StopOnConnection:
+		r.stop.SetPacket(value)
+	case r.stop.Reason == execution.StopBeforeComponent:
+		r.stop.Inputs[args[0]] = value
+	case r.stop.Reason == execution.StopAfterComponent:
+		r.stop.Outputs[args[0]] = value
+	default:
+		fmt.Fprintln(r.out, "nothing to set at this stop")
+	}
+}
+
+func splitEndpoint(endpoint string) (string, string) {
+	if i := strings.LastIndex(endpoint, "."); i >= 0 {
+		return endpoint[:i], endpoint[i+1:]
+	}
+	return endpoint, ""
+}
+
+func printData(w io.Writer, data map[string]interface{}) {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s = %#v\n", name, data[name])
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/spec"
+	"github.com/forrest/go-flow/visualization"
+)
+
+// runDiff compares two pipelines, each given as a spec file or an example name.
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format (text, json, dot)")
+	exitCode := fs.Bool("exit-code", false, "Exit with 1 if the pipelines differ and 3 if the change is breaking")
+	params := make(paramFlags)
+	fs.Var(params, "param", "Spec parameter as name=value, applied to both pipelines (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goflow diff [flags] OLD NEW")
+		fmt.Fprintln(fs.Output(), "OLD and NEW are JSON spec files or example names (simple, file).")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	from, err := loadPipelineRef(fs.Arg(0), params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	to, err := loadPipelineRef(fs.Arg(1), params)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	d := core.DiffPipelines(from, to)
+
+	switch *format {
+	case "text":
+		fmt.Print(d.String())
+	case "json":
+		data, err := d.JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding diff: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+	case "dot":
+		fmt.Print(visualization.DiffToDOT(from, to))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+		return 2
+	}
+
+	if *exitCode {
+		switch d.Compatibility {
+		case core.CompatibilityBreaking:
+			return 3
+		case core.CompatibilityNonBreaking:
+			return 1
+		}
+	}
+	return 0
+}
+
+// loadPipelineRef loads ref as a spec file when such a file exists and as a
+// bundled example otherwise.
+func loadPipelineRef(ref string, params paramFlags) (*core.Pipeline, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return spec.LoadPipeline(ref, params)
+	}
+	return examplePipeline(ref)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Specs for source -> upper -> sink, the same with a larger buffer, and a
+// version without upper.
+const (
+	diffSpecV1 = `{
+  "name": "text", "version": "1.0.0",
+  "components": [
+    {"name": "source", "type": "string_source", "config": {"data": "hello"}},
+    {"name": "upper", "type": "uppercase"},
+    {"name": "sink", "type": "string_sink"}
+  ],
+  "connections": [
+    {"from": "source.output", "to": "upper.input"},
+    {"from": "upper.output", "to": "sink.input"}
+  ]
+}`
+	diffSpecBuffered = `{
+  "name": "text", "version": "1.1.0",
+  "components": [
+    {"name": "source", "type": "string_source", "config": {"data": "hello"}},
+    {"name": "upper", "type": "uppercase"},
+    {"name": "sink", "type": "string_sink"}
+  ],
+  "connections": [
+    {"from": "source.output", "to": "upper.input", "buffer_size": 5},
+    {"from": "upper.output", "to": "sink.input"}
+  ]
+}`
+	diffSpecV2 = `{
+  "name": "text", "version": "2.0.0",
+  "components": [
+    {"name": "source", "type": "string_source", "config": {"data": "hello"}},
+    {"name": "sink", "type": "string_sink"}
+  ],
+  "connections": [
+    {"from": "source.output", "to": "sink.input"}
+  ]
+}`
+)
+
+func writeSpec(t *testing.T, name, spec string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".json")
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// captureDiff runs the diff command and returns its standard output and exit
+// code.
+func captureDiff(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	code := runDiff(args)
+	w.Close()
+	return <-output, code
+}
+
+func TestDiffFormats(t *testing.T) {
+	v1 := writeSpec(t, "v1", diffSpecV1)
+	v2 := writeSpec(t, "v2", diffSpecV2)
+
+	text, code := captureDiff(t, v1, v2)
+	if code != 0 {
+		t.Errorf("Expected exit code 0 without -exit-code, got %d", code)
+	}
+	if !strings.HasPrefix(text, `Pipeline "text" 1.0.0 -> 2.0.0: breaking`) || !strings.Contains(text, "upper") {
+		t.Errorf("Unexpected text diff:\n%s", text)
+	}
+
+	data, _ := captureDiff(t, "-format", "json", v1, v2)
+	var diff struct {
+		Compatibility string   `json:"compatibility"`
+		Breaking      []string `json:"breaking"`
+	}
+	if err := json.Unmarshal([]byte(data), &diff); err != nil {
+		t.Fatalf("Expected JSON output, got %v:\n%s", err, data)
+	}
+	if diff.Compatibility != "breaking" || len(diff.Breaking) == 0 {
+		t.Errorf("Expected a breaking JSON diff, got %+v", diff)
+	}
+
+	dot, _ := captureDiff(t, "-format", "dot", v1, v2)
+	if !strings.HasPrefix(dot, `digraph "text" {`) || !strings.Contains(dot, `"upper" [`) || !strings.Contains(dot, "color=red") {
+		t.Errorf("Unexpected DOT diff:\n%s", dot)
+	}
+
+	if _, code := captureDiff(t, "-format", "yaml", v1, v2); code != 2 {
+		t.Errorf("Expected exit code 2 for an unknown format, got %d", code)
+	}
+}
+
+func TestDiffExitCodes(t *testing.T) {
+	v1 := writeSpec(t, "v1", diffSpecV1)
+	buffered := writeSpec(t, "buffered", diffSpecBuffered)
+	v2 := writeSpec(t, "v2", diffSpecV2)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"identical", []string{"-exit-code", v1, v1}, 0},
+		{"non-breaking", []string{"-exit-code", v1, buffered}, 1},
+		{"breaking", []string{"-exit-code", v1, v2}, 3},
+		{"missing argument", []string{v1}, 2},
+		{"unknown pipeline", []string{v1, "missing"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, code := captureDiff(t, tt.args...); code != tt.want {
+				t.Errorf("Expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
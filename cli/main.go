@@ -18,19 +18,24 @@ type ExampleSource struct {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "plan":
+			os.Exit(runPlan(os.Args[2:]))
+		case "debug":
+			os.Exit(runDebug(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		}
+	}
+
 	format := flag.String("T", "dot", "Output format (dot, svg, png)")
 	example := flag.String("example", "simple", "Example pipeline to generate (simple, file)")
 	flag.Parse()
 
-	var p *core.Pipeline
-
-	switch *example {
-	case "simple":
-		p = createSimplePipeline()
-	case "file":
-		p = create_file_processing_pipeline()
-	default:
-		fmt.Printf("Unknown example: %s\n", *example)
+	p, err := examplePipeline(*example)
+	if err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
@@ -50,6 +55,18 @@ func main() {
 	}
 }
 
+// examplePipeline builds one of the bundled example pipelines by name.
+func examplePipeline(name string) (*core.Pipeline, error) {
+	switch name {
+	case "simple":
+		return createSimplePipeline(), nil
+	case "file":
+		return create_file_processing_pipeline(), nil
+	default:
+		return nil, fmt.Errorf("Unknown example: %s", name)
+	}
+}
+
 func createSimplePipeline() *core.Pipeline {
 	p := core.NewPipeline("simple-example")
 	p.AddComponent("source", components.NewStringSource("hello world"))
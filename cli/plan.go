@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runPlan prints the execution plan of an example or spec pipeline without running it.
+func runPlan(args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	source := addPipelineFlags(fs, "Example pipeline to plan")
+	format := fs.String("format", "text", "Output format (text, json)")
+	fs.Parse(args)
+
+	p, err := source.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	plan, err := p.Plan()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error planning pipeline: %v\n", err)
+		return 1
+	}
+
+	switch *format {
+	case "text":
+		fmt.Print(plan.String())
+	case "json":
+		data, err := plan.JSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding plan: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/spec"
+)
+
+// paramFlags collects repeated -param name=value flags. Values that parse as
+// JSON keep their JSON type; anything else is a string.
+type paramFlags map[string]interface{}
+
+func (f paramFlags) String() string {
+	return fmt.Sprint(map[string]interface{}(f))
+}
+
+func (f paramFlags) Set(value string) error {
+	name, raw, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err == nil {
+		f[name] = decoded
+	} else {
+		f[name] = raw
+	}
+	return nil
+}
+
+// pipelineSource selects the pipeline a command works on: a bundled example or
+// a spec file with parameters.
+type pipelineSource struct {
+	example string
+	spec    string
+	params  paramFlags
+}
+
+// addPipelineFlags registers -example, -spec and -param on fs.
+func addPipelineFlags(fs *flag.FlagSet, usage string) *pipelineSource {
+	src := &pipelineSource{params: make(paramFlags)}
+	fs.StringVar(&src.example, "example", "simple", usage+" (simple, file)")
+	fs.StringVar(&src.spec, "spec", "", "Load the pipeline from a JSON spec file instead of an example")
+	fs.Var(src.params, "param", "Spec parameter as name=value (repeatable)")
+	return src
+}
+
+func (src *pipelineSource) load() (*core.Pipeline, error) {
+	if src.spec != "" {
+		return spec.LoadPipeline(src.spec, src.params)
+	}
+	return examplePipeline(src.example)
+}
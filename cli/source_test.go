@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNullParamFlag(t *testing.T) {
+	path := writeSpec(t, "spec", `{
+  "name": "text",
+  "parameters": [{"name": "text", "type": "string", "default": "hi"}],
+  "components": [{"name": "source", "type": "string_source", "config": {"data": "${text}"}}]
+}`)
+	src := &pipelineSource{spec: path, params: make(paramFlags)}
+	if err := src.params.Set("text=null"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.load(); err == nil || !strings.Contains(err.Error(), "parameter 'text' is null") {
+		t.Errorf("Expected -param text=null to be rejected, got %v", err)
+	}
+
+	delete(src.params, "text")
+	if _, err := src.load(); err != nil {
+		t.Errorf("Expected the default to be used, got %v", err)
+	}
+}
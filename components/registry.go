@@ -0,0 +1,84 @@
+package components
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/forrest/go-flow/core"
+)
+
+var stringType = reflect.TypeOf("")
+
+func init() {
+	RegisterBuiltins(core.DefaultRegistry)
+}
+
+// RegisterBuiltins registers the components of this package with r under the
+// type names used in spec files.
+func RegisterBuiltins(r *core.ComponentRegistry) {
+	register(r, "string_source", []core.Parameter{
+		{Name: "data", Type: stringType, Default: "", Description: "String to emit"},
+	}, func(config map[string]interface{}) (core.Component, error) {
+		data, err := stringParam(config, "data")
+		if err != nil {
+			return nil, err
+		}
+		return NewStringSource(data), nil
+	})
+	register(r, "string_sink", nil, func(config map[string]interface{}) (core.Component, error) {
+		return NewStringSink(), nil
+	})
+	register(r, "uppercase", nil, func(config map[string]interface{}) (core.Component, error) {
+		return NewUpperCase(), nil
+	})
+	register(r, "grep", []core.Parameter{
+		{Name: "pattern", Type: stringType, Default: "", Description: "Substring a line must contain"},
+	}, func(config map[string]interface{}) (core.Component, error) {
+		pattern, err := stringParam(config, "pattern")
+		if err != nil {
+			return nil, err
+		}
+		return NewGrep(pattern), nil
+	})
+	register(r, "file_reader", []core.Parameter{
+		{Name: "path", Type: stringType, Default: "", Description: "File to read"},
+	}, func(config map[string]interface{}) (core.Component, error) {
+		path, err := stringParam(config, "path")
+		if err != nil {
+			return nil, err
+		}
+		return NewFileReader(path), nil
+	})
+	register(r, "file_writer", []core.Parameter{
+		{Name: "path", Type: stringType, Default: "", Description: "File to write"},
+	}, func(config map[string]interface{}) (core.Component, error) {
+		path, err := stringParam(config, "path")
+		if err != nil {
+			return nil, err
+		}
+		return NewFileWriter(path), nil
+	})
+}
+
+// register adds a factory that checks the configuration against params before
+// calling create. Registration errors are ignored so that registering twice is
+// harmless.
+func register(r *core.ComponentRegistry, name string, params []core.Parameter, create func(config map[string]interface{}) (core.Component, error)) {
+	r.Register(name, func(config map[string]interface{}) (core.Component, error) {
+		values, err := core.ResolveParameters(params, config)
+		if err != nil {
+			return nil, err
+		}
+		return create(values)
+	})
+}
+
+// stringParam returns the string parameter called name, or an error if it is
+// missing, null or not a string.
+func stringParam(config map[string]interface{}, name string) (string, error) {
+	value, ok := config[name].(string)
+	if !ok {
+		return "", fmt.Errorf("parameter '%s': expected string, got %T", name, config[name])
+	}
+	return value, nil
+}
@@ -0,0 +1,26 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/forrest/go-flow/core"
+)
+
+func TestRegistryRejectsBadConfig(t *testing.T) {
+	r := core.NewComponentRegistry()
+	RegisterBuiltins(r)
+
+	for _, config := range []map[string]interface{}{
+		{"data": nil},
+		{"data": 3},
+	} {
+		_, err := r.Create("string_source", config)
+		if err == nil || !strings.Contains(err.Error(), "parameter 'data'") {
+			t.Errorf("Create(%v): expected an error for parameter 'data', got %v", config, err)
+		}
+	}
+	if _, err := r.Create("grep", map[string]interface{}{"pattern": "x"}); err != nil {
+		t.Errorf("Expected a valid config to be accepted, got %v", err)
+	}
+}
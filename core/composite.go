@@ -0,0 +1,299 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Names of the boundary components added around a sub-pipeline while it runs
+// as a component. They are reserved inside pipelines that are run that way.
+const (
+	compositeInputName  = "__inputs"
+	compositeOutputName = "__outputs"
+)
+
+// portMapping maps an external port of a composite pipeline to an inner port.
+type portMapping struct {
+	name      string
+	component string
+	port      Port
+	input     bool
+}
+
+// exposedPort presents an inner port under its external name.
+type exposedPort struct {
+	Port
+	name string
+}
+
+// Name returns the external name of the port.
+func (p *exposedPort) Name() string {
+	return p.name
+}
+
+// Expose makes a port of an inner component available under name when the
+// pipeline is used as a component. Whether the port is an input or an output
+// is taken from the component. Once any port is exposed, only exposed ports
+// are visible from outside.
+func (p *Pipeline) Expose(name, component, port string) *Pipeline {
+	c, ok := p.components[component]
+	if !ok {
+		p.errors = append(p.errors, fmt.Errorf("cannot expose '%s': component '%s' not found", name, component))
+		return p
+	}
+
+	in := portByName(c.InputPorts(), port)
+	out := portByName(c.OutputPorts(), port)
+	var mapping portMapping
+	switch {
+	case in != nil && out != nil:
+		p.errors = append(p.errors, fmt.Errorf("cannot expose '%s': component '%s' has both an input and an output port named '%s'", name, component, port))
+		return p
+	case in != nil:
+		for _, conn := range p.connections {
+			if conn.ToComponent == component && conn.ToPort == port {
+				p.errors = append(p.errors, fmt.Errorf("cannot expose '%s': input port '%s.%s' is already connected", name, component, port))
+				return p
+			}
+		}
+		mapping = portMapping{name: name, component: component, port: in, input: true}
+	case out != nil:
+		mapping = portMapping{name: name, component: component, port: out}
+	default:
+		p.errors = append(p.errors, fmt.Errorf("cannot expose '%s': port '%s' not found on component '%s'", name, port, component))
+		return p
+	}
+
+	for _, m := range p.exposed {
+		if m.name == name && m.input == mapping.input {
+			p.errors = append(p.errors, fmt.Errorf("cannot expose '%s': name already exposed by %s.%s", name, m.component, m.port.Name()))
+			return p
+		}
+	}
+	p.exposed = append(p.exposed, mapping)
+	return p
+}
+
+// InputPorts returns the input ports of the pipeline.
+func (p *Pipeline) InputPorts() []Port {
+	return mappedPorts(p.portMappings(true))
+}
+
+// OutputPorts returns the output ports of the pipeline.
+func (p *Pipeline) OutputPorts() []Port {
+	return mappedPorts(p.portMappings(false))
+}
+
+// portMappings returns the external ports of one direction. Explicitly exposed
+// ports take precedence; without them every unconnected port is exposed, under
+// its own name when that is unique and as "component.port" otherwise.
+func (p *Pipeline) portMappings(input bool) []portMapping {
+	var explicit, mappings []portMapping
+	for _, m := range p.exposed {
+		if m.input == input {
+			explicit = append(explicit, m)
+		}
+	}
+	if len(p.exposed) > 0 {
+		return explicit
+	}
+
+	names := make([]string, 0, len(p.components))
+	for name := range p.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counts := make(map[string]int)
+	for _, name := range names {
+		component := p.components[name]
+		ports := component.OutputPorts()
+		if input {
+			ports = component.InputPorts()
+		}
+		for _, port := range ports {
+			if p.isConnected(name, port.Name(), input) {
+				continue
+			}
+			mappings = append(mappings, portMapping{name: port.Name(), component: name, port: port, input: input})
+			counts[port.Name()]++
+		}
+	}
+	for i := range mappings {
+		if counts[mappings[i].name] > 1 {
+			mappings[i].name = mappings[i].component + "." + mappings[i].port.Name()
+		}
+	}
+	return mappings
+}
+
+func (p *Pipeline) isConnected(component, port string, input bool) bool {
+	for _, conn := range p.connections {
+		if input && conn.ToComponent == component && conn.ToPort == port {
+			return true
+		}
+		if !input && conn.FromComponent == component && conn.FromPort == port {
+			return true
+		}
+	}
+	return false
+}
+
+func mappedPorts(mappings []portMapping) []Port {
+	ports := make([]Port, 0, len(mappings))
+	for _, m := range mappings {
+		if m.name == m.port.Name() {
+			ports = append(ports, m.port)
+		} else {
+			ports = append(ports, &exposedPort{Port: m.port, name: m.name})
+		}
+	}
+	return ports
+}
+
+// Process runs the pipeline as a component. Every call runs a new Instance
+// through the lifecycle of Run on the pipeline's engine (or a default engine
+// when none is set), with a context that is cancelled when Process returns.
+// The components the instance creates afresh are initialized and cleaned up
+// by every call; the shared ones belong to the lifecycle of the pipeline
+// itself, which the enclosing pipeline runs when it initializes and cleans up
+// its components. Failures are returned as a PipelineError attributed to the
+// sub-pipeline.
+func (p *Pipeline) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if len(p.errors) > 0 {
+		return nil, fmt.Errorf("sub-pipeline %s has %d construction errors: %w", p.name, len(p.errors), p.errors[0])
+	}
+	for _, reserved := range []string{compositeInputName, compositeOutputName} {
+		if _, ok := p.components[reserved]; ok {
+			return nil, fmt.Errorf("sub-pipeline %s uses reserved component name '%s'", p.name, reserved)
+		}
+	}
+
+	engine := p.engine
+	if engine == nil {
+		if defaultEngineCreator == nil {
+			return nil, fmt.Errorf("sub-pipeline %s has no engine and no default engine creator is registered", p.name)
+		}
+		engine = defaultEngineCreator()
+		defer engine.Close()
+	}
+
+	inputMappings := p.portMappings(true)
+	outputMappings := p.portMappings(false)
+
+	// Feed the inputs through a source component and collect the outputs with a
+	// sink, so the inner pipeline never depends on external channel naming.
+	source := &compositeInput{values: make(map[string]interface{}, len(inputMappings))}
+	for _, m := range inputMappings {
+		value, ok := inputs[m.name]
+		if !ok {
+			if m.port.Required() && m.port.DefaultValue() == nil {
+				return nil, fmt.Errorf("sub-pipeline %s: required input '%s' not provided", p.name, m.name)
+			}
+			value = m.port.DefaultValue()
+		}
+		source.values[m.name] = value
+		source.Outputs = append(source.Outputs, &exposedPort{Port: m.port, name: m.name})
+	}
+	sink := &compositeOutput{values: make(map[string]interface{}, len(outputMappings))}
+	for _, m := range outputMappings {
+		sink.Inputs = append(sink.Inputs, &exposedPort{Port: m.port, name: m.name})
+	}
+
+	run := p.Instance()
+	run.engine = engine
+	run.shared = make(map[string]bool)
+	for name, component := range p.components {
+		if run.components[name] == component {
+			run.shared[name] = true
+		}
+	}
+	run.AddComponent(compositeInputName, source)
+	run.AddComponent(compositeOutputName, sink)
+	for _, m := range inputMappings {
+		run.connections = append(run.connections, compositeConnection(compositeInputName, m.name, m.component, m.port.Name()))
+	}
+	for _, m := range outputMappings {
+		run.connections = append(run.connections, compositeConnection(m.component, m.port.Name(), compositeOutputName, m.name))
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if p.config != nil && p.config.Timeout > 0 {
+		subCtx, cancel = context.WithTimeout(subCtx, p.config.Timeout)
+		defer cancel()
+	}
+
+	if err := run.runLifecycle(subCtx, nil, nil); err != nil {
+		pipelineErr := NewPipelineError(fmt.Sprintf("sub-pipeline failed: %v", err), p.name, RuntimeError, Error, false).
+			WithOriginalError(err).
+			WithContext("execution_id", run.context.ExecutionID)
+		p.errorCollector.Collect(pipelineErr)
+		return nil, pipelineErr
+	}
+	return sink.collected(), nil
+}
+
+func compositeConnection(fromComponent, fromPort, toComponent, toPort string) Connection {
+	return Connection{
+		FromComponent: fromComponent,
+		FromPort:      fromPort,
+		ToComponent:   toComponent,
+		ToPort:        toPort,
+		Name:          connectionName(fromComponent, fromPort, toComponent, toPort),
+		Metadata:      make(map[string]interface{}),
+	}
+}
+
+// compositeInput emits the inputs of a sub-pipeline.
+type compositeInput struct {
+	BaseComponent
+	values map[string]interface{}
+}
+
+func (c *compositeInput) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(c.values))
+	for name, value := range c.values {
+		outputs[name] = value
+	}
+	return outputs, nil
+}
+
+// compositeOutput collects the outputs of a sub-pipeline.
+type compositeOutput struct {
+	BaseComponent
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+func (c *compositeOutput) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, value := range inputs {
+		if value != nil {
+			c.values[name] = value
+		}
+	}
+	return nil, nil
+}
+
+func (c *compositeOutput) collected() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	outputs := make(map[string]interface{}, len(c.values))
+	for name, value := range c.values {
+		outputs[name] = value
+	}
+	return outputs
+}
+
+func portByName(ports []Port, name string) Port {
+	for _, port := range ports {
+		if port.Name() == name {
+			return port
+		}
+	}
+	return nil
+}
@@ -0,0 +1,664 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConstraintError is a structured validation failure. Path locates the
+// failing value inside the validated data, starting at "$" for the value
+// itself, e.g. "$.address.zip" or "$.items[2]".
+type ConstraintError struct {
+	Path       string
+	Constraint string
+	Message    string
+	Value      interface{}
+	// Causes holds the failures of each alternative of an AnyOf.
+	Causes []*ConstraintError
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+func constraintErrorf(path, constraint string, value interface{}, format string, args ...interface{}) *ConstraintError {
+	return &ConstraintError{Path: path, Constraint: constraint, Message: fmt.Sprintf(format, args...), Value: value}
+}
+
+// asConstraintError returns err as a ConstraintError, wrapping plain errors
+// returned by simple constraints.
+func asConstraintError(err error, path, constraint string, value interface{}) *ConstraintError {
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	return &ConstraintError{Path: path, Constraint: constraint, Message: err.Error(), Value: value}
+}
+
+func anyOfError(path, constraint string, value interface{}, causes []*ConstraintError) *ConstraintError {
+	messages := make([]string, len(causes))
+	for i, cause := range causes {
+		messages[i] = cause.Error()
+	}
+	e := constraintErrorf(path, constraint, value, "value matches none of the alternatives: %s", strings.Join(messages, "; "))
+	e.Causes = causes
+	return e
+}
+
+// rebase moves an error reported relative to a nested value under prefix.
+func (e *ConstraintError) rebase(prefix string) *ConstraintError {
+	moved := *e
+	moved.Path = prefix + strings.TrimPrefix(e.Path, "$")
+	moved.Causes = make([]*ConstraintError, len(e.Causes))
+	for i, cause := range e.Causes {
+		moved.Causes[i] = cause.rebase(prefix)
+	}
+	return &moved
+}
+
+// JSONSchemaConstraint is implemented by constraints that can be expressed
+// as JSON Schema keywords. ApplyJSONSchema adds the keywords to node and
+// reports false if the constraint has no equivalent.
+type JSONSchemaConstraint interface {
+	Constraint
+	ApplyJSONSchema(node *SchemaNode) bool
+}
+
+// constraintNode expresses a constraint as a standalone schema node.
+func constraintNode(c Constraint) (*SchemaNode, bool) {
+	node := &SchemaNode{}
+	if !applyConstraintKeywords(node, c) {
+		return nil, false
+	}
+	return node, true
+}
+
+// EnumConstraint requires the value to equal one of Values. Values are
+// compared by their JSON encoding, so 1 and 1.0 are equal.
+type EnumConstraint struct {
+	Values []interface{}
+}
+
+// OneOf creates an EnumConstraint.
+func OneOf(values ...interface{}) *EnumConstraint {
+	return &EnumConstraint{Values: values}
+}
+
+func (c *EnumConstraint) Validate(data interface{}) error {
+	if !containsJSONValue(c.Values, data) {
+		return constraintErrorf("$", c.Description(), data, "value %v is not one of %v", data, c.Values)
+	}
+	return nil
+}
+
+func (c *EnumConstraint) Description() string {
+	return fmt.Sprintf("must be one of %v", c.Values)
+}
+
+func (c *EnumConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	node.Enum = c.Values
+	return true
+}
+
+// CollectionLengthConstraint bounds the number of elements of a slice, array
+// or map. A zero MaxLength means no upper bound.
+type CollectionLengthConstraint struct {
+	MinLength int
+	MaxLength int
+}
+
+func (c *CollectionLengthConstraint) Validate(data interface{}) error {
+	val := indirect(reflect.ValueOf(data))
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+	default:
+		return fmt.Errorf("collection length constraint cannot be applied to type %T", data)
+	}
+	length := val.Len()
+	if length < c.MinLength {
+		return constraintErrorf("$", c.Description(), data, "collection has %d elements, fewer than %d", length, c.MinLength)
+	}
+	if c.MaxLength > 0 && length > c.MaxLength {
+		return constraintErrorf("$", c.Description(), data, "collection has %d elements, more than %d", length, c.MaxLength)
+	}
+	return nil
+}
+
+func (c *CollectionLengthConstraint) Description() string {
+	switch {
+	case c.MinLength > 0 && c.MaxLength > 0:
+		return fmt.Sprintf("must have between %d and %d elements", c.MinLength, c.MaxLength)
+	case c.MaxLength > 0:
+		return fmt.Sprintf("must have at most %d elements", c.MaxLength)
+	default:
+		return fmt.Sprintf("must have at least %d elements", c.MinLength)
+	}
+}
+
+func (c *CollectionLengthConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	var min, max *int
+	if c.MinLength > 0 {
+		n := c.MinLength
+		min = &n
+	}
+	if c.MaxLength > 0 {
+		n := c.MaxLength
+		max = &n
+	}
+	if node.Type.Has("object") {
+		node.MinProperties, node.MaxProperties = min, max
+	} else {
+		node.MinItems, node.MaxItems = min, max
+	}
+	return true
+}
+
+// UniqueConstraint requires the elements of a slice or array to be distinct.
+type UniqueConstraint struct{}
+
+func (c *UniqueConstraint) Validate(data interface{}) error {
+	val := indirect(reflect.ValueOf(data))
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return fmt.Errorf("unique constraint cannot be applied to type %T", data)
+	}
+	if i, j, ok := firstDuplicate(val); ok {
+		return constraintErrorf("$", c.Description(), data, "items %d and %d are equal", i, j)
+	}
+	return nil
+}
+
+func (c *UniqueConstraint) Description() string {
+	return "elements must be unique"
+}
+
+func (c *UniqueConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	node.UniqueItems = true
+	return true
+}
+
+// firstDuplicate returns the indexes of the first two equal elements.
+func firstDuplicate(arr reflect.Value) (int, int, bool) {
+	seen := make(map[string]int, arr.Len())
+	for j := 0; j < arr.Len(); j++ {
+		item := arr.Index(j).Interface()
+		key, err := json.Marshal(item)
+		if err != nil {
+			for i := 0; i < j; i++ {
+				if reflect.DeepEqual(arr.Index(i).Interface(), item) {
+					return i, j, true
+				}
+			}
+			continue
+		}
+		if i, ok := seen[string(key)]; ok {
+			return i, j, true
+		}
+		seen[string(key)] = j
+	}
+	return 0, 0, false
+}
+
+// String formats understood by FormatConstraint and asserted by JSON Schema
+// validation.
+const (
+	FormatEmail    = "email"
+	FormatURI      = "uri"
+	FormatUUID     = "uuid"
+	FormatDateTime = "date-time"
+	FormatDate     = "date"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// checkFormat validates s against a known format. Unknown formats pass.
+func checkFormat(format, s string) error {
+	switch format {
+	case FormatEmail:
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return fmt.Errorf("%q is not a valid email address", s)
+		}
+	case FormatURI:
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+			return fmt.Errorf("%q is not a valid absolute URL", s)
+		}
+	case FormatUUID:
+		if !uuidPattern.MatchString(s) {
+			return fmt.Errorf("%q is not a valid UUID", s)
+		}
+	case FormatDateTime:
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("%q is not an RFC 3339 timestamp", s)
+		}
+	case FormatDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return fmt.Errorf("%q is not a date (YYYY-MM-DD)", s)
+		}
+	}
+	return nil
+}
+
+// FormatConstraint validates strings against a well-known format: email,
+// uri (absolute URLs), uuid, date-time (RFC 3339) or date. time.Time values
+// satisfy date-time.
+type FormatConstraint struct {
+	Format string
+}
+
+func (c *FormatConstraint) Validate(data interface{}) error {
+	switch c.Format {
+	case FormatEmail, FormatURI, FormatUUID, FormatDateTime, FormatDate:
+	default:
+		return fmt.Errorf("unsupported format %q", c.Format)
+	}
+	if _, ok := data.(time.Time); ok && c.Format == FormatDateTime {
+		return nil
+	}
+	str, ok := data.(string)
+	if !ok {
+		return fmt.Errorf("format constraint can only be applied to strings, got %T", data)
+	}
+	if err := checkFormat(c.Format, str); err != nil {
+		return constraintErrorf("$", c.Description(), data, "%v", err)
+	}
+	return nil
+}
+
+func (c *FormatConstraint) Description() string {
+	return fmt.Sprintf("must be a valid %s", c.Format)
+}
+
+func (c *FormatConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	node.Format = c.Format
+	return true
+}
+
+// PredicateConstraint validates data with a custom function. Name describes
+// the condition, e.g. "must be even".
+type PredicateConstraint struct {
+	Name string
+	Test func(data interface{}) bool
+}
+
+// Predicate creates a PredicateConstraint.
+func Predicate(name string, test func(data interface{}) bool) *PredicateConstraint {
+	return &PredicateConstraint{Name: name, Test: test}
+}
+
+func (c *PredicateConstraint) Validate(data interface{}) error {
+	if !c.Test(data) {
+		return constraintErrorf("$", c.Name, data, "value %v does not satisfy: %s", data, c.Name)
+	}
+	return nil
+}
+
+func (c *PredicateConstraint) Description() string {
+	return c.Name
+}
+
+// AllOfConstraint requires every constraint to hold.
+type AllOfConstraint struct {
+	Constraints []Constraint
+}
+
+// AllOf creates an AllOfConstraint.
+func AllOf(constraints ...Constraint) *AllOfConstraint {
+	return &AllOfConstraint{Constraints: constraints}
+}
+
+func (c *AllOfConstraint) Validate(data interface{}) error {
+	for _, constraint := range c.Constraints {
+		if err := constraint.Validate(data); err != nil {
+			return asConstraintError(err, "$", constraint.Description(), data)
+		}
+	}
+	return nil
+}
+
+func (c *AllOfConstraint) Description() string {
+	return "all of (" + describeAll(c.Constraints) + ")"
+}
+
+func (c *AllOfConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	nodes, ok := constraintNodes(c.Constraints)
+	if ok {
+		node.AllOf = append(node.AllOf, nodes...)
+	}
+	return ok
+}
+
+// AnyOfConstraint requires at least one constraint to hold.
+type AnyOfConstraint struct {
+	Constraints []Constraint
+}
+
+// AnyOf creates an AnyOfConstraint.
+func AnyOf(constraints ...Constraint) *AnyOfConstraint {
+	return &AnyOfConstraint{Constraints: constraints}
+}
+
+func (c *AnyOfConstraint) Validate(data interface{}) error {
+	causes := make([]*ConstraintError, 0, len(c.Constraints))
+	for _, constraint := range c.Constraints {
+		err := constraint.Validate(data)
+		if err == nil {
+			return nil
+		}
+		causes = append(causes, asConstraintError(err, "$", constraint.Description(), data))
+	}
+	return anyOfError("$", c.Description(), data, causes)
+}
+
+func (c *AnyOfConstraint) Description() string {
+	return "any of (" + describeAll(c.Constraints) + ")"
+}
+
+func (c *AnyOfConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	nodes, ok := constraintNodes(c.Constraints)
+	if ok && node.AnyOf == nil {
+		node.AnyOf = nodes
+		return true
+	}
+	return false
+}
+
+// NotConstraint requires the wrapped constraint to fail.
+type NotConstraint struct {
+	Constraint Constraint
+}
+
+// Not creates a NotConstraint.
+func Not(constraint Constraint) *NotConstraint {
+	return &NotConstraint{Constraint: constraint}
+}
+
+func (c *NotConstraint) Validate(data interface{}) error {
+	if c.Constraint.Validate(data) == nil {
+		return constraintErrorf("$", c.Description(), data, "value %v must not satisfy: %s", data, c.Constraint.Description())
+	}
+	return nil
+}
+
+func (c *NotConstraint) Description() string {
+	return "not (" + c.Constraint.Description() + ")"
+}
+
+func (c *NotConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	sub, ok := constraintNode(c.Constraint)
+	if ok && node.Not == nil {
+		node.Not = sub
+		return true
+	}
+	return false
+}
+
+func describeAll(constraints []Constraint) string {
+	descriptions := make([]string, len(constraints))
+	for i, c := range constraints {
+		descriptions[i] = c.Description()
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+func constraintNodes(constraints []Constraint) ([]*SchemaNode, bool) {
+	nodes := make([]*SchemaNode, 0, len(constraints))
+	for _, c := range constraints {
+		node, ok := constraintNode(c)
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, true
+}
+
+// FieldConstraint applies a constraint to values nested inside structs, maps
+// and slices. Path segments are separated by dots; "[n]" selects an element
+// and "[*]" every element, e.g. "address.zip" or "items[*].sku". Struct
+// fields are matched by their json names. Absent values are not checked, as
+// with JSON Schema properties; combine with other constraints to require them.
+type FieldConstraint struct {
+	Path       string
+	Constraint Constraint
+}
+
+// Field creates a FieldConstraint.
+func Field(path string, constraint Constraint) *FieldConstraint {
+	return &FieldConstraint{Path: path, Constraint: constraint}
+}
+
+// Each applies a constraint to every element of a slice, array or map.
+func Each(constraint Constraint) *FieldConstraint {
+	return Field("[*]", constraint)
+}
+
+type pathSegment struct {
+	name  string
+	index int
+	each  bool
+}
+
+func parseFieldPath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name != "" {
+			segments = append(segments, pathSegment{name: name, index: -1})
+		}
+		for rest != "" {
+			inner, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("invalid field path %q: unterminated [", path)
+			}
+			if inner == "*" {
+				segments = append(segments, pathSegment{index: -1, each: true})
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid field path %q: bad index %q", path, inner)
+				}
+				segments = append(segments, pathSegment{index: n})
+			}
+			rest = strings.TrimPrefix(after, "[")
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+		}
+		if name == "" && !strings.Contains(part, "[") {
+			return nil, fmt.Errorf("invalid field path %q: empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+func (c *FieldConstraint) Validate(data interface{}) error {
+	segments, err := parseFieldPath(c.Path)
+	if err != nil {
+		return err
+	}
+	return c.validateAt(reflect.ValueOf(data), segments, "$")
+}
+
+func (c *FieldConstraint) validateAt(val reflect.Value, segments []pathSegment, path string) error {
+	val = indirect(val)
+	if len(segments) == 0 {
+		var data interface{}
+		if val.IsValid() {
+			data = val.Interface()
+		}
+		if err := c.Constraint.Validate(data); err != nil {
+			var ce *ConstraintError
+			if errors.As(err, &ce) {
+				return ce.rebase(path)
+			}
+			return &ConstraintError{Path: path, Constraint: c.Constraint.Description(), Message: err.Error(), Value: data}
+		}
+		return nil
+	}
+	if !val.IsValid() {
+		return nil
+	}
+
+	seg, rest := segments[0], segments[1:]
+	switch {
+	case seg.each:
+		switch val.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < val.Len(); i++ {
+				if err := c.validateAt(val.Index(i), rest, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			keys := val.MapKeys()
+			sortValues(keys)
+			for _, key := range keys {
+				if err := c.validateAt(val.MapIndex(key), rest, fmt.Sprintf("%s.%v", path, key.Interface())); err != nil {
+					return err
+				}
+			}
+		default:
+			return constraintErrorf(path, c.Description(), val.Interface(), "cannot iterate over %s", val.Type())
+		}
+		return nil
+	case seg.name == "":
+		if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+			return constraintErrorf(path, c.Description(), val.Interface(), "cannot index %s", val.Type())
+		}
+		if seg.index >= val.Len() {
+			return nil
+		}
+		return c.validateAt(val.Index(seg.index), rest, fmt.Sprintf("%s[%d]", path, seg.index))
+	default:
+		child, ok, err := selectField(val, seg.name)
+		if err != nil {
+			return constraintErrorf(path, c.Description(), val.Interface(), "%v", err)
+		}
+		if !ok {
+			return nil
+		}
+		return c.validateAt(child, rest, path+"."+seg.name)
+	}
+}
+
+func (c *FieldConstraint) Description() string {
+	return c.Path + ": " + c.Constraint.Description()
+}
+
+// ApplyJSONSchema nests the wrapped constraint's keywords under the
+// properties and items the path selects. Paths with element indexes have no
+// equivalent.
+func (c *FieldConstraint) ApplyJSONSchema(node *SchemaNode) bool {
+	segments, err := parseFieldPath(c.Path)
+	if err != nil {
+		return false
+	}
+	for _, seg := range segments {
+		if seg.name == "" && !seg.each {
+			return false
+		}
+	}
+
+	target, ok := constraintNode(c.Constraint)
+	if !ok {
+		return false
+	}
+	current := node
+	for _, seg := range segments {
+		var next *SchemaNode
+		switch {
+		case !seg.each:
+			if current.Properties == nil {
+				current.Properties = make(map[string]*SchemaNode)
+			}
+			if current.Properties[seg.name] == nil {
+				current.Properties[seg.name] = &SchemaNode{}
+			}
+			next = current.Properties[seg.name]
+		case current.Type.Has("object"):
+			if current.AdditionalProperties == nil {
+				current.AdditionalProperties = &SchemaNode{}
+			}
+			next = current.AdditionalProperties
+		default:
+			if current.Items == nil {
+				current.Items = &SchemaNode{}
+			}
+			next = current.Items
+		}
+		current = next
+	}
+	current.AllOf = append(current.AllOf, target)
+	return true
+}
+
+// selectField returns the struct field with the given json name, or the map
+// entry with the given key.
+func selectField(val reflect.Value, name string) (reflect.Value, bool, error) {
+	switch val.Kind() {
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false, fmt.Errorf("cannot select %q from %s", name, val.Type())
+		}
+		entry := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+		return entry, entry.IsValid(), nil
+	case reflect.Struct:
+		t := val.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			jsonName, _, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+			if field.Anonymous && jsonName == "" {
+				if inner := indirect(val.Field(i)); inner.Kind() == reflect.Struct {
+					if v, ok, _ := selectField(inner, name); ok {
+						return v, true, nil
+					}
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if jsonName == name || (jsonName == "" && field.Name == name) {
+				return val.Field(i), true, nil
+			}
+		}
+		return reflect.Value{}, false, nil
+	default:
+		return reflect.Value{}, false, fmt.Errorf("cannot select %q from %s", name, val.Type())
+	}
+}
+
+// indirect dereferences pointers and interfaces, returning the zero Value
+// for nil.
+func indirect(val reflect.Value) reflect.Value {
+	for val.IsValid() && (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) {
+		if val.IsNil() {
+			return reflect.Value{}
+		}
+		val = val.Elem()
+	}
+	return val
+}
+
+func sortValues(values []reflect.Value) {
+	less := func(i, j int) bool {
+		return fmt.Sprint(values[i].Interface()) < fmt.Sprint(values[j].Interface())
+	}
+	for i := 1; i < len(values); i++ {
+		for j := i; j > 0 && less(j, j-1); j-- {
+			values[j], values[j-1] = values[j-1], values[j]
+		}
+	}
+}
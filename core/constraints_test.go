@@ -0,0 +1,213 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type constraintLine struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type constraintOrder struct {
+	ID    string           `json:"id"`
+	Email string           `json:"email"`
+	Lines []constraintLine `json:"lines"`
+	Tags  []string         `json:"tags,omitempty"`
+}
+
+func TestSimpleConstraints(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint Constraint
+		valid      []interface{}
+		invalid    []interface{}
+	}{
+		{"enum", OneOf("red", "green", 3), []interface{}{"red", 3, 3.0}, []interface{}{"blue", 4}},
+		{"collection length", &CollectionLengthConstraint{MinLength: 1, MaxLength: 2},
+			[]interface{}{[]int{1}, map[string]int{"a": 1, "b": 2}, [1]string{"x"}},
+			[]interface{}{[]int{}, []int{1, 2, 3}, "not a collection"}},
+		{"unique", &UniqueConstraint{}, []interface{}{[]int{1, 2, 3}, []string{}},
+			[]interface{}{[]int{1, 2, 1}, []interface{}{map[string]int{"a": 1}, map[string]int{"a": 1}}}},
+		{"email", &FormatConstraint{Format: FormatEmail}, []interface{}{"ada@example.com"},
+			[]interface{}{"Ada <ada@example.com>", "ada", 42}},
+		{"uri", &FormatConstraint{Format: FormatURI}, []interface{}{"https://example.com/x", "mailto:ada@example.com"},
+			[]interface{}{"/relative/path", "example.com"}},
+		{"uuid", &FormatConstraint{Format: FormatUUID}, []interface{}{"123e4567-e89b-12d3-a456-426614174000"},
+			[]interface{}{"123e4567", "123e4567-e89b-12d3-a456-42661417400g"}},
+		{"date-time", &FormatConstraint{Format: FormatDateTime}, []interface{}{"2024-01-02T03:04:05Z", time.Now()},
+			[]interface{}{"2024-01-02", "yesterday"}},
+		{"date", &FormatConstraint{Format: FormatDate}, []interface{}{"2024-01-02"}, []interface{}{"2024-13-02"}},
+		{"predicate", Predicate("must be even", func(v interface{}) bool { n, ok := v.(int); return ok && n%2 == 0 }),
+			[]interface{}{2, 0}, []interface{}{3, "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range tt.valid {
+				if err := tt.constraint.Validate(v); err != nil {
+					t.Errorf("Expected %v to be valid: %v", v, err)
+				}
+			}
+			for _, v := range tt.invalid {
+				if err := tt.constraint.Validate(v); err == nil {
+					t.Errorf("Expected %v to be rejected", v)
+				}
+			}
+		})
+	}
+
+	if err := (&FormatConstraint{Format: "hostname"}).Validate("example.com"); err == nil {
+		t.Error("Expected unsupported formats to be rejected")
+	}
+}
+
+func TestCompositeConstraints(t *testing.T) {
+	short := &StringLengthConstraint{MaxLength: 3}
+	upper := &RegexConstraint{Pattern: "^[A-Z]+$"}
+
+	all := AllOf(short, upper)
+	if err := all.Validate("ABC"); err != nil {
+		t.Errorf("AllOf rejected valid value: %v", err)
+	}
+	if err := all.Validate("abc"); err == nil {
+		t.Error("AllOf should fail when one constraint fails")
+	}
+
+	any := AnyOf(OneOf("n/a"), AllOf(short, upper))
+	if err := any.Validate("n/a"); err != nil {
+		t.Errorf("AnyOf rejected valid value: %v", err)
+	}
+	err := any.Validate("abcd")
+	var ce *ConstraintError
+	if !errors.As(err, &ce) || len(ce.Causes) != 2 {
+		t.Fatalf("Expected a ConstraintError with two causes, got %v", err)
+	}
+
+	not := Not(OneOf("admin", "root"))
+	if err := not.Validate("ada"); err != nil {
+		t.Errorf("Not rejected valid value: %v", err)
+	}
+	if err := not.Validate("root"); err == nil {
+		t.Error("Not should fail when the wrapped constraint holds")
+	}
+	if got := not.Description(); got != "not (must be one of [admin root])" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
+
+func TestFieldConstraintPaths(t *testing.T) {
+	constraint := AllOf(
+		Field("email", &FormatConstraint{Format: FormatEmail}),
+		Field("lines", &CollectionLengthConstraint{MinLength: 1}),
+		Field("lines[*].quantity", &NumericRangeConstraint{Min: int64(1)}),
+		Field("tags", Each(&StringLengthConstraint{MinLength: 1})),
+	)
+
+	order := constraintOrder{ID: "o-1", Email: "ada@example.com", Lines: []constraintLine{{"a", 1}, {"b", 2}}}
+	if err := constraint.Validate(order); err != nil {
+		t.Fatalf("Valid order rejected: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		order interface{}
+		path  string
+	}{
+		{"nested field", constraintOrder{Email: "ada@example.com", Lines: []constraintLine{{"a", 1}, {"b", 0}}}, "$.lines[1].quantity"},
+		{"pointer", &constraintOrder{Email: "nope", Lines: []constraintLine{{"a", 1}}}, "$.email"},
+		{"map", map[string]interface{}{"email": "ada@example.com", "lines": []interface{}{}}, "$.lines"},
+		{"each", constraintOrder{Email: "ada@example.com", Lines: []constraintLine{{"a", 1}}, Tags: []string{"x", ""}}, "$.tags[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := constraint.Validate(tt.order)
+			var ce *ConstraintError
+			if !errors.As(err, &ce) {
+				t.Fatalf("Expected a ConstraintError, got %v", err)
+			}
+			if ce.Path != tt.path {
+				t.Errorf("Expected path %s, got %s (%v)", tt.path, ce.Path, err)
+			}
+		})
+	}
+
+	// Absent values are not checked
+	if err := Field("missing.value", OneOf(1)).Validate(map[string]interface{}{}); err != nil {
+		t.Errorf("Absent field should be skipped: %v", err)
+	}
+	if err := Field("lines[", OneOf(1)).Validate(order); err == nil {
+		t.Error("Expected an invalid path to be rejected")
+	}
+}
+
+func TestConstraintsInJSONSchema(t *testing.T) {
+	schema := NewBaseSchema(reflect.TypeOf(constraintOrder{}), "Order")
+	schema.AddConstraint(Field("email", &FormatConstraint{Format: FormatEmail}))
+	schema.AddConstraint(Field("lines", AllOf(&CollectionLengthConstraint{MinLength: 1}, &UniqueConstraint{})))
+	schema.AddConstraint(Field("lines[*].sku", AnyOf(OneOf("a", "b"), Not(&StringLengthConstraint{MaxLength: 3}))))
+	schema.AddConstraint(Predicate("must be shippable", func(interface{}) bool { return true }))
+
+	loaded, err := LoadJSONSchema([]byte(schema.JSONSchema()))
+	if err != nil {
+		t.Fatalf("Export should load: %v\n%s", err, schema.JSONSchema())
+	}
+	if got := loaded.Root().Comment; !strings.Contains(got, "must be shippable") || strings.Contains(got, "email") {
+		t.Errorf("Only the predicate should be left unmapped, got comment %q", got)
+	}
+
+	valid := map[string]interface{}{
+		"id": "o-1", "email": "ada@example.com",
+		"lines": []interface{}{map[string]interface{}{"sku": "a", "quantity": 1}},
+	}
+	if err := loaded.Validate(valid); err != nil {
+		t.Fatalf("Loaded schema rejected valid data: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(map[string]interface{})
+		path string
+	}{
+		{"format", func(d map[string]interface{}) { d["email"] = "nope" }, "$.email"},
+		{"min items", func(d map[string]interface{}) { d["lines"] = []interface{}{} }, "$.lines"},
+		{"unique", func(d map[string]interface{}) {
+			line := map[string]interface{}{"sku": "a", "quantity": 1}
+			d["lines"] = []interface{}{line, line}
+		}, "$.lines"},
+		{"any of", func(d map[string]interface{}) {
+			d["lines"] = []interface{}{map[string]interface{}{"sku": "c", "quantity": 1}}
+		}, "$.lines[0].sku"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]interface{}{}
+			for k, v := range valid {
+				data[k] = v
+			}
+			tt.edit(data)
+			err := loaded.Validate(data)
+			var ce *ConstraintError
+			if !errors.As(err, &ce) || ce.Path != tt.path {
+				t.Errorf("Expected an error at %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestValidatePacketReportsPath(t *testing.T) {
+	port := &BasePort{PortName: "orders", PortType: reflect.TypeOf(constraintOrder{})}
+	port.PortConstraints = []Constraint{Field("lines[*].quantity", &NumericRangeConstraint{Min: int64(1)})}
+
+	err := ValidatePacket("intake", port, DirectionInput, constraintOrder{Lines: []constraintLine{{"a", 0}}})
+	if err == nil {
+		t.Fatal("Expected a validation error")
+	}
+	if got := err.Context()["path"]; got != "$.lines[0].quantity" {
+		t.Errorf("Expected path context $.lines[0].quantity, got %v", got)
+	}
+}
@@ -0,0 +1,612 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeKind classifies a difference between two pipeline versions.
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+	ChangeRewired
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	case ChangeRewired:
+		return "rewired"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the change kind by name.
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Compatibility classifies a pipeline diff as a whole.
+type Compatibility int
+
+const (
+	CompatibilityIdentical Compatibility = iota
+	CompatibilityNonBreaking
+	CompatibilityBreaking
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case CompatibilityIdentical:
+		return "identical"
+	case CompatibilityNonBreaking:
+		return "non-breaking"
+	case CompatibilityBreaking:
+		return "breaking"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the compatibility by name.
+func (c Compatibility) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// Configurable is implemented by components that report their configuration
+// for diffs. Components that do not implement it are compared by their
+// exported fields.
+type Configurable interface {
+	Config() map[string]interface{}
+}
+
+// PipelineDiff is the structural difference between two versions of a pipeline.
+type PipelineDiff struct {
+	Name          string           `json:"name"`
+	FromVersion   string           `json:"from_version"`
+	ToVersion     string           `json:"to_version"`
+	Components    []ComponentDiff  `json:"components,omitempty"`
+	Connections   []ConnectionDiff `json:"connections,omitempty"`
+	ExternalPorts []PortDiff       `json:"external_ports,omitempty"`
+	Compatibility Compatibility    `json:"compatibility"`
+	Breaking      []string         `json:"breaking,omitempty"`
+	Warnings      []string         `json:"warnings,omitempty"`
+}
+
+// ComponentDiff describes an added, removed or modified component.
+type ComponentDiff struct {
+	Name       string       `json:"name"`
+	Kind       ChangeKind   `json:"kind"`
+	OldType    string       `json:"old_type,omitempty"`
+	NewType    string       `json:"new_type,omitempty"`
+	OldVersion string       `json:"old_version,omitempty"`
+	NewVersion string       `json:"new_version,omitempty"`
+	Ports      []PortDiff   `json:"ports,omitempty"`
+	Config     []ConfigDiff `json:"config,omitempty"`
+	Breaking   bool         `json:"breaking"`
+}
+
+// PortDiff describes an added, removed or modified port. Component is empty
+// for the external ports of the pipeline.
+type PortDiff struct {
+	Component   string     `json:"component,omitempty"`
+	Port        string     `json:"port"`
+	Direction   string     `json:"direction"`
+	Kind        ChangeKind `json:"kind"`
+	OldType     string     `json:"old_type,omitempty"`
+	NewType     string     `json:"new_type,omitempty"`
+	OldRequired bool       `json:"old_required,omitempty"`
+	NewRequired bool       `json:"new_required,omitempty"`
+	Breaking    bool       `json:"breaking"`
+}
+
+// ConfigDiff describes a changed configuration value.
+type ConfigDiff struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// ConnectionDiff describes an added, removed, rewired or modified connection.
+// Ports are written as "component.port". For a rewired connection the input
+// port To is now fed from From instead of OldFrom. A modified connection has a
+// different transform, backpressure configuration or buffer size.
+type ConnectionDiff struct {
+	Kind            ChangeKind `json:"kind"`
+	From            string     `json:"from"`
+	To              string     `json:"to"`
+	OldFrom         string     `json:"old_from,omitempty"`
+	OldTransform    string     `json:"old_transform,omitempty"`
+	NewTransform    string     `json:"new_transform,omitempty"`
+	OldBackpressure string     `json:"old_backpressure,omitempty"`
+	NewBackpressure string     `json:"new_backpressure,omitempty"`
+	OldBufferSize   int        `json:"old_buffer_size,omitempty"`
+	NewBufferSize   int        `json:"new_buffer_size,omitempty"`
+	Breaking        bool       `json:"breaking"`
+}
+
+// DiffPipelines compares two versions of a pipeline. Removing components,
+// ports or connections, rewiring, changing port types and adding required
+// inputs are breaking; additions and configuration changes are not.
+func DiffPipelines(from, to *Pipeline) *PipelineDiff {
+	d := &PipelineDiff{
+		Name:        to.Name(),
+		FromVersion: from.GetVersion(),
+		ToVersion:   to.GetVersion(),
+	}
+
+	for _, name := range unionNames(from.components, to.components) {
+		old, inOld := from.components[name]
+		cur, inNew := to.components[name]
+		switch {
+		case !inOld:
+			d.Components = append(d.Components, ComponentDiff{
+				Name: name, Kind: ChangeAdded, NewType: componentType(cur), NewVersion: cur.Version(),
+			})
+		case !inNew:
+			d.Components = append(d.Components, ComponentDiff{
+				Name: name, Kind: ChangeRemoved, OldType: componentType(old), OldVersion: old.Version(), Breaking: true,
+			})
+			d.Breaking = append(d.Breaking, fmt.Sprintf("component %s removed", name))
+		default:
+			if cd, changed := d.diffComponent(name, old, cur); changed {
+				d.Components = append(d.Components, cd)
+			}
+		}
+	}
+
+	d.diffConnections(from.connections, to.connections)
+
+	// Ports exposed implicitly change with every connection, so external ports
+	// are only compared for pipelines that declare them with Expose
+	if len(from.exposed) > 0 || len(to.exposed) > 0 {
+		d.ExternalPorts = append(diffPorts("", "input", from.InputPorts(), to.InputPorts()),
+			diffPorts("", "output", from.OutputPorts(), to.OutputPorts())...)
+	}
+	for _, pd := range d.ExternalPorts {
+		if pd.Breaking {
+			d.Breaking = append(d.Breaking, fmt.Sprintf("external %s port %s %s", pd.Direction, pd.Port, pd.Kind))
+		}
+	}
+
+	switch {
+	case len(d.Breaking) > 0:
+		d.Compatibility = CompatibilityBreaking
+	case len(d.Components) > 0 || len(d.Connections) > 0 || len(d.ExternalPorts) > 0:
+		d.Compatibility = CompatibilityNonBreaking
+	}
+	d.checkVersions()
+	return d
+}
+
+func (d *PipelineDiff) diffComponent(name string, old, cur Component) (ComponentDiff, bool) {
+	cd := ComponentDiff{
+		Name:       name,
+		Kind:       ChangeModified,
+		OldType:    componentType(old),
+		NewType:    componentType(cur),
+		OldVersion: old.Version(),
+		NewVersion: cur.Version(),
+	}
+	if cd.OldType != cd.NewType {
+		cd.Breaking = true
+		d.Breaking = append(d.Breaking, fmt.Sprintf("component %s changed type from %s to %s", name, cd.OldType, cd.NewType))
+	}
+
+	cd.Ports = append(diffPorts(name, "input", old.InputPorts(), cur.InputPorts()),
+		diffPorts(name, "output", old.OutputPorts(), cur.OutputPorts())...)
+	for _, pd := range cd.Ports {
+		if pd.Breaking {
+			cd.Breaking = true
+			d.Breaking = append(d.Breaking, fmt.Sprintf("%s port %s.%s %s", pd.Direction, name, pd.Port, pd.Kind))
+		}
+	}
+	cd.Config = diffConfig(componentConfig(old), componentConfig(cur))
+
+	changed := cd.OldType != cd.NewType || cd.OldVersion != cd.NewVersion || len(cd.Ports) > 0 || len(cd.Config) > 0
+	return cd, changed
+}
+
+func (d *PipelineDiff) diffConnections(from, to []Connection) {
+	key := func(c Connection) string {
+		return c.FromComponent + "." + c.FromPort + " -> " + c.ToComponent + "." + c.ToPort
+	}
+	oldKeys := make(map[string]Connection)
+	for _, c := range from {
+		oldKeys[key(c)] = c
+	}
+	newKeys := make(map[string]Connection)
+	for _, c := range to {
+		newKeys[key(c)] = c
+	}
+
+	var removed, added []Connection
+	for _, c := range from {
+		if _, ok := newKeys[key(c)]; !ok {
+			removed = append(removed, c)
+		}
+	}
+	for _, c := range to {
+		if _, ok := oldKeys[key(c)]; !ok {
+			added = append(added, c)
+		}
+	}
+
+	// A removed and an added connection into the same input port are a rewiring
+	rewired := make(map[int]bool)
+	for _, r := range removed {
+		target := r.ToComponent + "." + r.ToPort
+		source := r.FromComponent + "." + r.FromPort
+		match := -1
+		for i, a := range added {
+			if !rewired[i] && a.ToComponent+"."+a.ToPort == target {
+				match = i
+				break
+			}
+		}
+		if match < 0 {
+			d.Connections = append(d.Connections, ConnectionDiff{Kind: ChangeRemoved, From: source, To: target, Breaking: true})
+			d.Breaking = append(d.Breaking, fmt.Sprintf("connection %s removed", key(r)))
+			continue
+		}
+		rewired[match] = true
+		a := added[match]
+		d.Connections = append(d.Connections, ConnectionDiff{
+			Kind: ChangeRewired, From: a.FromComponent + "." + a.FromPort, To: target, OldFrom: source, Breaking: true,
+		})
+		d.Breaking = append(d.Breaking, fmt.Sprintf("input %s rewired from %s to %s.%s", target, source, a.FromComponent, a.FromPort))
+	}
+	for _, c := range to {
+		old, ok := oldKeys[key(c)]
+		if !ok {
+			continue
+		}
+		cd := ConnectionDiff{
+			Kind:            ChangeModified,
+			From:            c.FromComponent + "." + c.FromPort,
+			To:              c.ToComponent + "." + c.ToPort,
+			OldTransform:    transformName(old.Transform),
+			NewTransform:    transformName(c.Transform),
+			OldBackpressure: describeBackpressure(old.Backpressure),
+			NewBackpressure: describeBackpressure(c.Backpressure),
+			OldBufferSize:   old.BufferSize,
+			NewBufferSize:   c.BufferSize,
+		}
+		if cd.OldTransform != cd.NewTransform || cd.OldBackpressure != cd.NewBackpressure || cd.OldBufferSize != cd.NewBufferSize {
+			d.Connections = append(d.Connections, cd)
+		}
+	}
+	for i, a := range added {
+		if !rewired[i] {
+			d.Connections = append(d.Connections, ConnectionDiff{
+				Kind: ChangeAdded, From: a.FromComponent + "." + a.FromPort, To: a.ToComponent + "." + a.ToPort,
+			})
+		}
+	}
+
+	sort.SliceStable(d.Connections, func(i, j int) bool {
+		if d.Connections[i].To != d.Connections[j].To {
+			return d.Connections[i].To < d.Connections[j].To
+		}
+		return d.Connections[i].From < d.Connections[j].From
+	})
+}
+
+// checkVersions warns when the version change does not match the changes.
+func (d *PipelineDiff) checkVersions() {
+	if d.Compatibility == CompatibilityIdentical {
+		return
+	}
+	from, errFrom := ParseVersion(d.FromVersion)
+	to, errTo := ParseVersion(d.ToVersion)
+	if errFrom != nil || errTo != nil {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("versions %q and %q are not both semantic versions", d.FromVersion, d.ToVersion))
+		return
+	}
+	switch {
+	case to.Compare(from) <= 0:
+		d.Warnings = append(d.Warnings, fmt.Sprintf("version %s is not greater than %s; suggest %s", to, from, d.SuggestedVersion()))
+	case d.Compatibility == CompatibilityBreaking && to.Major == from.Major:
+		d.Warnings = append(d.Warnings, fmt.Sprintf("breaking changes require a major version bump; suggest %s", d.SuggestedVersion()))
+	}
+}
+
+// SuggestedVersion returns the next version after FromVersion that matches the
+// changes: a major bump for breaking changes, a minor bump for additions and a
+// patch bump otherwise. It returns ToVersion when FromVersion cannot be parsed.
+func (d *PipelineDiff) SuggestedVersion() string {
+	v, err := ParseVersion(d.FromVersion)
+	if err != nil {
+		return d.ToVersion
+	}
+	v.PreRelease = ""
+	switch {
+	case d.Compatibility == CompatibilityBreaking:
+		return Version{Major: v.Major + 1}.String()
+	case d.hasAdditions():
+		return Version{Major: v.Major, Minor: v.Minor + 1}.String()
+	case d.Compatibility == CompatibilityNonBreaking:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}.String()
+	default:
+		return v.String()
+	}
+}
+
+func (d *PipelineDiff) hasAdditions() bool {
+	for _, c := range d.Components {
+		if c.Kind == ChangeAdded {
+			return true
+		}
+		for _, p := range c.Ports {
+			if p.Kind == ChangeAdded {
+				return true
+			}
+		}
+	}
+	for _, c := range d.Connections {
+		if c.Kind == ChangeAdded {
+			return true
+		}
+	}
+	for _, p := range d.ExternalPorts {
+		if p.Kind == ChangeAdded {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the diff as text.
+func (d *PipelineDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pipeline %q %s -> %s: %s\n", d.Name, d.FromVersion, d.ToVersion, d.Compatibility)
+
+	if len(d.Components) > 0 {
+		b.WriteString("\nComponents:\n")
+		for _, c := range d.Components {
+			fmt.Fprintf(&b, "  %s %s%s\n", changeSymbol(c.Kind), c.Name, breakingMark(c.Breaking))
+			if c.Kind != ChangeModified {
+				continue
+			}
+			if c.OldType != c.NewType {
+				fmt.Fprintf(&b, "      type: %s -> %s\n", c.OldType, c.NewType)
+			}
+			if c.OldVersion != c.NewVersion {
+				fmt.Fprintf(&b, "      version: %s -> %s\n", c.OldVersion, c.NewVersion)
+			}
+			for _, p := range c.Ports {
+				fmt.Fprintf(&b, "      %s\n", p.describe())
+			}
+			for _, cfg := range c.Config {
+				fmt.Fprintf(&b, "      config %s: %v -> %v\n", cfg.Key, cfg.Old, cfg.New)
+			}
+		}
+	}
+
+	if len(d.Connections) > 0 {
+		b.WriteString("\nConnections:\n")
+		for _, c := range d.Connections {
+			switch c.Kind {
+			case ChangeRewired:
+				fmt.Fprintf(&b, "  %s %s: %s -> %s%s\n", changeSymbol(c.Kind), c.To, c.OldFrom, c.From, breakingMark(c.Breaking))
+			case ChangeModified:
+				fmt.Fprintf(&b, "  %s %s -> %s\n", changeSymbol(c.Kind), c.From, c.To)
+				if c.OldTransform != c.NewTransform {
+					fmt.Fprintf(&b, "      transform: %s -> %s\n", orNone(c.OldTransform), orNone(c.NewTransform))
+				}
+				if c.OldBackpressure != c.NewBackpressure {
+					fmt.Fprintf(&b, "      backpressure: %s -> %s\n", orNone(c.OldBackpressure), orNone(c.NewBackpressure))
+				}
+				if c.OldBufferSize != c.NewBufferSize {
+					fmt.Fprintf(&b, "      buffer: %d -> %d\n", c.OldBufferSize, c.NewBufferSize)
+				}
+			default:
+				fmt.Fprintf(&b, "  %s %s -> %s%s\n", changeSymbol(c.Kind), c.From, c.To, breakingMark(c.Breaking))
+			}
+		}
+	}
+
+	if len(d.ExternalPorts) > 0 {
+		b.WriteString("\nExternal ports:\n")
+		for _, p := range d.ExternalPorts {
+			fmt.Fprintf(&b, "  %s\n", p.describe())
+		}
+	}
+
+	if len(d.Warnings) > 0 {
+		b.WriteString("\nWarnings:\n")
+		for _, w := range d.Warnings {
+			fmt.Fprintf(&b, "  %s\n", w)
+		}
+	}
+	return b.String()
+}
+
+// JSON returns the diff as indented JSON.
+func (d *PipelineDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+func (p PortDiff) describe() string {
+	s := fmt.Sprintf("%s %s port %s", changeSymbol(p.Kind), p.Direction, p.Port)
+	switch p.Kind {
+	case ChangeAdded:
+		s += fmt.Sprintf(" (%s%s)", p.NewType, requiredMark(p.NewRequired))
+	case ChangeRemoved:
+		s += fmt.Sprintf(" (%s%s)", p.OldType, requiredMark(p.OldRequired))
+	case ChangeModified:
+		s += fmt.Sprintf(": %s%s -> %s%s", p.OldType, requiredMark(p.OldRequired), p.NewType, requiredMark(p.NewRequired))
+	}
+	return s + breakingMark(p.Breaking)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func transformName(t DataTransform) string {
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
+func describeBackpressure(bp *BackpressureConfig) string {
+	if bp == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s(buffer=%d, drop=%s, timeout=%s, retries=%d)", bp.Strategy, bp.BufferSize, bp.DropPolicy, bp.Timeout, bp.MaxRetries)
+}
+
+func changeSymbol(kind ChangeKind) string {
+	switch kind {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func breakingMark(breaking bool) string {
+	if breaking {
+		return " [breaking]"
+	}
+	return ""
+}
+
+func requiredMark(required bool) string {
+	if required {
+		return ", required"
+	}
+	return ""
+}
+
+func diffPorts(component, direction string, old, cur []Port) []PortDiff {
+	oldPorts := make(map[string]Port, len(old))
+	for _, p := range old {
+		oldPorts[p.Name()] = p
+	}
+	newPorts := make(map[string]Port, len(cur))
+	for _, p := range cur {
+		newPorts[p.Name()] = p
+	}
+
+	var diffs []PortDiff
+	for _, name := range unionNames(oldPorts, newPorts) {
+		o, inOld := oldPorts[name]
+		n, inNew := newPorts[name]
+		pd := PortDiff{Component: component, Port: name, Direction: direction}
+		switch {
+		case !inOld:
+			pd.Kind = ChangeAdded
+			pd.NewType, pd.NewRequired = typeName(n.Type()), n.Required()
+			pd.Breaking = direction == "input" && n.Required()
+		case !inNew:
+			pd.Kind = ChangeRemoved
+			pd.OldType, pd.OldRequired = typeName(o.Type()), o.Required()
+			pd.Breaking = true
+		default:
+			pd.Kind = ChangeModified
+			pd.OldType, pd.OldRequired = typeName(o.Type()), o.Required()
+			pd.NewType, pd.NewRequired = typeName(n.Type()), n.Required()
+			if o.Type() == n.Type() && o.Required() == n.Required() {
+				continue
+			}
+			pd.Breaking = o.Type() != n.Type() || (direction == "input" && n.Required() && !o.Required())
+		}
+		diffs = append(diffs, pd)
+	}
+	return diffs
+}
+
+func diffConfig(old, cur map[string]interface{}) []ConfigDiff {
+	var diffs []ConfigDiff
+	for _, key := range unionNames(old, cur) {
+		o, n := old[key], cur[key]
+		if !reflect.DeepEqual(o, n) {
+			diffs = append(diffs, ConfigDiff{Key: key, Old: o, New: n})
+		}
+	}
+	return diffs
+}
+
+// componentConfig returns the configuration of a component: Config() when it
+// is Configurable, the parameters of a template instance, or otherwise its
+// exported fields of simple types.
+func componentConfig(c Component) map[string]interface{} {
+	if configurable, ok := c.(Configurable); ok {
+		return configurable.Config()
+	}
+	if p, ok := c.(*Pipeline); ok {
+		params, _ := p.GetMetadata("parameters").(map[string]interface{})
+		return params
+	}
+
+	v := reflect.ValueOf(c)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	config := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Func, reflect.Chan, reflect.Interface, reflect.UnsafePointer, reflect.Ptr:
+			continue
+		}
+		config[field.Name] = v.Field(i).Interface()
+	}
+	return config
+}
+
+func componentType(c Component) string {
+	return reflect.TypeOf(c).String()
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.String()
+}
+
+func unionNames[V any, W any](a map[string]V, b map[string]W) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	for name := range a {
+		seen[name] = true
+	}
+	for name := range b {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// counterComponent is a stateful test component with a configuration field.
+type counterComponent struct {
+	BaseComponent
+	Threshold int
+	count     int
+}
+
+func newCounterComponent(threshold int) *counterComponent {
+	c := &counterComponent{Threshold: threshold}
+	c.Inputs = []Port{&BasePort{PortName: "input", PortType: reflect.TypeOf("")}}
+	return c
+}
+
+func (c *counterComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.count++
+	return nil, nil
+}
+
+func (c *counterComponent) SaveState() (map[string]interface{}, error) {
+	return map[string]interface{}{"total": c.count}, nil
+}
+
+func (c *counterComponent) RestoreState(state map[string]interface{}) error {
+	c.count = state["total"].(int)
+	return nil
+}
+
+func newDiffTestPipelines() (*Pipeline, *Pipeline) {
+	v1 := NewPipeline("diff_test").SetVersion("1.0.0")
+	v1.AddComponent("src", NewTestValidationComponent("src"))
+	v1.AddComponent("b", NewTestValidationComponent("b"))
+	v1.AddComponent("sink", newCounterComponent(1))
+	Connect[string](v1, "src", "output", "b", "input")
+	Connect[string](v1, "b", "output", "sink", "input")
+
+	v2 := NewPipeline("diff_test").SetVersion("2.0.0")
+	v2.AddComponent("src", NewTestValidationComponent("src"))
+	v2.AddComponent("x", NewTestValidationComponent("x"))
+	v2.AddComponent("sink", newCounterComponent(5))
+	Connect[string](v2, "src", "output", "x", "input")
+	Connect[string](v2, "x", "output", "sink", "input")
+	return v1, v2
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v2", "2.0.0", true},
+		{"1.0.0-rc.1+build5", "1.0.0-rc.1", true},
+		{"1.x", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.in)
+		if (err == nil) != tt.ok || (tt.ok && v.String() != tt.want) {
+			t.Errorf("ParseVersion(%q) = %v, %v", tt.in, v, err)
+		}
+	}
+	if MustParseVersion("1.0.0-rc.1").Compare(MustParseVersion("1.0.0")) != -1 {
+		t.Error("Expected pre-release to sort before release")
+	}
+	if MustParseVersion("1.10.0").Compare(MustParseVersion("1.9.9")) != 1 {
+		t.Error("Expected numeric comparison of minor versions")
+	}
+
+	// Pre-release precedence from the semver specification
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2",
+		"1.0.0-beta.11", "1.0.0-rc.1", "1.0.0-rc.2", "1.0.0-rc.10", "1.0.0"}
+	for i := 1; i < len(ordered); i++ {
+		lower, higher := MustParseVersion(ordered[i-1]), MustParseVersion(ordered[i])
+		if lower.Compare(higher) != -1 || higher.Compare(lower) != 1 {
+			t.Errorf("Expected %s < %s", lower, higher)
+		}
+	}
+}
+
+func TestMigratorMatchesParsedVersions(t *testing.T) {
+	migrator := NewMigrator()
+	step := func(cp *Checkpoint) error { return nil }
+	if err := migrator.Register(MigrationStep{From: "1.0", To: "v1.1.0", Migrate: step}); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Register(MigrationStep{From: "1.1", To: "2", Migrate: step}); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Register(MigrationStep{From: "1.0.0", To: "1.1", Migrate: step}); err == nil {
+		t.Error("Expected the same step with other spellings to be rejected")
+	}
+
+	path, err := migrator.Path("1.0.0", "2.0.0")
+	if err != nil || len(path) != 2 {
+		t.Fatalf("Expected a two-step path, got %v, %v", path, err)
+	}
+	if path, err := migrator.Path("1.0", "1.0.0"); err != nil || len(path) != 0 {
+		t.Errorf("Expected no steps between spellings of one version, got %v, %v", path, err)
+	}
+
+	cp := &Checkpoint{Version: "1.0"}
+	migrated, err := migrator.Migrate(cp, "2.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPipeline("p").SetVersion("2.0.0")
+	if err := p.Restore(migrated); err != nil {
+		t.Errorf("Expected %s to restore into %s, got %v", migrated.Version, p.Version(), err)
+	}
+}
+
+func TestDiffPipelines(t *testing.T) {
+	v1, v2 := newDiffTestPipelines()
+	d := DiffPipelines(v1, v2)
+
+	if d.Compatibility != CompatibilityBreaking {
+		t.Fatalf("Expected breaking diff, got %s", d.Compatibility)
+	}
+
+	kinds := make(map[string]ChangeKind)
+	for _, c := range d.Components {
+		kinds[c.Name] = c.Kind
+	}
+	if !reflect.DeepEqual(kinds, map[string]ChangeKind{"b": ChangeRemoved, "x": ChangeAdded, "sink": ChangeModified}) {
+		t.Errorf("Unexpected component changes: %v", kinds)
+	}
+	for _, c := range d.Components {
+		if c.Name == "sink" && (len(c.Config) != 1 || c.Config[0].Key != "Threshold" || c.Breaking) {
+			t.Errorf("Expected a non-breaking Threshold config change, got %+v", c)
+		}
+	}
+
+	var rewired *ConnectionDiff
+	for i, c := range d.Connections {
+		if c.Kind == ChangeRewired {
+			rewired = &d.Connections[i]
+		}
+	}
+	if rewired == nil || rewired.To != "sink.input" || rewired.OldFrom != "b.output" || rewired.From != "x.output" {
+		t.Errorf("Expected sink.input to be rewired from b.output to x.output, got %+v", d.Connections)
+	}
+	if len(d.Connections) != 3 {
+		t.Errorf("Expected removed, added and rewired connections, got %+v", d.Connections)
+	}
+	if len(d.Warnings) != 0 || d.SuggestedVersion() != "2.0.0" {
+		t.Errorf("Expected major bump without warnings, got %v (suggest %s)", d.Warnings, d.SuggestedVersion())
+	}
+
+	v2.SetVersion("1.1.0")
+	if d := DiffPipelines(v1, v2); len(d.Warnings) != 1 || !strings.Contains(d.Warnings[0], "major version bump") {
+		t.Errorf("Expected a warning about the missing major bump, got %v", d.Warnings)
+	}
+
+	text := d.String()
+	for _, want := range []string{"- b [breaking]", "+ x", "config Threshold: 1 -> 5", "~ sink.input: b.output -> x.output [breaking]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected text diff to contain %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestDiffNonBreaking(t *testing.T) {
+	v1 := NewPipeline("p").SetVersion("1.0.0")
+	v1.AddComponent("a", NewTestValidationComponent("a"))
+	v2 := NewPipeline("p").SetVersion("1.0.0")
+	v2.AddComponent("a", NewTestValidationComponent("a"))
+
+	if d := DiffPipelines(v1, v2); d.Compatibility != CompatibilityIdentical {
+		t.Errorf("Expected identical pipelines, got %s", d)
+	}
+
+	v2.AddComponent("extra", newCounterComponent(1))
+	Connect[string](v2, "a", "output", "extra", "input")
+	v2.SetVersion("1.1.0")
+	d := DiffPipelines(v1, v2)
+	if d.Compatibility != CompatibilityNonBreaking || d.SuggestedVersion() != "1.1.0" || len(d.Warnings) != 0 {
+		t.Errorf("Expected a non-breaking minor change, got %s", d)
+	}
+}
+
+func TestCheckpointMigration(t *testing.T) {
+	v1, v2 := newDiffTestPipelines()
+	v1.GetComponents()["sink"].(*counterComponent).count = 3
+
+	cp, err := v1.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	cp.State["b"] = map[string]interface{}{"seen": 1}
+	cp.InFlight = []InFlightPacket{
+		{FromComponent: "src", FromPort: "output", ToComponent: "b", ToPort: "input", Value: "lost"},
+		{FromComponent: "b", FromPort: "output", ToComponent: "sink", ToPort: "input", Value: "kept"},
+	}
+
+	migrator := NewMigrator()
+	if err := migrator.Register(DiffPipelines(v1, v2).MigrationStep()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := migrator.Register(MigrationStep{From: "2.0.0", To: "2.1.0", Migrate: func(cp *Checkpoint) error {
+		cp.State["sink"]["total"] = cp.State["sink"]["total"].(int) * 10
+		return nil
+	}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := migrator.Register(MigrationStep{From: "2.1.0", To: "2.0.0", Migrate: func(*Checkpoint) error { return nil }}); err == nil {
+		t.Error("Expected a backwards step to be rejected")
+	}
+
+	migrated, err := migrator.Migrate(cp, "2.1.0")
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if cp.Version != "1.0.0" || cp.State["sink"]["total"] != 3 {
+		t.Errorf("Expected the original checkpoint to be unchanged, got %+v", cp)
+	}
+	if _, ok := migrated.State["b"]; ok {
+		t.Error("Expected state of removed component b to be dropped")
+	}
+	if len(migrated.InFlight) != 1 || migrated.InFlight[0].FromComponent != "x" || migrated.InFlight[0].Value != "kept" {
+		t.Errorf("Expected the in-flight packet to move to x.output, got %+v", migrated.InFlight)
+	}
+
+	if err := v2.Restore(migrated); err == nil {
+		t.Error("Expected restore into a different version to fail")
+	}
+	v2.SetVersion("2.1.0")
+	if err := v2.Restore(migrated); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := v2.GetComponents()["sink"].(*counterComponent).count; got != 30 {
+		t.Errorf("Expected migrated count 30, got %d", got)
+	}
+
+	if _, err := migrator.Migrate(cp, "3.0.0"); err == nil {
+		t.Error("Expected missing migration path to fail")
+	}
+}
+
+func TestDiffConnectionSettings(t *testing.T) {
+	build := func(version string) *Pipeline {
+		p := NewPipeline("p").SetVersion(version)
+		p.AddComponent("a", NewTestValidationComponent("a"))
+		p.AddComponent("b", NewTestValidationComponent("b"))
+		Connect[string](p, "a", "output", "b", "input")
+		return p
+	}
+	v1, v2 := build("1.0.0"), build("1.0.1")
+	v2.ConnectWithTransform("a", "output", "b", "input", NewStringToUpperTransform())
+	v2.ConnectWithBackpressure("a", "output", "b", "input", &BackpressureConfig{Strategy: BackpressureDrop, BufferSize: 5})
+
+	d := DiffPipelines(v1, v2)
+	if d.Compatibility != CompatibilityNonBreaking || len(d.Connections) != 1 {
+		t.Fatalf("Expected one non-breaking connection change, got %s", d)
+	}
+	c := d.Connections[0]
+	if c.Kind != ChangeModified || c.OldTransform != "" || c.NewTransform == "" || c.NewBackpressure == "" {
+		t.Errorf("Expected transform and backpressure changes, got %+v", c)
+	}
+	if d.SuggestedVersion() != "1.0.1" {
+		t.Errorf("Expected a patch bump, got %s", d.SuggestedVersion())
+	}
+
+	data, err := d.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"kind": "modified"`) || !strings.Contains(string(data), `"compatibility": "non-breaking"`) {
+		t.Errorf("Expected change kinds to be encoded by name:\n%s", data)
+	}
+}
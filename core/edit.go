@@ -0,0 +1,254 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RemoveComponent removes a component together with its connections and the
+// ports the pipeline exposes from it.
+func (p *Pipeline) RemoveComponent(name string) error {
+	if _, ok := p.components[name]; !ok {
+		return fmt.Errorf("component '%s' not found", name)
+	}
+	delete(p.components, name)
+	delete(p.factories, name)
+
+	connections := make([]Connection, 0, len(p.connections))
+	for _, conn := range p.connections {
+		if conn.FromComponent != name && conn.ToComponent != name {
+			connections = append(connections, conn)
+		}
+	}
+	p.connections = connections
+
+	var exposed []portMapping
+	for _, m := range p.exposed {
+		if m.component != name {
+			exposed = append(exposed, m)
+		}
+	}
+	p.exposed = exposed
+	return nil
+}
+
+// ReplaceComponent puts component in the place of the component called name,
+// keeping its connections and exposed ports. Every connection is checked
+// again against the ports of the new component, including schema
+// migrations; connections with a transform only need the ports to exist.
+// Nothing changes if any check fails.
+func (p *Pipeline) ReplaceComponent(name string, component Component) error {
+	if _, ok := p.components[name]; !ok {
+		return fmt.Errorf("component '%s' not found", name)
+	}
+	if component == nil {
+		return fmt.Errorf("cannot replace '%s' with a nil component", name)
+	}
+	resolve := func(n string) Component {
+		if n == name {
+			return component
+		}
+		return p.components[n]
+	}
+
+	var errs []error
+	connections := append([]Connection(nil), p.connections...)
+	for i := range connections {
+		conn := &connections[i]
+		if conn.FromComponent != name && conn.ToComponent != name {
+			continue
+		}
+		if err := p.checkConnection(conn, resolve(conn.FromComponent), resolve(conn.ToComponent)); err != nil {
+			errs = append(errs, fmt.Errorf("connection %s: %w", conn.Name, err))
+		}
+	}
+
+	exposed := append([]portMapping(nil), p.exposed...)
+	for i := range exposed {
+		m := &exposed[i]
+		if m.component != name {
+			continue
+		}
+		ports := component.OutputPorts()
+		if m.input {
+			ports = component.InputPorts()
+		}
+		port := portByName(ports, m.port.Name())
+		switch {
+		case port == nil:
+			errs = append(errs, fmt.Errorf("exposed port '%s': port '%s' not found", m.name, m.port.Name()))
+		case port.Type() != m.port.Type():
+			errs = append(errs, fmt.Errorf("exposed port '%s': port '%s' has type %s, but expected %s", m.name, port.Name(), port.Type(), m.port.Type()))
+		default:
+			m.port = port
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot replace component '%s': %w", name, errors.Join(errs...))
+	}
+	component.SetName(name)
+	p.components[name] = component
+	delete(p.factories, name)
+	p.connections = connections
+	p.exposed = exposed
+	return nil
+}
+
+// RenameComponent renames a component and updates its connections and
+// exposed ports.
+func (p *Pipeline) RenameComponent(oldName, newName string) error {
+	component, ok := p.components[oldName]
+	if !ok {
+		return fmt.Errorf("component '%s' not found", oldName)
+	}
+	if newName == "" {
+		return fmt.Errorf("cannot rename '%s' to an empty name", oldName)
+	}
+	if _, ok := p.components[newName]; ok {
+		return fmt.Errorf("cannot rename '%s': component '%s' already exists", oldName, newName)
+	}
+
+	delete(p.components, oldName)
+	component.SetName(newName)
+	p.components[newName] = component
+	if factory, ok := p.factories[oldName]; ok {
+		delete(p.factories, oldName)
+		p.factories[newName] = factory
+	}
+
+	connections := append([]Connection(nil), p.connections...)
+	for i := range connections {
+		conn := &connections[i]
+		if conn.FromComponent != oldName && conn.ToComponent != oldName {
+			continue
+		}
+		from, to := conn.FromComponent, conn.ToComponent
+		if conn.FromComponent == oldName {
+			conn.FromComponent = newName
+		}
+		if conn.ToComponent == oldName {
+			conn.ToComponent = newName
+		}
+		conn.Name = connectionName(conn.FromComponent, conn.FromPort, conn.ToComponent, conn.ToPort)
+		conn.Description = redescribe(conn.Description, from, to, conn.FromComponent, conn.ToComponent)
+	}
+	p.connections = connections
+
+	exposed := append([]portMapping(nil), p.exposed...)
+	for i := range exposed {
+		if exposed[i].component == oldName {
+			exposed[i].component = newName
+		}
+	}
+	p.exposed = exposed
+	return nil
+}
+
+// Disconnect removes the connection between two ports.
+func (p *Pipeline) Disconnect(fromComponent, fromPort, toComponent, toPort string) error {
+	i := p.connectionIndex(fromComponent, fromPort, toComponent, toPort)
+	if i < 0 {
+		return fmt.Errorf("connection %s not found", connectionName(fromComponent, fromPort, toComponent, toPort))
+	}
+	connections := make([]Connection, 0, len(p.connections)-1)
+	connections = append(connections, p.connections[:i]...)
+	p.connections = append(connections, p.connections[i+1:]...)
+	return nil
+}
+
+// Rewire moves the connection from fromComponent.fromPort to
+// toComponent.toPort so that it connects newFromComponent.newFromPort to
+// newToComponent.newToPort. The connection keeps its transform, backpressure,
+// buffer size and metadata; the new ports are checked like in ConnectPorts.
+func (p *Pipeline) Rewire(fromComponent, fromPort, toComponent, toPort, newFromComponent, newFromPort, newToComponent, newToPort string) error {
+	i := p.connectionIndex(fromComponent, fromPort, toComponent, toPort)
+	if i < 0 {
+		return fmt.Errorf("connection %s not found", connectionName(fromComponent, fromPort, toComponent, toPort))
+	}
+	if j := p.connectionIndex(newFromComponent, newFromPort, newToComponent, newToPort); j >= 0 && j != i {
+		return fmt.Errorf("connection %s already exists", p.connections[j].Name)
+	}
+	from, ok := p.components[newFromComponent]
+	if !ok {
+		return fmt.Errorf("source component '%s' not found", newFromComponent)
+	}
+	to, ok := p.components[newToComponent]
+	if !ok {
+		return fmt.Errorf("target component '%s' not found", newToComponent)
+	}
+
+	for _, m := range p.exposed {
+		if m.input && m.component == newToComponent && m.port.Name() == newToPort {
+			return fmt.Errorf("input port '%s.%s' is exposed as '%s'", newToComponent, newToPort, m.name)
+		}
+	}
+
+	conn := p.connections[i]
+	conn.Description = redescribe(conn.Description, conn.FromComponent, conn.ToComponent, newFromComponent, newToComponent)
+	conn.FromComponent, conn.FromPort = newFromComponent, newFromPort
+	conn.ToComponent, conn.ToPort = newToComponent, newToPort
+	if err := p.checkConnection(&conn, from, to); err != nil {
+		return err
+	}
+	conn.Name = connectionName(newFromComponent, newFromPort, newToComponent, newToPort)
+
+	connections := append([]Connection(nil), p.connections...)
+	connections[i] = conn
+	p.connections = connections
+	return nil
+}
+
+// checkConnection checks that conn can connect from and to, and updates its
+// schema migration and feedback flag.
+func (p *Pipeline) checkConnection(conn *Connection, from, to Component) error {
+	outPort := portByName(from.OutputPorts(), conn.FromPort)
+	if outPort == nil {
+		return fmt.Errorf("output port validation failed for %s: port '%s' not found", conn.FromComponent, conn.FromPort)
+	}
+	if conn.Transform != nil {
+		if portByName(to.InputPorts(), conn.ToPort) == nil {
+			return fmt.Errorf("input port validation failed for %s: port '%s' not found", conn.ToComponent, conn.ToPort)
+		}
+		conn.Feedback = isFeedbackPort(to, conn.ToPort)
+		return nil
+	}
+	migration, err := p.validatePortMatch(from, conn.FromPort, to, conn.ToPort, outPort.Type())
+	if err != nil {
+		return err
+	}
+	conn.Migration = migration
+	conn.Feedback = isFeedbackPort(to, conn.ToPort)
+	return nil
+}
+
+// connectionIndex returns the index of a connection, or -1.
+func (p *Pipeline) connectionIndex(fromComponent, fromPort, toComponent, toPort string) int {
+	for i, conn := range p.connections {
+		if conn.FromComponent == fromComponent && conn.FromPort == fromPort &&
+			conn.ToComponent == toComponent && conn.ToPort == toPort {
+			return i
+		}
+	}
+	return -1
+}
+
+func connectionName(fromComponent, fromPort, toComponent, toPort string) string {
+	return fmt.Sprintf("%s.%s -> %s.%s", fromComponent, fromPort, toComponent, toPort)
+}
+
+func connectionDescription(fromComponent, toComponent string) string {
+	return fmt.Sprintf("Connection from %s to %s", fromComponent, toComponent)
+}
+
+// redescribe updates a description made by connectionDescription for a
+// connection whose components changed, keeping suffixes such as "with
+// transform". Other descriptions are returned unchanged.
+func redescribe(description, oldFrom, oldTo, newFrom, newTo string) string {
+	rest, ok := strings.CutPrefix(description, connectionDescription(oldFrom, oldTo))
+	if !ok || (rest != "" && !strings.HasPrefix(rest, " with ")) {
+		return description
+	}
+	return connectionDescription(newFrom, newTo) + rest
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newEditPipeline(t *testing.T) *Pipeline {
+	t.Helper()
+	p := NewPipeline("edit")
+	p.AddComponent("a", identity("a"))
+	p.AddComponent("b", identity("b"))
+	p.AddComponent("c", identity("c"))
+	p.ConnectPorts("a", "output", "b", "input")
+	p.ConnectPorts("b", "output", "c", "input")
+	p.Expose("in", "a", "input").Expose("out", "c", "output")
+	if len(p.Errors()) > 0 {
+		t.Fatal(p.Errors())
+	}
+	return p
+}
+
+func upper(name string) *FuncComponent {
+	return Func1(name, func(ctx context.Context, s string) (string, error) { return strings.ToUpper(s), nil })
+}
+
+func connectionNames(p *Pipeline) []string {
+	var names []string
+	for _, conn := range p.GetConnections() {
+		names = append(names, conn.Name)
+	}
+	return names
+}
+
+func TestAddComponentDuplicate(t *testing.T) {
+	p := newEditPipeline(t)
+	original := p.GetComponents()["b"]
+	p.AddComponent("b", identity("other"))
+	if len(p.Errors()) != 1 {
+		t.Fatalf("Expected a construction error, got %v", p.Errors())
+	}
+	if p.GetComponents()["b"] != original {
+		t.Error("Expected the existing component to be kept")
+	}
+
+	called := false
+	p.AddComponentFactory("b", func() Component {
+		called = true
+		return identity("other")
+	})
+	if len(p.Errors()) != 2 || called {
+		t.Errorf("Expected a duplicate factory to be rejected without calling it, got %v", p.Errors())
+	}
+	if p.Instance().GetComponents()["b"] != original {
+		t.Error("Expected instances to keep the existing component")
+	}
+}
+
+func TestRemoveComponent(t *testing.T) {
+	p := newEditPipeline(t)
+	if err := p.RemoveComponent("c"); err != nil {
+		t.Fatal(err)
+	}
+	if got := connectionNames(p); len(got) != 1 || got[0] != "a.output -> b.input" {
+		t.Errorf("Expected only a -> b to remain, got %v", got)
+	}
+	if len(p.OutputPorts()) != 0 {
+		t.Errorf("Expected the exposed port of c to be removed, got %v", p.OutputPorts())
+	}
+	if err := p.RemoveComponent("c"); err == nil {
+		t.Error("Expected an error for a missing component")
+	}
+}
+
+func TestReplaceComponent(t *testing.T) {
+	p := newEditPipeline(t)
+	replacement := identity("new")
+	if err := p.ReplaceComponent("b", replacement); err != nil {
+		t.Fatal(err)
+	}
+	if p.GetComponents()["b"] != replacement || replacement.Name() != "b" {
+		t.Error("Expected the replacement under the old name")
+	}
+	if len(p.GetConnections()) != 2 {
+		t.Errorf("Expected the wiring to be kept, got %v", connectionNames(p))
+	}
+
+	if err := p.ReplaceComponent("b", upper("upper")); err == nil || !strings.Contains(err.Error(), "type mismatch") {
+		t.Errorf("Expected a type mismatch, got %v", err)
+	}
+	rejected := upper("upper")
+	if err := p.ReplaceComponent("b", rejected); err == nil {
+		t.Error("Expected a type mismatch")
+	}
+	if p.GetComponents()["b"] != replacement || rejected.Name() != "upper" {
+		t.Error("Expected a failed replace to change nothing")
+	}
+
+	if err := p.ReplaceComponent("c", upper("upper")); err == nil || !strings.Contains(err.Error(), "exposed port 'out'") {
+		t.Errorf("Expected the exposed port to be checked, got %v", err)
+	}
+	if err := p.ReplaceComponent("missing", identity("x")); err == nil {
+		t.Error("Expected an error for a missing component")
+	}
+}
+
+func TestRenameComponent(t *testing.T) {
+	p := newEditPipeline(t)
+	if err := p.RenameComponent("b", "middle"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.output -> middle.input", "middle.output -> c.input"}
+	if got := connectionNames(p); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if p.GetComponents()["middle"].Name() != "middle" {
+		t.Error("Expected the component to carry its new name")
+	}
+	if got := p.GetConnections()[0].Description; got != "Connection from a to middle" {
+		t.Errorf("Expected the description to follow the rename, got %q", got)
+	}
+	if err := p.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if err := p.RenameComponent("a", "c"); err == nil {
+		t.Error("Expected an error when the new name is taken")
+	}
+	if err := p.RenameComponent("missing", "x"); err == nil {
+		t.Error("Expected an error for a missing component")
+	}
+}
+
+func TestDisconnectAndRewire(t *testing.T) {
+	p := newEditPipeline(t)
+	p.AddComponent("d", identity("d"))
+	p.AddComponent("s", upper("s"))
+	p.SetConnectionBufferSize("b", "output", "c", "input", 7)
+
+	if err := p.Rewire("b", "output", "c", "input", "b", "output", "d", "input"); err != nil {
+		t.Fatal(err)
+	}
+	conn := p.GetConnections()[1]
+	if conn.Name != "b.output -> d.input" || conn.Description != "Connection from b to d" || conn.BufferSize != 7 {
+		t.Errorf("Expected the rewired connection to keep its settings, got %+v", conn)
+	}
+
+	if err := p.Rewire("b", "output", "d", "input", "b", "output", "s", "input"); err == nil {
+		t.Error("Expected a type mismatch")
+	}
+	if err := p.Rewire("b", "output", "d", "input", "a", "output", "b", "input"); err == nil {
+		t.Error("Expected an error for an existing connection")
+	}
+	if err := p.Rewire("b", "output", "d", "input", "b", "output", "a", "input"); err == nil {
+		t.Error("Expected an error for an exposed input port")
+	}
+	if err := p.Rewire("c", "output", "d", "input", "c", "output", "b", "input"); err == nil {
+		t.Error("Expected an error for a missing connection")
+	}
+
+	if err := p.Disconnect("a", "output", "b", "input"); err != nil {
+		t.Fatal(err)
+	}
+	if got := connectionNames(p); len(got) != 1 || got[0] != "b.output -> d.input" {
+		t.Errorf("Expected only b -> d to remain, got %v", got)
+	}
+	if err := p.Disconnect("a", "output", "b", "input"); err == nil {
+		t.Error("Expected an error for a missing connection")
+	}
+}
@@ -444,5 +444,17 @@ func TestConstraintValidation(t *testing.T) {
 		if err := constraint.Validate(123); err == nil {
 			t.Error("Non-string should fail validation")
 		}
+
+		// Mismatch
+		if err := constraint.Validate("a test"); err != nil {
+			t.Errorf("Unanchored pattern should match: %v", err)
+		}
+		anchored := &RegexConstraint{Pattern: `^[a-z]+\d$`}
+		if err := anchored.Validate("abc"); err == nil {
+			t.Error("Non-matching string should fail validation")
+		}
+		if err := (&RegexConstraint{Pattern: "("}).Validate("x"); err == nil {
+			t.Error("Invalid pattern should fail validation")
+		}
 	})
 }
\ No newline at end of file
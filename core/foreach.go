@@ -0,0 +1,299 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// ForEachPolicy decides how a ForEach reacts to failing elements.
+type ForEachPolicy int
+
+const (
+	// ForEachPolicyFailFast cancels the remaining elements on the first failure
+	ForEachPolicyFailFast ForEachPolicy = iota
+	// ForEachPolicyFailAtEnd runs every element and then fails if any failed
+	ForEachPolicyFailAtEnd
+	// ForEachPolicyContinue runs every element and reports failures on the "errors" port
+	ForEachPolicyContinue
+)
+
+func (fp ForEachPolicy) String() string {
+	switch fp {
+	case ForEachPolicyFailFast:
+		return "fail_fast"
+	case ForEachPolicyFailAtEnd:
+		return "fail_at_end"
+	case ForEachPolicyContinue:
+		return "continue"
+	default:
+		return "unknown"
+	}
+}
+
+// ForEachConfig configures a ForEach.
+type ForEachConfig struct {
+	// Element names the sub-pipeline input that receives each element. It may
+	// be empty if the sub-pipeline has a single input.
+	Element string
+	// Result names the sub-pipeline output that is collected. It may be empty
+	// if the sub-pipeline has a single output.
+	Result string
+	// Parallelism bounds the instances running at once, runtime.NumCPU() if <= 0
+	Parallelism int
+	Policy      ForEachPolicy
+}
+
+// ElementError is the failure of one element of a ForEach.
+type ElementError struct {
+	Index   int
+	Element interface{}
+	Err     error
+}
+
+func (e ElementError) Error() string {
+	return fmt.Sprintf("element %d: %v", e.Index, e.Err)
+}
+
+func (e ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ForEach runs a sub-pipeline once per element of the collection on its
+// "items" port and gathers the results, in the order of the elements, on its
+// "results" port. Every element runs in its own instance through
+// Pipeline.Process. The other inputs of the sub-pipeline become inputs of the
+// ForEach and are passed to every instance unchanged. Create one with
+// NewForEach or NewForEachTemplate.
+type ForEach struct {
+	BaseComponent
+	instance    func() (*Pipeline, error)
+	element     string
+	result      string
+	resultType  reflect.Type
+	parallelism int
+	policy      ForEachPolicy
+	err         error
+}
+
+// NewForEach creates a ForEach that runs an Instance of sub for every
+// element. Components of sub that keep state must be added with
+// AddComponentFactory or implement Cloner; the others are shared by all
+// instances.
+func NewForEach(sub *Pipeline, config ForEachConfig) *ForEach {
+	// Process runs an Instance of sub on every call
+	return newForEach(sub, nil, func() (*Pipeline, error) { return sub, nil }, config)
+}
+
+// NewForEachTemplate creates a ForEach that instantiates t with params for
+// every element, so the instances share nothing.
+func NewForEachTemplate(t PipelineTemplate, params map[string]interface{}, config ForEachConfig) *ForEach {
+	prototype, err := t.Instantiate(params)
+	return newForEach(prototype, err, func() (*Pipeline, error) { return t.Instantiate(params) }, config)
+}
+
+func newForEach(prototype *Pipeline, err error, instance func() (*Pipeline, error), config ForEachConfig) *ForEach {
+	c := &ForEach{
+		instance:    instance,
+		element:     config.Element,
+		result:      config.Result,
+		parallelism: config.Parallelism,
+		policy:      config.Policy,
+		err:         err,
+	}
+	c.ComponentDescription = "Runs a sub-pipeline for every element of a collection"
+	c.ComponentTags = []string{"foreach", "composite"}
+	if c.parallelism <= 0 {
+		c.parallelism = runtime.NumCPU()
+	}
+	if err != nil {
+		return c
+	}
+	if prototype == nil {
+		c.err = fmt.Errorf("foreach needs a sub-pipeline")
+		return c
+	}
+
+	elementPort, err := selectPort(prototype.InputPorts(), c.element, "input")
+	if err != nil {
+		c.err = err
+		return c
+	}
+	resultPort, err := selectPort(prototype.OutputPorts(), c.result, "output")
+	if err != nil {
+		c.err = err
+		return c
+	}
+	c.element, c.result, c.resultType = elementPort.Name(), resultPort.Name(), resultPort.Type()
+
+	c.Inputs = []Port{&BasePort{
+		PortName:        "items",
+		PortType:        reflect.SliceOf(elementPort.Type()),
+		IsRequired:      true,
+		PortDescription: fmt.Sprintf("Elements for %s", elementPort.Name()),
+	}}
+	for _, port := range prototype.InputPorts() {
+		if port.Name() == c.element {
+			continue
+		}
+		if port.Name() == "items" {
+			c.err = fmt.Errorf("foreach cannot pass the sub-pipeline input 'items' through")
+			return c
+		}
+		c.Inputs = append(c.Inputs, port)
+	}
+	c.Outputs = []Port{
+		&BasePort{
+			PortName:        "results",
+			PortType:        reflect.SliceOf(c.resultType),
+			PortDescription: fmt.Sprintf("Values of %s in element order", c.result),
+		},
+		&BasePort{
+			PortName:        "errors",
+			PortType:        reflect.TypeOf([]ElementError(nil)),
+			PortDescription: "Failed elements, emitted only if some failed",
+		},
+	}
+	return c
+}
+
+// selectPort returns the named port, or the only port if name is empty.
+func selectPort(ports []Port, name, kind string) (Port, error) {
+	if name != "" {
+		if port := portByName(ports, name); port != nil {
+			return port, nil
+		}
+		return nil, fmt.Errorf("foreach: sub-pipeline has no %s port '%s'", kind, name)
+	}
+	if len(ports) != 1 {
+		return nil, fmt.Errorf("foreach: sub-pipeline has %d %s ports, name the one to use", len(ports), kind)
+	}
+	return ports[0], nil
+}
+
+// Validate reports an unusable sub-pipeline.
+func (c *ForEach) Validate() error {
+	return c.err
+}
+
+// Process runs the sub-pipeline for every element. A result is the zero
+// value if its instance failed or produced nothing on the result port.
+func (c *ForEach) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	items := reflect.ValueOf(inputs["items"])
+	if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return nil, funcInputError(c.Name(), "items", c.Inputs[0].Type(), inputs["items"])
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := reflect.MakeSlice(reflect.SliceOf(c.resultType), items.Len(), items.Len())
+	failures := make([]*ElementError, items.Len())
+	var first *ElementError
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, c.parallelism)
+
+	for i := 0; i < items.Len(); i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(index int, element interface{}) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			value, err := c.runElement(ctx, inputs, element)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[index] = &ElementError{Index: index, Element: element, Err: err}
+				if first == nil {
+					first = failures[index]
+				}
+				if c.policy == ForEachPolicyFailFast {
+					cancel()
+				}
+				return
+			}
+			if value != nil {
+				results.Index(index).Set(reflect.ValueOf(value))
+			}
+		}(i, items.Index(i).Interface())
+	}
+	wg.Wait()
+
+	var elementErrors []ElementError
+	for _, failure := range failures {
+		if failure != nil {
+			elementErrors = append(elementErrors, *failure)
+		}
+	}
+	if len(elementErrors) > 0 && c.policy != ForEachPolicyContinue {
+		return nil, c.failure(*first, elementErrors)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]interface{}{"results": results.Interface()}
+	if len(elementErrors) > 0 {
+		outputs["errors"] = elementErrors
+	}
+	return outputs, nil
+}
+
+// runElement runs one instance and returns the value of its result port.
+func (c *ForEach) runElement(ctx context.Context, inputs map[string]interface{}, element interface{}) (interface{}, error) {
+	instance, err := c.instance()
+	if err != nil {
+		return nil, err
+	}
+	instanceInputs := make(map[string]interface{}, len(inputs))
+	for name, value := range inputs {
+		if name != "items" {
+			instanceInputs[name] = value
+		}
+	}
+	instanceInputs[c.element] = element
+
+	outputs, err := instance.Process(ctx, instanceInputs)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := outputs[c.result]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	if !reflect.TypeOf(value).AssignableTo(c.resultType) {
+		return nil, fmt.Errorf("result %s has type %T, expected %s", c.result, value, c.resultType)
+	}
+	return value, nil
+}
+
+// failure describes the failed elements as one PipelineError. first is the
+// element that failed first; with ForEachPolicyFailFast the others were
+// cancelled because of it.
+func (c *ForEach) failure(first ElementError, elementErrors []ElementError) error {
+	errs := make([]error, len(elementErrors))
+	for i := range elementErrors {
+		errs[i] = elementErrors[i]
+	}
+	message := fmt.Sprintf("%d elements failed, first %v", len(elementErrors), first)
+	if c.policy == ForEachPolicyFailFast {
+		message = fmt.Sprintf("element %d failed: %v", first.Index, first.Err)
+	}
+	return NewPipelineError(message, c.Name(), RuntimeError, Error, false).
+		WithOriginalError(errors.Join(errs...)).
+		WithContext("index", first.Index).
+		WithContext("failed", len(elementErrors))
+}
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FuncComponent adapts a typed Go function to the Component interface. Its
+// ports, with their types and schemas, are derived from the function's type
+// parameters, and packets are converted to and from the typed values before
+// and after each call. Create one with Func1, Func2, Source, Sink or FuncPorts.
+type FuncComponent struct {
+	BaseComponent
+	process func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error)
+	err     error
+}
+
+// Validate reports port types that cannot be adapted.
+func (c *FuncComponent) Validate() error {
+	return c.err
+}
+
+// Process converts the inputs and calls the wrapped function.
+func (c *FuncComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	return c.process(ctx, inputs)
+}
+
+// Describe sets the component's description and returns the component.
+func (c *FuncComponent) Describe(description string) *FuncComponent {
+	c.ComponentDescription = description
+	return c
+}
+
+// Func1 creates a component with an "input" port of type In and an "output"
+// port of type Out:
+//
+//	upper := core.Func1("upper", func(ctx context.Context, s string) (string, error) {
+//		return strings.ToUpper(s), nil
+//	})
+func Func1[In, Out any](name string, fn func(ctx context.Context, in In) (Out, error)) *FuncComponent {
+	c := newFuncComponent(name)
+	c.Inputs = []Port{typedPort[In]("input", true)}
+	c.Outputs = []Port{typedPort[Out]("output", false)}
+	c.process = func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		in, err := typedInput[In](c.Name(), inputs, "input")
+		if err != nil {
+			return nil, err
+		}
+		out, err := fn(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"output": out}, nil
+	}
+	return c
+}
+
+// Func2 creates a component with "input1" and "input2" ports of types In1
+// and In2 and an "output" port of type Out. Use FuncPorts for other port
+// names or more ports.
+func Func2[In1, In2, Out any](name string, fn func(ctx context.Context, in1 In1, in2 In2) (Out, error)) *FuncComponent {
+	c := newFuncComponent(name)
+	c.Inputs = []Port{typedPort[In1]("input1", true), typedPort[In2]("input2", true)}
+	c.Outputs = []Port{typedPort[Out]("output", false)}
+	c.process = func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		in1, err := typedInput[In1](c.Name(), inputs, "input1")
+		if err != nil {
+			return nil, err
+		}
+		in2, err := typedInput[In2](c.Name(), inputs, "input2")
+		if err != nil {
+			return nil, err
+		}
+		out, err := fn(ctx, in1, in2)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"output": out}, nil
+	}
+	return c
+}
+
+// Source creates a component without inputs and an "output" port of type Out.
+func Source[Out any](name string, fn func(ctx context.Context) (Out, error)) *FuncComponent {
+	c := newFuncComponent(name)
+	c.Outputs = []Port{typedPort[Out]("output", false)}
+	c.process = func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"output": out}, nil
+	}
+	return c
+}
+
+// Sink creates a component with an "input" port of type In and no outputs.
+func Sink[In any](name string, fn func(ctx context.Context, in In) error) *FuncComponent {
+	c := newFuncComponent(name)
+	c.Inputs = []Port{typedPort[In]("input", true)}
+	c.process = func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		in, err := typedInput[In](c.Name(), inputs, "input")
+		if err != nil {
+			return nil, err
+		}
+		return nil, fn(ctx, in)
+	}
+	return c
+}
+
+// FuncPorts creates a component whose ports are the exported fields of the
+// structs In and Out. Ports are named after the field's port tag, falling
+// back to its json name and then the field name. Input ports are required
+// unless the field is a pointer, interface, slice or map, or its port tag
+// has the optional option; absent optional inputs are left at their zero
+// value. Output fields holding nil are not emitted.
+//
+//	type joinIn struct {
+//		Left  string `port:"left"`
+//		Right string `port:"right"`
+//		Sep   *string `port:"sep"`
+//	}
+//	type joinOut struct {
+//		Joined string `port:"joined"`
+//	}
+//	join := core.FuncPorts("join", func(ctx context.Context, in joinIn) (joinOut, error) { ... })
+func FuncPorts[In, Out any](name string, fn func(ctx context.Context, in In) (Out, error)) *FuncComponent {
+	c := newFuncComponent(name)
+	inType := reflect.TypeOf((*In)(nil)).Elem()
+	outType := reflect.TypeOf((*Out)(nil)).Elem()
+	inFields, inErr := structPorts(inType, true)
+	outFields, outErr := structPorts(outType, false)
+	for _, f := range inFields {
+		c.Inputs = append(c.Inputs, f.port)
+	}
+	for _, f := range outFields {
+		c.Outputs = append(c.Outputs, f.port)
+	}
+
+	switch {
+	case inErr != nil:
+		c.err = inErr
+	case outErr != nil:
+		c.err = outErr
+	}
+
+	c.process = func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		if c.err != nil {
+			return nil, NewPipelineError(c.err.Error(), c.Name(), ConfigurationError, Error, false)
+		}
+
+		var in In
+		inVal := reflect.ValueOf(&in).Elem()
+		for _, f := range inFields {
+			value, ok := inputs[f.port.PortName]
+			if !ok || value == nil {
+				if f.port.IsRequired {
+					return nil, funcInputError(c.Name(), f.port.PortName, f.port.PortType, value)
+				}
+				continue
+			}
+			v := reflect.ValueOf(value)
+			if !v.Type().AssignableTo(f.port.PortType) {
+				return nil, funcInputError(c.Name(), f.port.PortName, f.port.PortType, value)
+			}
+			inVal.FieldByIndex(f.index).Set(v)
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		outVal := reflect.ValueOf(out)
+		outputs := make(map[string]interface{}, len(outFields))
+		for _, f := range outFields {
+			field := outVal.FieldByIndex(f.index)
+			if isNilValue(field) {
+				continue
+			}
+			outputs[f.port.PortName] = field.Interface()
+		}
+		return outputs, nil
+	}
+	return c
+}
+
+func newFuncComponent(name string) *FuncComponent {
+	c := &FuncComponent{}
+	c.ComponentName = name
+	c.ComponentTags = []string{"func"}
+	return c
+}
+
+// typedPort describes a port carrying T.
+func typedPort[T any](name string, input bool) *BasePort {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return &BasePort{
+		PortName:        name,
+		PortType:        t,
+		IsRequired:      input,
+		PortDescription: fmt.Sprintf("%s value", t),
+		PortSchema:      NewBaseSchema(t, fmt.Sprintf("%s %s", name, t)),
+	}
+}
+
+// typedInput converts the packet on the named port to T. A missing or nil
+// packet is accepted for types whose zero value is nil.
+func typedInput[T any](component string, inputs map[string]interface{}, port string) (T, error) {
+	var zero T
+	value := inputs[port]
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if value == nil && canBeNil(t) {
+		return zero, nil
+	}
+	return zero, funcInputError(component, port, t, value)
+}
+
+func funcInputError(component, port string, expected reflect.Type, value interface{}) *BasePipelineError {
+	got := "nothing"
+	if value != nil {
+		got = reflect.TypeOf(value).String()
+	}
+	return NewPipelineError(
+		fmt.Sprintf("input %s expects %s, got %s", port, expected, got),
+		component,
+		ValidationError,
+		Error,
+		false,
+	).WithContext("port", port)
+}
+
+type structPort struct {
+	port  *BasePort
+	index []int
+}
+
+// structPorts describes the exported fields of a struct as ports.
+func structPorts(t reflect.Type, input bool) ([]structPort, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("port type %s must be a struct", t)
+	}
+	var ports []structPort
+	seen := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, optional, skip := portFieldName(field)
+		if skip {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("port type %s declares port %q twice", t, name)
+		}
+		seen[name] = true
+		ports = append(ports, structPort{
+			port: &BasePort{
+				PortName:        name,
+				PortType:        field.Type,
+				IsRequired:      input && !optional && !canBeNil(field.Type),
+				PortDescription: field.Tag.Get("description"),
+				PortSchema:      NewBaseSchema(field.Type, fmt.Sprintf("%s %s", name, field.Type)),
+			},
+			index: field.Index,
+		})
+	}
+	return ports, nil
+}
+
+// portFieldName reads a field's port name from its port tag, json tag or
+// Go name.
+func portFieldName(field reflect.StructField) (name string, optional, skip bool) {
+	if tag, ok := field.Tag.Lookup("port"); ok {
+		if tag == "-" {
+			return "", false, true
+		}
+		parts := strings.Split(tag, ",")
+		for _, opt := range parts[1:] {
+			if opt == "optional" {
+				optional = true
+			}
+		}
+		if parts[0] != "" {
+			return parts[0], optional, false
+		}
+	}
+	jsonName, _, skip := jsonFieldName(field)
+	if skip {
+		return "", false, true
+	}
+	if jsonName != "" {
+		return jsonName, optional, false
+	}
+	return field.Name, optional, false
+}
+
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return true
+	}
+	return false
+}
+
+func isNilValue(v reflect.Value) bool {
+	return canBeNil(v.Type()) && v.IsNil()
+}
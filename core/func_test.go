@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type joinInputs struct {
+	Left  string  `port:"left"`
+	Right string  `port:"right"`
+	Sep   *string `port:"sep"`
+	Note  string  `port:"note,optional"`
+}
+
+type joinOutputs struct {
+	Joined string   `json:"joined"`
+	Parts  []string `json:"parts"`
+	Length int
+}
+
+func TestFunc1(t *testing.T) {
+	upper := Func1("upper", func(ctx context.Context, s string) (string, error) {
+		return strings.ToUpper(s), nil
+	})
+
+	if in := upper.InputPorts(); len(in) != 1 || in[0].Name() != "input" || in[0].Type() != reflect.TypeOf("") || !in[0].Required() {
+		t.Errorf("Unexpected input ports %+v", in)
+	}
+	if out := upper.OutputPorts(); len(out) != 1 || out[0].Name() != "output" || out[0].Schema() == nil {
+		t.Errorf("Unexpected output ports %+v", out)
+	}
+
+	outputs, err := upper.Process(context.Background(), map[string]interface{}{"input": "hello"})
+	if err != nil || outputs["output"] != "HELLO" {
+		t.Errorf("Expected HELLO, got %v (%v)", outputs, err)
+	}
+
+	_, err = upper.Process(context.Background(), map[string]interface{}{"input": 42})
+	var pipelineErr PipelineError
+	if !errors.As(err, &pipelineErr) || pipelineErr.ErrorType() != ValidationError {
+		t.Errorf("Expected a validation error for the wrong input type, got %v", err)
+	}
+
+	failing := Func1("fail", func(ctx context.Context, n int) (int, error) { return 0, errors.New("boom") })
+	if _, err := failing.Process(context.Background(), map[string]interface{}{"input": 1}); err == nil || err.Error() != "boom" {
+		t.Errorf("Expected the function's error, got %v", err)
+	}
+}
+
+func TestFuncComponentsInPipeline(t *testing.T) {
+	var got []string
+	p := NewPipeline("typed")
+	p.AddComponent("source", Source("source", func(ctx context.Context) (string, error) { return "a", nil }))
+	p.AddComponent("pair", Func2("pair", func(ctx context.Context, a string, n int) (string, error) {
+		return strings.Repeat(a, n), nil
+	}))
+	p.AddComponent("count", Source("count", func(ctx context.Context) (int, error) { return 3, nil }))
+	p.AddComponent("sink", Sink("sink", func(ctx context.Context, s string) error {
+		got = append(got, s)
+		return nil
+	}))
+	Connect[string](p, "source", "output", "pair", "input1")
+	Connect[int](p, "count", "output", "pair", "input2")
+	Connect[string](p, "pair", "output", "sink", "input")
+	Connect[int](p, "pair", "output", "sink", "input")
+
+	if errs := p.Errors(); len(errs) != 1 {
+		t.Fatalf("Expected only the int connection to fail, got %v", errs)
+	}
+	if result := p.ValidateComprehensive(); !result.Valid {
+		t.Errorf("Expected typed components to validate, got %v", result.Errors)
+	}
+
+	pair := p.GetComponents()["pair"]
+	out, err := pair.Process(context.Background(), map[string]interface{}{"input1": "ab", "input2": 2})
+	if err != nil || out["output"] != "abab" {
+		t.Fatalf("Unexpected result %v (%v)", out, err)
+	}
+	if _, err := p.GetComponents()["sink"].Process(context.Background(), out); err == nil {
+		t.Error("Expected the sink to reject a packet on the wrong port")
+	}
+	if _, err := p.GetComponents()["sink"].Process(context.Background(), map[string]interface{}{"input": "abab"}); err != nil || len(got) != 1 {
+		t.Errorf("Expected the sink to record the packet, got %v (%v)", got, err)
+	}
+}
+
+func TestFuncPorts(t *testing.T) {
+	join := FuncPorts("join", func(ctx context.Context, in joinInputs) (joinOutputs, error) {
+		sep := " "
+		if in.Sep != nil {
+			sep = *in.Sep
+		}
+		joined := in.Left + sep + in.Right
+		return joinOutputs{Joined: joined, Length: len(joined)}, nil
+	})
+	if err := join.Validate(); err != nil {
+		t.Fatalf("Expected valid port structs: %v", err)
+	}
+
+	var inputs []string
+	for _, port := range join.InputPorts() {
+		inputs = append(inputs, port.Name())
+		if want := port.Name() == "left" || port.Name() == "right"; port.Required() != want {
+			t.Errorf("Port %s: expected required=%v", port.Name(), want)
+		}
+	}
+	if !reflect.DeepEqual(inputs, []string{"left", "right", "sep", "note"}) {
+		t.Errorf("Unexpected input ports %v", inputs)
+	}
+	var outputs []string
+	for _, port := range join.OutputPorts() {
+		outputs = append(outputs, port.Name())
+	}
+	if !reflect.DeepEqual(outputs, []string{"joined", "parts", "Length"}) {
+		t.Errorf("Unexpected output ports %v", outputs)
+	}
+
+	sep := "-"
+	out, err := join.Process(context.Background(), map[string]interface{}{"left": "a", "right": "b", "sep": &sep})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["joined"] != "a-b" || out["Length"] != 3 {
+		t.Errorf("Unexpected outputs %v", out)
+	}
+	if _, ok := out["parts"]; ok {
+		t.Error("Nil outputs should not be emitted")
+	}
+	if _, err := join.Process(context.Background(), map[string]interface{}{"left": "a"}); err == nil {
+		t.Error("Expected a missing required input to fail")
+	}
+
+	bad := FuncPorts("bad", func(ctx context.Context, in string) (joinOutputs, error) { return joinOutputs{}, nil })
+	if bad.Validate() == nil {
+		t.Error("Expected a non-struct port type to fail validation")
+	}
+}
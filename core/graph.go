@@ -0,0 +1,316 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Graph queries follow every connection, including the feedback connections
+// of loops, so that a closure holds complete loops. Names are returned in
+// sorted order.
+
+// Upstream returns the components that name depends on, directly or
+// transitively, or nil if name is not in the graph.
+func (g *ComponentGraph) Upstream(name string) []string {
+	return g.closure(name, g.adjacency(true))
+}
+
+// Downstream returns the components that depend on name, directly or
+// transitively, or nil if name is not in the graph.
+func (g *ComponentGraph) Downstream(name string) []string {
+	return g.closure(name, g.adjacency(false))
+}
+
+// Paths returns every path from one component to another that visits no
+// component twice. The only path from a component to itself is the
+// component.
+func (g *ComponentGraph) Paths(from, to string) [][]string {
+	if g.Nodes[from] == nil || g.Nodes[to] == nil {
+		return nil
+	}
+	forward := g.adjacency(false)
+	paths := make([][]string, 0)
+	visited := map[string]bool{from: true}
+	path := []string{from}
+	var walk func(current string)
+	walk = func(current string) {
+		if current == to {
+			paths = append(paths, append([]string(nil), path...))
+			return
+		}
+		for _, next := range forward[current] {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			path = append(path, next)
+			walk(next)
+			path = path[:len(path)-1]
+			visited[next] = false
+		}
+	}
+	walk(from)
+	return paths
+}
+
+// Sources returns the components without incoming connections.
+func (g *ComponentGraph) Sources() []string {
+	return g.withoutEdges(g.adjacency(true))
+}
+
+// Sinks returns the components without outgoing connections.
+func (g *ComponentGraph) Sinks() []string {
+	return g.withoutEdges(g.adjacency(false))
+}
+
+// ConnectedComponents returns the groups of components that are connected to
+// each other, ignoring the direction of connections. Groups are ordered by
+// their first name.
+func (g *ComponentGraph) ConnectedComponents() [][]string {
+	neighbours := g.adjacency(false)
+	for name, previous := range g.adjacency(true) {
+		neighbours[name] = append(neighbours[name], previous...)
+	}
+	seen := make(map[string]bool)
+	var groups [][]string
+	for _, name := range g.names() {
+		if seen[name] {
+			continue
+		}
+		group := []string{name}
+		seen[name] = true
+		for queue := []string{name}; len(queue) > 0; queue = queue[1:] {
+			for _, next := range neighbours[queue[0]] {
+				if !seen[next] {
+					seen[next] = true
+					group = append(group, next)
+					queue = append(queue, next)
+				}
+			}
+		}
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// adjacency returns the sorted neighbours of every component, following
+// connections forward or in reverse.
+func (g *ComponentGraph) adjacency(reverse bool) map[string][]string {
+	seen := make(map[[2]string]bool)
+	adjacent := make(map[string][]string)
+	for _, edge := range g.Edges {
+		from, to := edge.From, edge.To
+		if reverse {
+			from, to = to, from
+		}
+		if g.Nodes[from] == nil || g.Nodes[to] == nil || seen[[2]string{from, to}] {
+			continue
+		}
+		seen[[2]string{from, to}] = true
+		adjacent[from] = append(adjacent[from], to)
+	}
+	for name := range adjacent {
+		sort.Strings(adjacent[name])
+	}
+	return adjacent
+}
+
+// closure returns the components reachable from name, without name itself.
+func (g *ComponentGraph) closure(name string, adjacent map[string][]string) []string {
+	if g.Nodes[name] == nil {
+		return nil
+	}
+	visited := map[string]bool{name: true}
+	reached := make([]string, 0)
+	for queue := []string{name}; len(queue) > 0; queue = queue[1:] {
+		for _, next := range adjacent[queue[0]] {
+			if !visited[next] {
+				visited[next] = true
+				reached = append(reached, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	sort.Strings(reached)
+	return reached
+}
+
+func (g *ComponentGraph) withoutEdges(adjacent map[string][]string) []string {
+	names := make([]string, 0)
+	for _, name := range g.names() {
+		if len(adjacent[name]) == 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (g *ComponentGraph) names() []string {
+	names := make([]string, 0, len(g.Nodes))
+	for name := range g.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Upstream returns the components that a component depends on, directly or
+// transitively.
+func (p *Pipeline) Upstream(name string) ([]string, error) {
+	graph, err := p.graphOf(name)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Upstream(name), nil
+}
+
+// Downstream returns the components that depend on a component, directly or
+// transitively.
+func (p *Pipeline) Downstream(name string) ([]string, error) {
+	graph, err := p.graphOf(name)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Downstream(name), nil
+}
+
+// Paths returns every path between two components that visits no component
+// twice.
+func (p *Pipeline) Paths(from, to string) ([][]string, error) {
+	graph, err := p.graphOf(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return graph.Paths(from, to), nil
+}
+
+// Sources returns the components without incoming connections.
+func (p *Pipeline) Sources() ([]string, error) {
+	graph, err := p.GetComponentGraph()
+	if err != nil {
+		return nil, err
+	}
+	return graph.Sources(), nil
+}
+
+// Sinks returns the components without outgoing connections.
+func (p *Pipeline) Sinks() ([]string, error) {
+	graph, err := p.GetComponentGraph()
+	if err != nil {
+		return nil, err
+	}
+	return graph.Sinks(), nil
+}
+
+// ConnectedComponents returns the groups of components that are connected to
+// each other.
+func (p *Pipeline) ConnectedComponents() ([][]string, error) {
+	graph, err := p.GetComponentGraph()
+	if err != nil {
+		return nil, err
+	}
+	return graph.ConnectedComponents(), nil
+}
+
+// graphOf returns the component graph, checking that names are components.
+func (p *Pipeline) graphOf(names ...string) (*ComponentGraph, error) {
+	for _, name := range names {
+		if _, ok := p.components[name]; !ok {
+			return nil, fmt.Errorf("component '%s' not found", name)
+		}
+	}
+	return p.GetComponentGraph()
+}
+
+// Subgraph returns a copy of the pipeline that holds only the named
+// components, the connections between them and the ports they expose. Like
+// Decorate, the copy shares its components, configuration and context with
+// p, so running it records component states in p's context.
+func (p *Pipeline) Subgraph(names ...string) (*Pipeline, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("subgraph needs at least one component")
+	}
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := p.components[name]; !ok {
+			return nil, fmt.Errorf("component '%s' not found", name)
+		}
+		keep[name] = true
+	}
+
+	sub := *p
+	sub.components = make(map[string]Component, len(keep))
+	for name := range keep {
+		sub.components[name] = p.components[name]
+	}
+	sub.connections = make([]Connection, 0, len(p.connections))
+	for _, conn := range p.connections {
+		if keep[conn.FromComponent] && keep[conn.ToComponent] {
+			sub.connections = append(sub.connections, conn)
+		}
+	}
+	sub.exposed = nil
+	for _, m := range p.exposed {
+		if keep[m.component] {
+			sub.exposed = append(sub.exposed, m)
+		}
+	}
+	return &sub, nil
+}
+
+// RunTarget runs only what is needed to produce the outputs of the targets:
+// the targets and every component upstream of them. Components outside that
+// subgraph do not run and keep their state.
+func (p *Pipeline) RunTarget(ctx context.Context, targets ...string) error {
+	graph, err := p.graphOf(targets...)
+	if err != nil {
+		return err
+	}
+	needed := make(map[string]bool)
+	for _, target := range targets {
+		needed[target] = true
+		for _, name := range graph.Upstream(target) {
+			needed[name] = true
+		}
+	}
+	return p.runSubgraph(ctx, needed)
+}
+
+// RunDownstream runs what a change to the given components affects: the
+// changed components, everything downstream of them, and the upstream
+// components those need for their inputs.
+func (p *Pipeline) RunDownstream(ctx context.Context, changed ...string) error {
+	graph, err := p.graphOf(changed...)
+	if err != nil {
+		return err
+	}
+	affected := make(map[string]bool)
+	for _, name := range changed {
+		affected[name] = true
+		for _, downstream := range graph.Downstream(name) {
+			affected[downstream] = true
+		}
+	}
+	needed := make(map[string]bool)
+	for name := range affected {
+		needed[name] = true
+		for _, upstream := range graph.Upstream(name) {
+			needed[upstream] = true
+		}
+	}
+	return p.runSubgraph(ctx, needed)
+}
+
+func (p *Pipeline) runSubgraph(ctx context.Context, needed map[string]bool) error {
+	names := make([]string, 0, len(needed))
+	for name := range needed {
+		names = append(names, name)
+	}
+	sub, err := p.Subgraph(names...)
+	if err != nil {
+		return err
+	}
+	return sub.Run(ctx)
+}
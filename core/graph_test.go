@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// newGraphPipeline builds a -> b -> d, a -> c -> d, e -> f and a lone g.
+func newGraphPipeline(t *testing.T) *Pipeline {
+	t.Helper()
+	p := NewPipeline("graph")
+	for _, name := range []string{"a", "b", "c", "e", "f", "g"} {
+		p.AddComponent(name, identity(name))
+	}
+	p.AddComponent("d", Func2("d", func(ctx context.Context, x, y int) (int, error) { return x + y, nil }))
+	p.ConnectPorts("a", "output", "b", "input")
+	p.ConnectPorts("a", "output", "c", "input")
+	p.ConnectPorts("b", "output", "d", "input1")
+	p.ConnectPorts("c", "output", "d", "input2")
+	p.ConnectPorts("e", "output", "f", "input")
+	if len(p.Errors()) > 0 {
+		t.Fatal(p.Errors())
+	}
+	return p
+}
+
+func TestGraphQueries(t *testing.T) {
+	p := newGraphPipeline(t)
+
+	check := func(name string, got, want interface{}, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	upstream, err := p.Upstream("d")
+	check("upstream of d", upstream, []string{"a", "b", "c"}, err)
+	downstream, err := p.Downstream("b")
+	check("downstream of b", downstream, []string{"d"}, err)
+	downstream, err = p.Downstream("g")
+	check("downstream of g", downstream, []string{}, err)
+	paths, err := p.Paths("a", "d")
+	check("paths from a to d", paths, [][]string{{"a", "b", "d"}, {"a", "c", "d"}}, err)
+	paths, err = p.Paths("d", "a")
+	check("paths from d to a", paths, [][]string{}, err)
+	sources, err := p.Sources()
+	check("sources", sources, []string{"a", "e", "g"}, err)
+	sinks, err := p.Sinks()
+	check("sinks", sinks, []string{"d", "f", "g"}, err)
+	groups, err := p.ConnectedComponents()
+	check("connected components", groups, [][]string{{"a", "b", "c", "d"}, {"e", "f"}, {"g"}}, err)
+
+	if _, err := p.Upstream("missing"); err == nil {
+		t.Error("Expected an error for a missing component")
+	}
+}
+
+func TestGraphQueriesFollowFeedback(t *testing.T) {
+	config := NewDefaultPipelineConfig()
+	config.AllowCycles = true
+	p := NewPipelineWithConfig("loop", config)
+	p.AddComponent("init", identity("init"))
+	p.AddComponent("loop", NewLoopEntry[int](3, nil))
+	p.AddComponent("body", identity("body"))
+	p.AddComponent("sink", identity("sink"))
+	p.ConnectPorts("init", "output", "loop", "init")
+	p.ConnectPorts("loop", "body", "body", "input")
+	p.ConnectPorts("body", "output", "loop", "feedback")
+	p.ConnectPorts("loop", "done", "sink", "input")
+	if len(p.Errors()) > 0 {
+		t.Fatal(p.Errors())
+	}
+
+	upstream, err := p.Upstream("sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"body", "init", "loop"}; !reflect.DeepEqual(upstream, want) {
+		t.Errorf("Expected the loop body upstream of sink, got %v", upstream)
+	}
+	sinks, _ := p.Sinks()
+	if want := []string{"sink"}; !reflect.DeepEqual(sinks, want) {
+		t.Errorf("Expected %v, got %v", want, sinks)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	p := newGraphPipeline(t)
+	p.Expose("result", "d", "output")
+
+	sub, err := p.Subgraph("a", "b", "e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sub.GetComponents()) != 3 {
+		t.Errorf("Expected 3 components, got %d", len(sub.GetComponents()))
+	}
+	if len(sub.GetConnections()) != 1 || sub.GetConnections()[0].Name != "a.output -> b.input" {
+		t.Errorf("Expected only a -> b, got %v", connectionNames(sub))
+	}
+	if portByName(sub.OutputPorts(), "result") != nil {
+		t.Error("Expected the exposed port of d to be dropped")
+	}
+	if len(p.GetComponents()) != 7 || len(p.GetConnections()) != 5 {
+		t.Error("Expected the pipeline to be unchanged")
+	}
+	if _, err := p.Subgraph("a", "missing"); err == nil {
+		t.Error("Expected an error for a missing component")
+	}
+}
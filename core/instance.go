@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Cloner is implemented by components that keep state between calls to
+// Process. Clone returns a new, unnamed instance with the same configuration
+// and fresh state. Instance clones such components for every run.
+type Cloner interface {
+	Component
+	Clone() Component
+}
+
+// AddComponentFactory adds the component created by factory. The pipeline
+// keeps one instance for validation and for Run; Instance calls factory again
+// for every run, so each run gets its own component.
+func (p *Pipeline) AddComponentFactory(name string, factory func() Component) *Pipeline {
+	if factory == nil {
+		p.errors = append(p.errors, fmt.Errorf("component factory for '%s' is nil", name))
+		return p
+	}
+	if _, exists := p.components[name]; exists {
+		p.errors = append(p.errors, fmt.Errorf("component '%s' already exists", name))
+		return p
+	}
+	component := factory()
+	if component == nil {
+		p.errors = append(p.errors, fmt.Errorf("component factory for '%s' returned nil", name))
+		return p
+	}
+	p.AddComponent(name, component)
+	if p.factories == nil {
+		p.factories = make(map[string]func() Component)
+	}
+	p.factories[name] = factory
+	return p
+}
+
+// Instance returns a copy of the pipeline for one run. The copy shares the
+// definition with p: connections, configuration, metadata, engine, hooks and
+// error handler. It gets its own PipelineContext, with a new ExecutionID and
+// metrics, its own error collector, and new instances of the components added
+// with AddComponentFactory or implementing Cloner. The remaining components
+// are shared between instances and must be safe for concurrent use.
+//
+// Instances of the same pipeline can run concurrently with each other, but
+// not while the pipeline itself is being modified.
+func (p *Pipeline) Instance() *Pipeline {
+	run := *p
+	run.context = NewPipelineContext()
+	run.errors = append([]error(nil), p.errors...)
+	run.pipelineErrors = make([]PipelineError, 0)
+	run.errorCollector = NewErrorCollector()
+	run.components = make(map[string]Component, len(p.components))
+	for name, component := range p.components {
+		run.components[name] = p.freshComponent(name, component)
+	}
+	run.connections = append([]Connection(nil), p.connections...)
+	return &run
+}
+
+// freshComponent returns the instance of a component for a new run.
+func (p *Pipeline) freshComponent(name string, component Component) Component {
+	var fresh Component
+	if factory, ok := p.factories[name]; ok {
+		fresh = factory()
+	} else if cloner, ok := component.(Cloner); ok {
+		fresh = cloner.Clone()
+	}
+	if fresh == nil {
+		return component
+	}
+	fresh.SetName(name)
+	return fresh
+}
+
+// RunInstance runs a new Instance of the pipeline and returns it, so the
+// caller can inspect the context and errors of that run. Unlike Run it may be
+// called concurrently.
+func (p *Pipeline) RunInstance(ctx context.Context) (*Pipeline, error) {
+	run := p.Instance()
+	return run, run.Run(ctx)
+}
+
+// Clone returns a new Instance, so sub-pipelines used as components get fresh
+// components in every instance of the enclosing pipeline.
+func (p *Pipeline) Clone() Component {
+	return p.Instance()
+}
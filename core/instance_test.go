@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func identity(name string) *FuncComponent {
+	return Func1(name, func(ctx context.Context, n int) (int, error) { return n, nil })
+}
+
+func TestInstance(t *testing.T) {
+	created := 0
+	sub := NewPipeline("sub")
+	sub.AddComponent("loop", NewLoopEntry[int](3, nil))
+
+	p := NewPipeline("instances")
+	p.AddComponent("shared", identity("shared"))
+	p.AddComponentFactory("made", func() Component {
+		created++
+		return identity("made")
+	})
+	p.AddComponent("loop", NewLoopEntry[int](3, nil))
+	p.AddComponent("sub", sub)
+	if len(p.Errors()) > 0 {
+		t.Fatal(p.Errors())
+	}
+
+	run := p.Instance()
+	original, copied := p.GetComponents(), run.GetComponents()
+	if copied["shared"] != original["shared"] {
+		t.Error("Expected components without factory or Clone to be shared")
+	}
+	for _, name := range []string{"made", "loop", "sub"} {
+		if copied[name] == original[name] {
+			t.Errorf("Expected a fresh %s", name)
+		}
+		if copied[name].Name() != name {
+			t.Errorf("Expected fresh component to be named %s, got %s", name, copied[name].Name())
+		}
+	}
+	if created != 2 {
+		t.Errorf("Expected the factory to be called once per pipeline and instance, got %d", created)
+	}
+	if copied["sub"].(*Pipeline).GetComponents()["loop"] == sub.GetComponents()["loop"] {
+		t.Error("Expected sub-pipeline components to be cloned")
+	}
+	if run.GetContext() == p.GetContext() || run.GetContext().ExecutionID == p.GetContext().ExecutionID {
+		t.Error("Expected the instance to have its own context and execution ID")
+	}
+	if run.GetErrorCollector() == p.GetErrorCollector() {
+		t.Error("Expected the instance to have its own error collector")
+	}
+
+	run.AddComponent("extra", identity("extra"))
+	if _, ok := p.GetComponents()["extra"]; ok {
+		t.Error("Expected changes to the instance to leave the pipeline alone")
+	}
+}
+
+func TestAddComponentFactoryErrors(t *testing.T) {
+	p := NewPipeline("factories")
+	p.AddComponentFactory("missing", nil)
+	p.AddComponentFactory("empty", func() Component { return nil })
+	if len(p.Errors()) != 2 {
+		t.Errorf("Expected 2 construction errors, got %v", p.Errors())
+	}
+	if len(p.GetComponents()) != 0 {
+		t.Errorf("Expected no components, got %v", p.GetComponents())
+	}
+}
@@ -0,0 +1,462 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// JSONSchemaDialect is the meta-schema URI written to exported schemas.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaNode is one node of a JSON Schema (draft 2020-12) document. Only the
+// keywords go-flow can validate are modelled; annotations such as title,
+// default and examples are kept so documents round-trip. The formats listed
+// in FormatConstraint are asserted; other formats are annotations.
+type SchemaNode struct {
+	Schema  string                 `json:"$schema,omitempty"`
+	ID      string                 `json:"$id,omitempty"`
+	Ref     string                 `json:"$ref,omitempty"`
+	Defs    map[string]*SchemaNode `json:"$defs,omitempty"`
+	Comment string                 `json:"$comment,omitempty"`
+
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Examples    []interface{} `json:"examples,omitempty"`
+
+	AllOf []*SchemaNode `json:"allOf,omitempty"`
+	AnyOf []*SchemaNode `json:"anyOf,omitempty"`
+	Not   *SchemaNode   `json:"not,omitempty"`
+
+	Type  SchemaTypes   `json:"type,omitempty"`
+	Enum  []interface{} `json:"enum,omitempty"`
+	Const interface{}   `json:"const,omitempty"`
+
+	Properties           map[string]*SchemaNode `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *SchemaNode            `json:"additionalProperties,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+
+	Items       *SchemaNode `json:"items,omitempty"`
+	MinItems    *int        `json:"minItems,omitempty"`
+	MaxItems    *int        `json:"maxItems,omitempty"`
+	UniqueItems bool        `json:"uniqueItems,omitempty"`
+
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Format    string `json:"format,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+
+	// Never is set for the boolean schema false, which rejects every value.
+	Never bool `json:"-"`
+}
+
+// SchemaTypes is the value of the "type" keyword. A single type is written
+// as a string, several as an array.
+type SchemaTypes []string
+
+// MarshalJSON writes one type as a string and several as an array.
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (t *SchemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaTypes{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// Has reports whether the type list contains name.
+func (t SchemaTypes) Has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+type schemaNodeJSON SchemaNode
+
+// MarshalJSON writes the node, using the boolean schema false for Never.
+func (n *SchemaNode) MarshalJSON() ([]byte, error) {
+	if n.Never {
+		return []byte("false"), nil
+	}
+	return json.Marshal((*schemaNodeJSON)(n))
+}
+
+// UnmarshalJSON reads a schema object or a boolean schema. Keywords that
+// go-flow does not support are rejected rather than silently ignored.
+func (n *SchemaNode) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*n = SchemaNode{}
+		return nil
+	case "false":
+		*n = SchemaNode{Never: true}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var node schemaNodeJSON
+	if err := dec.Decode(&node); err != nil {
+		return fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	*n = SchemaNode(node)
+	for _, typ := range n.Type {
+		switch typ {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return fmt.Errorf("invalid JSON Schema: unknown type %q", typ)
+		}
+	}
+	if n.Pattern != "" {
+		if _, err := compilePattern(n.Pattern); err != nil {
+			return fmt.Errorf("invalid JSON Schema pattern %q: %w", n.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// patterns caches compiled "pattern" keywords, which are shared by all
+// validations of a schema.
+var patterns sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// schemaValidator validates values against a document, resolving "$ref"
+// pointers against its root.
+type schemaValidator struct {
+	root *SchemaNode
+}
+
+func (v *schemaValidator) resolve(ref string) (*SchemaNode, error) {
+	if ref == "#" {
+		return v.root, nil
+	}
+	if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+		if def, ok := v.root.Defs[name]; ok {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("unresolvable $ref %q", ref)
+}
+
+// validate checks value against node. The path locates the value in the
+// document being validated; failures are returned as *ConstraintError.
+func (v *schemaValidator) validate(node *SchemaNode, value interface{}, path string) error {
+	if node.Never {
+		return constraintErrorf(path, "false", value, "no value is allowed")
+	}
+	if node.Ref != "" {
+		target, err := v.resolve(node.Ref)
+		if err != nil {
+			return constraintErrorf(path, "$ref", value, "%v", err)
+		}
+		if err := v.validate(target, value, path); err != nil {
+			return err
+		}
+	}
+
+	for _, option := range node.AllOf {
+		if err := v.validate(option, value, path); err != nil {
+			return err
+		}
+	}
+	if len(node.AnyOf) > 0 {
+		var causes []*ConstraintError
+		for _, option := range node.AnyOf {
+			err := v.validate(option, value, path)
+			if err == nil {
+				causes = nil
+				break
+			}
+			causes = append(causes, asConstraintError(err, path, "anyOf", value))
+		}
+		if causes != nil {
+			return anyOfError(path, "anyOf", value, causes)
+		}
+	}
+	if node.Not != nil && v.validate(node.Not, value, path) == nil {
+		return constraintErrorf(path, "not", value, "value must not match the schema in not")
+	}
+
+	value, err := genericValue(value)
+	if err != nil {
+		return constraintErrorf(path, "type", value, "%v", err)
+	}
+
+	if len(node.Type) > 0 && !matchesAnyType(node.Type, value) {
+		return constraintErrorf(path, "type", value, "expected %s, got %s", strings.Join(node.Type, " or "), jsonTypeOf(value))
+	}
+	if len(node.Enum) > 0 && !containsJSONValue(node.Enum, value) {
+		return constraintErrorf(path, "enum", value, "value %v is not one of %v", value, node.Enum)
+	}
+	if node.Const != nil && !jsonEqual(node.Const, value) {
+		return constraintErrorf(path, "const", value, "value %v must equal %v", value, node.Const)
+	}
+
+	switch val := reflect.ValueOf(value); {
+	case value == nil:
+	case val.Kind() == reflect.String:
+		return v.validateString(node, val.String(), path)
+	case isNumberKind(val.Kind()):
+		return v.validateNumber(node, numberOf(val), path)
+	case val.Kind() == reflect.Map:
+		return v.validateObject(node, val, path)
+	case val.Kind() == reflect.Slice || val.Kind() == reflect.Array:
+		return v.validateArray(node, val, path)
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateString(node *SchemaNode, s string, path string) error {
+	length := len([]rune(s))
+	if node.MinLength != nil && length < *node.MinLength {
+		return constraintErrorf(path, "minLength", s, "string length %d is less than minimum %d", length, *node.MinLength)
+	}
+	if node.MaxLength != nil && length > *node.MaxLength {
+		return constraintErrorf(path, "maxLength", s, "string length %d exceeds maximum %d", length, *node.MaxLength)
+	}
+	if node.Pattern != "" {
+		re, err := compilePattern(node.Pattern)
+		if err != nil {
+			return constraintErrorf(path, "pattern", s, "invalid pattern %q: %v", node.Pattern, err)
+		}
+		if !re.MatchString(s) {
+			return constraintErrorf(path, "pattern", s, "string does not match pattern %s", node.Pattern)
+		}
+	}
+	if node.Format != "" {
+		if err := checkFormat(node.Format, s); err != nil {
+			return constraintErrorf(path, "format", s, "%v", err)
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateNumber(node *SchemaNode, n float64, path string) error {
+	if node.Minimum != nil && n < *node.Minimum {
+		return constraintErrorf(path, "minimum", n, "value %v is less than minimum %v", n, *node.Minimum)
+	}
+	if node.Maximum != nil && n > *node.Maximum {
+		return constraintErrorf(path, "maximum", n, "value %v exceeds maximum %v", n, *node.Maximum)
+	}
+	if node.ExclusiveMinimum != nil && n <= *node.ExclusiveMinimum {
+		return constraintErrorf(path, "exclusiveMinimum", n, "value %v must be greater than %v", n, *node.ExclusiveMinimum)
+	}
+	if node.ExclusiveMaximum != nil && n >= *node.ExclusiveMaximum {
+		return constraintErrorf(path, "exclusiveMaximum", n, "value %v must be less than %v", n, *node.ExclusiveMaximum)
+	}
+	if node.MultipleOf != nil && *node.MultipleOf > 0 {
+		if q := n / *node.MultipleOf; q != math.Trunc(q) {
+			return constraintErrorf(path, "multipleOf", n, "value %v is not a multiple of %v", n, *node.MultipleOf)
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateObject(node *SchemaNode, obj reflect.Value, path string) error {
+	if obj.Type().Key().Kind() != reflect.String {
+		return constraintErrorf(path, "type", obj.Interface(), "object keys must be strings, got %s", obj.Type().Key())
+	}
+	count := obj.Len()
+	if node.MinProperties != nil && count < *node.MinProperties {
+		return constraintErrorf(path, "minProperties", obj.Interface(), "object has %d properties, fewer than %d", count, *node.MinProperties)
+	}
+	if node.MaxProperties != nil && count > *node.MaxProperties {
+		return constraintErrorf(path, "maxProperties", obj.Interface(), "object has %d properties, more than %d", count, *node.MaxProperties)
+	}
+	for _, name := range node.Required {
+		if !obj.MapIndex(reflect.ValueOf(name).Convert(obj.Type().Key())).IsValid() {
+			return constraintErrorf(path, "required", obj.Interface(), "missing required property %q", name)
+		}
+	}
+
+	keys := obj.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		name := key.String()
+		child := node.AdditionalProperties
+		if prop, ok := node.Properties[name]; ok {
+			child = prop
+		}
+		if child == nil {
+			continue
+		}
+		if err := v.validate(child, obj.MapIndex(key).Interface(), path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateArray(node *SchemaNode, arr reflect.Value, path string) error {
+	length := arr.Len()
+	if node.MinItems != nil && length < *node.MinItems {
+		return constraintErrorf(path, "minItems", arr.Interface(), "array has %d items, fewer than %d", length, *node.MinItems)
+	}
+	if node.MaxItems != nil && length > *node.MaxItems {
+		return constraintErrorf(path, "maxItems", arr.Interface(), "array has %d items, more than %d", length, *node.MaxItems)
+	}
+	if node.UniqueItems {
+		if i, j, ok := firstDuplicate(arr); ok {
+			return constraintErrorf(path, "uniqueItems", arr.Interface(), "items %d and %d are equal", i, j)
+		}
+	}
+	if node.Items == nil {
+		return nil
+	}
+	for i := 0; i < length; i++ {
+		if err := v.validate(node.Items, arr.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genericValue dereferences pointers and converts structs and other JSON
+// marshalers into their generic JSON form so they can be validated like
+// map-shaped data.
+func genericValue(value interface{}) (interface{}, error) {
+	val := reflect.ValueOf(value)
+	for val.IsValid() && (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) {
+		if val.IsNil() {
+			return nil, nil
+		}
+		if _, ok := val.Interface().(json.Marshaler); ok {
+			break
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return nil, nil
+	}
+
+	_, marshaler := val.Interface().(json.Marshaler)
+	bytesValue := val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.Uint8
+	if !marshaler && !bytesValue && val.Kind() != reflect.Struct {
+		return val.Interface(), nil
+	}
+	data, err := json.Marshal(val.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert %s to JSON: %w", val.Type(), err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func numberOf(val reflect.Value) float64 {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint())
+	default:
+		return val.Float()
+	}
+}
+
+// jsonTypeOf returns the JSON type of a generic value.
+func jsonTypeOf(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	val := reflect.ValueOf(value)
+	switch k := val.Kind(); {
+	case k == reflect.Bool:
+		return "boolean"
+	case k == reflect.String:
+		return "string"
+	case k == reflect.Float32 || k == reflect.Float64:
+		if f := val.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	case isNumberKind(k):
+		return "integer"
+	case k == reflect.Map:
+		return "object"
+	case k == reflect.Slice || k == reflect.Array:
+		return "array"
+	default:
+		return val.Type().String()
+	}
+}
+
+func matchesAnyType(types SchemaTypes, value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, typ := range types {
+		if typ == actual || (typ == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonEqual compares values by their JSON encoding, so 1 and 1.0 are equal.
+func jsonEqual(a, b interface{}) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+func containsJSONValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if jsonEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LifecyclePhase is a phase of Pipeline.Run.
+type LifecyclePhase int
+
+const (
+	LifecyclePhaseValidate LifecyclePhase = iota
+	LifecyclePhaseInitialize
+	LifecyclePhaseHealthCheck
+	LifecyclePhaseExecute
+	LifecyclePhaseCleanup
+)
+
+func (lp LifecyclePhase) String() string {
+	switch lp {
+	case LifecyclePhaseValidate:
+		return "validate"
+	case LifecyclePhaseInitialize:
+		return "initialize"
+	case LifecyclePhaseHealthCheck:
+		return "health_check"
+	case LifecyclePhaseExecute:
+		return "execute"
+	case LifecyclePhaseCleanup:
+		return "cleanup"
+	default:
+		return "unknown"
+	}
+}
+
+// LifecycleHooks customizes the lifecycle of Pipeline.Run. The zero value
+// runs every phase.
+type LifecycleHooks struct {
+	// Skip phases that are managed elsewhere, e.g. components initialized
+	// once by the host service. Execution cannot be skipped.
+	SkipValidate    bool
+	SkipInitialize  bool
+	SkipHealthCheck bool
+	SkipCleanup     bool
+
+	// BeforePhase is called before each phase that runs. Returning an error
+	// fails the phase without running it.
+	BeforePhase func(ctx context.Context, phase LifecyclePhase) error
+	// AfterPhase is called after each phase that runs, with its error.
+	AfterPhase func(ctx context.Context, phase LifecyclePhase, err error)
+}
+
+func (h *LifecycleHooks) skips(phase LifecyclePhase) bool {
+	switch phase {
+	case LifecyclePhaseValidate:
+		return h.SkipValidate
+	case LifecyclePhaseInitialize:
+		return h.SkipInitialize
+	case LifecyclePhaseHealthCheck:
+		return h.SkipHealthCheck
+	case LifecyclePhaseCleanup:
+		return h.SkipCleanup
+	default:
+		return false
+	}
+}
+
+// SetLifecycleHooks sets the hooks used by Run.
+func (p *Pipeline) SetLifecycleHooks(hooks *LifecycleHooks) *Pipeline {
+	p.lifecycleHooks = hooks
+	return p
+}
+
+// LifecycleHooks returns the hooks used by Run, never nil.
+func (p *Pipeline) LifecycleHooks() *LifecycleHooks {
+	if p.lifecycleHooks == nil {
+		return &LifecycleHooks{}
+	}
+	return p.lifecycleHooks
+}
+
+// runLifecycle validates, initializes in topological order, health-checks
+// and executes the pipeline, then cleans up the initialized components in
+// reverse order. Cleanup also runs when an earlier phase fails or panics.
+// The first failure is returned; cleanup failures after it are collected.
+func (p *Pipeline) runLifecycle(ctx context.Context, inputs map[string]chan interface{}, outputs map[string]chan interface{}) (err error) {
+	hooks := p.LifecycleHooks()
+	var initialized []string
+
+	p.context.StartTime = time.Now()
+	status := PipelineStatusRunning
+	if control := RunControlFromContext(ctx); control != nil && control.Paused() {
+		status = PipelineStatusPaused
+	}
+	p.context.SetStatus(status)
+
+	panicking := true
+	defer func() {
+		if !hooks.skips(LifecyclePhaseCleanup) {
+			// Clean up even if the caller's context was cancelled
+			cleanupCtx := context.WithoutCancel(ctx)
+			cleanupErr := p.runPhase(cleanupCtx, hooks, LifecyclePhaseCleanup, func() *BasePipelineError {
+				return p.cleanupComponents(cleanupCtx, initialized)
+			})
+			if cleanupErr != nil && err == nil && !panicking {
+				err = cleanupErr
+			} else if cleanupErr != nil {
+				p.errorCollector.Collect(cleanupErr.(PipelineError))
+			}
+		}
+		if err != nil || panicking {
+			p.context.SetStatus(PipelineStatusError)
+		} else {
+			p.context.SetStatus(PipelineStatusIdle)
+		}
+	}()
+
+	err = p.runPhases(ctx, hooks, &initialized, inputs, outputs)
+	panicking = false
+	return err
+}
+
+// runPhases runs every phase before cleanup, recording the components that
+// were initialized.
+func (p *Pipeline) runPhases(ctx context.Context, hooks *LifecycleHooks, initialized *[]string, inputs map[string]chan interface{}, outputs map[string]chan interface{}) error {
+	order := p.lifecycleOrder()
+	if err := p.runPhase(ctx, hooks, LifecyclePhaseValidate, p.validateComponents); err != nil {
+		return err
+	}
+	owned := make([]string, 0, len(order))
+	for _, name := range order {
+		if !p.shared[name] {
+			owned = append(owned, name)
+		}
+	}
+	if hooks.SkipInitialize {
+		// Components initialized elsewhere are still cleaned up here
+		*initialized = owned
+	}
+	if err := p.runPhase(ctx, hooks, LifecyclePhaseInitialize, func() *BasePipelineError {
+		for _, name := range owned {
+			if err := p.components[name].Initialize(ctx); err != nil {
+				return phaseError(LifecyclePhaseInitialize, name, ConfigurationError, err)
+			}
+			*initialized = append(*initialized, name)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := p.runPhase(ctx, hooks, LifecyclePhaseHealthCheck, func() *BasePipelineError {
+		for _, name := range order {
+			if err := p.components[name].HealthCheck(ctx); err != nil {
+				return phaseError(LifecyclePhaseHealthCheck, name, ResourceError, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return p.runPhase(ctx, hooks, LifecyclePhaseExecute, func() *BasePipelineError {
+		if err := p.engine.Run(ctx, p, inputs, outputs); err != nil {
+			return phaseError(LifecyclePhaseExecute, "", RuntimeError, err)
+		}
+		return nil
+	})
+}
+
+// runPhase runs one phase unless the hooks skip it. Failures are returned
+// as PipelineErrors with the phase in their context.
+func (p *Pipeline) runPhase(ctx context.Context, hooks *LifecycleHooks, phase LifecyclePhase, run func() *BasePipelineError) error {
+	if hooks.skips(phase) {
+		return nil
+	}
+	var pipelineErr *BasePipelineError
+	if hooks.BeforePhase != nil {
+		if err := hooks.BeforePhase(ctx, phase); err != nil {
+			pipelineErr = phaseError(phase, "", ConfigurationError, err)
+		}
+	}
+	if pipelineErr == nil {
+		pipelineErr = run()
+	}
+	var err error
+	if pipelineErr != nil {
+		err = pipelineErr
+	}
+	if hooks.AfterPhase != nil {
+		hooks.AfterPhase(ctx, phase, err)
+	}
+	return err
+}
+
+// validateComponents checks the graph and every component, reporting the
+// first failure.
+func (p *Pipeline) validateComponents() *BasePipelineError {
+	if err := p.detectCycles(); err != nil {
+		return phaseError(LifecyclePhaseValidate, "", ValidationError, err)
+	}
+	if err := p.validateLoops(); err != nil {
+		return phaseError(LifecyclePhaseValidate, "", ValidationError, err)
+	}
+	for _, name := range p.lifecycleOrder() {
+		if err := p.components[name].Validate(); err != nil {
+			return phaseError(LifecyclePhaseValidate, name, ValidationError, err)
+		}
+	}
+	return nil
+}
+
+// cleanupComponents cleans up the named components in reverse order. Every
+// component is cleaned up; the first failure is returned and the rest are
+// collected.
+func (p *Pipeline) cleanupComponents(ctx context.Context, names []string) *BasePipelineError {
+	var first *BasePipelineError
+	for i := len(names) - 1; i >= 0; i-- {
+		if err := p.components[names[i]].Cleanup(ctx); err != nil {
+			cleanupErr := phaseError(LifecyclePhaseCleanup, names[i], ResourceError, err)
+			if first == nil {
+				first = cleanupErr
+			} else {
+				p.errorCollector.Collect(cleanupErr)
+			}
+		}
+	}
+	return first
+}
+
+// phaseError describes a lifecycle failure. Errors that already are
+// PipelineErrors keep their type and severity.
+func phaseError(phase LifecyclePhase, component string, errorType ErrorType, err error) *BasePipelineError {
+	severity, recoverable := Error, false
+	var pipelineErr PipelineError
+	if errors.As(err, &pipelineErr) {
+		errorType, severity, recoverable = pipelineErr.ErrorType(), pipelineErr.Severity(), pipelineErr.Recoverable()
+		if component == "" {
+			component = pipelineErr.Component()
+		}
+	}
+
+	message := fmt.Sprintf("%s failed: %v", phase, err)
+	if component != "" {
+		message = fmt.Sprintf("%s of component %s failed: %v", phase, component, err)
+	}
+	return NewPipelineError(message, component, errorType, severity, recoverable).
+		WithOriginalError(err).
+		WithContext("phase", phase.String())
+}
+
+// lifecycleOrder returns the components in topological order, ignoring
+// feedback connections. Ties and components on cycles are ordered by name, so
+// the order is deterministic.
+func (p *Pipeline) lifecycleOrder() []string {
+	inDegree := make(map[string]int, len(p.components))
+	dependents := make(map[string][]string)
+	for name := range p.components {
+		inDegree[name] = 0
+	}
+	seen := make(map[[2]string]bool)
+	for _, conn := range p.connections {
+		edge := [2]string{conn.FromComponent, conn.ToComponent}
+		if seen[edge] || conn.Feedback {
+			continue
+		}
+		if _, ok := p.components[conn.FromComponent]; !ok {
+			continue
+		}
+		if _, ok := p.components[conn.ToComponent]; !ok {
+			continue
+		}
+		seen[edge] = true
+		inDegree[conn.ToComponent]++
+		dependents[conn.FromComponent] = append(dependents[conn.FromComponent], conn.ToComponent)
+	}
+
+	var ready []string
+	for name, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sort.Strings(ready)
+
+	order := make([]string, 0, len(p.components))
+	done := make(map[string]bool, len(p.components))
+	for len(order) < len(p.components) {
+		if len(ready) == 0 {
+			// Break a cycle at its first remaining component by name
+			var rest []string
+			for name := range p.components {
+				if !done[name] {
+					rest = append(rest, name)
+				}
+			}
+			sort.Strings(rest)
+			ready = rest[:1]
+		}
+		current := ready[0]
+		ready = ready[1:]
+		if done[current] {
+			continue
+		}
+		done[current] = true
+		order = append(order, current)
+
+		var next []string
+		for _, dependent := range dependents[current] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 && !done[dependent] {
+				next = append(next, dependent)
+			}
+		}
+		ready = append(ready, next...)
+		sort.Strings(ready)
+	}
+	return order
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// lifecycleLog records lifecycle calls across components.
+type lifecycleLog struct {
+	calls []string
+}
+
+// lifecycleComponent records its lifecycle calls and fails the phases listed
+// in fail.
+type lifecycleComponent struct {
+	BaseComponent
+	log  *lifecycleLog
+	fail map[string]bool
+}
+
+func newLifecycleComponent(log *lifecycleLog, fail ...string) *lifecycleComponent {
+	c := &lifecycleComponent{log: log, fail: map[string]bool{}}
+	for _, phase := range fail {
+		c.fail[phase] = true
+	}
+	c.Inputs = []Port{&BasePort{PortName: "input", PortType: reflect.TypeOf("")}}
+	c.Outputs = []Port{&BasePort{PortName: "output", PortType: reflect.TypeOf("")}}
+	return c
+}
+
+func (c *lifecycleComponent) record(phase string) error {
+	c.log.calls = append(c.log.calls, phase+":"+c.Name())
+	if c.fail[phase] {
+		return errors.New(phase + " failed")
+	}
+	return nil
+}
+
+func (c *lifecycleComponent) Validate() error { return c.record("validate") }
+func (c *lifecycleComponent) Initialize(ctx context.Context) error {
+	return c.record("initialize")
+}
+func (c *lifecycleComponent) HealthCheck(ctx context.Context) error {
+	return c.record("health")
+}
+func (c *lifecycleComponent) Cleanup(ctx context.Context) error { return c.record("cleanup") }
+
+// lifecycleEngine records that it ran and optionally fails or panics.
+type lifecycleEngine struct {
+	log   *lifecycleLog
+	err   error
+	panic bool
+}
+
+func (e *lifecycleEngine) Run(ctx context.Context, p *Pipeline, inputs, outputs map[string]chan interface{}) error {
+	e.log.calls = append(e.log.calls, "execute")
+	if e.panic {
+		panic("engine exploded")
+	}
+	return e.err
+}
+
+func (e *lifecycleEngine) Close() error { return nil }
+
+// newLifecyclePipeline builds c -> b -> a, so topological order differs
+// from name order.
+func newLifecyclePipeline(log *lifecycleLog, engine *lifecycleEngine, fail map[string][]string) *Pipeline {
+	p := NewPipeline("lifecycle")
+	for _, name := range []string{"a", "b", "c"} {
+		p.AddComponent(name, newLifecycleComponent(log, fail[name]...))
+	}
+	p.ConnectPorts("c", "output", "b", "input")
+	p.ConnectPorts("b", "output", "a", "input")
+	p.SetEngine(engine)
+	return p
+}
+
+func TestRunLifecycleOrder(t *testing.T) {
+	log := &lifecycleLog{}
+	p := newLifecyclePipeline(log, &lifecycleEngine{log: log}, nil)
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []string{
+		"validate:c", "validate:b", "validate:a",
+		"initialize:c", "initialize:b", "initialize:a",
+		"health:c", "health:b", "health:a",
+		"execute",
+		"cleanup:a", "cleanup:b", "cleanup:c",
+	}
+	if !reflect.DeepEqual(log.calls, want) {
+		t.Errorf("Unexpected lifecycle:\n got %v\nwant %v", log.calls, want)
+	}
+	if p.GetContext().Status != PipelineStatusIdle {
+		t.Errorf("Expected status IDLE after the run, got %s", p.GetContext().Status)
+	}
+}
+
+func TestRunLifecycleFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      map[string][]string
+		engineErr error
+		phase     string
+		component string
+		cleanups  []string
+	}{
+		{"validate", map[string][]string{"b": {"validate"}}, nil, "validate", "b", nil},
+		{"initialize", map[string][]string{"b": {"initialize"}}, nil, "initialize", "b", []string{"cleanup:c"}},
+		{"health check", map[string][]string{"a": {"health"}}, nil, "health_check", "a", []string{"cleanup:a", "cleanup:b", "cleanup:c"}},
+		{"execute", nil, errors.New("boom"), "execute", "", []string{"cleanup:a", "cleanup:b", "cleanup:c"}},
+		{"cleanup", map[string][]string{"a": {"cleanup"}, "c": {"cleanup"}}, nil, "cleanup", "a", []string{"cleanup:a", "cleanup:b", "cleanup:c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := &lifecycleLog{}
+			p := newLifecyclePipeline(log, &lifecycleEngine{log: log, err: tt.engineErr}, tt.fail)
+			err := p.Run(context.Background())
+
+			var pipelineErr PipelineError
+			if !errors.As(err, &pipelineErr) {
+				t.Fatalf("Expected a PipelineError, got %v", err)
+			}
+			if got := pipelineErr.Context()["phase"]; got != tt.phase {
+				t.Errorf("Expected phase %s, got %v (%v)", tt.phase, got, err)
+			}
+			if pipelineErr.Component() != tt.component {
+				t.Errorf("Expected component %q, got %q", tt.component, pipelineErr.Component())
+			}
+
+			var cleanups []string
+			for _, call := range log.calls {
+				if len(call) > 8 && call[:8] == "cleanup:" {
+					cleanups = append(cleanups, call)
+				}
+			}
+			if !reflect.DeepEqual(cleanups, tt.cleanups) {
+				t.Errorf("Expected cleanups %v, got %v", tt.cleanups, cleanups)
+			}
+			if p.GetContext().Status != PipelineStatusError {
+				t.Errorf("Expected status ERROR, got %s", p.GetContext().Status)
+			}
+		})
+	}
+}
+
+func TestRunLifecycleCleansUpAfterPanic(t *testing.T) {
+	log := &lifecycleLog{}
+	p := newLifecyclePipeline(log, &lifecycleEngine{log: log, panic: true}, nil)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to propagate")
+			}
+		}()
+		p.Run(context.Background())
+	}()
+
+	if n := len(log.calls); n < 3 || log.calls[n-1] != "cleanup:c" {
+		t.Errorf("Expected cleanup to run after the panic, got %v", log.calls)
+	}
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	log := &lifecycleLog{}
+	var phases []string
+	p := newLifecyclePipeline(log, &lifecycleEngine{log: log}, map[string][]string{"b": {"validate", "health"}})
+	p.SetLifecycleHooks(&LifecycleHooks{
+		SkipValidate:    true,
+		SkipHealthCheck: true,
+		SkipCleanup:     true,
+		AfterPhase: func(ctx context.Context, phase LifecyclePhase, err error) {
+			phases = append(phases, phase.String())
+		},
+	})
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatalf("Skipped phases should not fail the run: %v", err)
+	}
+	if !reflect.DeepEqual(phases, []string{"initialize", "execute"}) {
+		t.Errorf("Unexpected phases %v", phases)
+	}
+
+	p.SetLifecycleHooks(&LifecycleHooks{
+		BeforePhase: func(ctx context.Context, phase LifecyclePhase) error {
+			if phase == LifecyclePhaseExecute {
+				return errors.New("maintenance window")
+			}
+			return nil
+		},
+	})
+	log.calls = nil
+	p = newLifecyclePipeline(log, &lifecycleEngine{log: log}, nil).SetLifecycleHooks(p.LifecycleHooks())
+	if err := p.Run(context.Background()); err == nil {
+		t.Fatal("Expected BeforePhase to fail the run")
+	}
+	for _, call := range log.calls {
+		if call == "execute" {
+			t.Error("Execution should not run when BeforePhase fails")
+		}
+	}
+	if log.calls[len(log.calls)-1] != "cleanup:c" {
+		t.Errorf("Expected cleanup after the hook failure, got %v", log.calls)
+	}
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// LoopBoundary is implemented by components that may close a feedback loop.
+// A connection into one of its feedback ports is a back edge: cycle checks
+// and topological orders ignore it, and engines iterate the loop instead of
+// deadlocking on it. Cycles are only accepted when the pipeline's config
+// sets AllowCycles and every cycle passes through a feedback port.
+type LoopBoundary interface {
+	Component
+	FeedbackPorts() []string
+}
+
+// LoopEntry is the entry of a feedback loop. The packet on its "init" port
+// is sent into the loop body through "body", and the body's result comes
+// back on "feedback". The entry sends the result around again until it
+// converges or the iteration limit is reached, and then emits it on "done".
+//
+//	loop := core.NewLoopEntry(10, func(previous, current float64) bool {
+//		return math.Abs(current-previous) < 1e-6
+//	})
+//	p.AddComponent("loop", loop).AddComponent("refine", refine)
+//	core.Connect[float64](p, "loop", "body", "refine", "input")
+//	core.Connect[float64](p, "refine", "output", "loop", "feedback")
+type LoopEntry struct {
+	BaseComponent
+	maxIterations int
+	converged     func(previous, current interface{}) bool
+
+	mu           sync.Mutex
+	iterations   int
+	previous     interface{}
+	hasConverged bool
+}
+
+// NewLoopEntry creates a loop entry whose ports carry T. The loop stops
+// after maxIterations passes through the body, or earlier once converged
+// reports true for the results of two consecutive passes; the first pass is
+// compared with the initial packet. A nil converged only limits iterations.
+func NewLoopEntry[T any](maxIterations int, converged func(previous, current T) bool) *LoopEntry {
+	c := &LoopEntry{maxIterations: maxIterations}
+	c.ComponentDescription = "Iterates a loop body until convergence"
+	c.ComponentTags = []string{"loop"}
+	c.Inputs = []Port{typedPort[T]("init", true), typedPort[T]("feedback", false)}
+	c.Outputs = []Port{typedPort[T]("body", false), typedPort[T]("done", false)}
+	if converged != nil {
+		c.converged = func(previous, current interface{}) bool {
+			p, _ := previous.(T)
+			q, _ := current.(T)
+			return converged(p, q)
+		}
+	}
+	return c
+}
+
+// FeedbackPorts returns the "feedback" port.
+func (c *LoopEntry) FeedbackPorts() []string {
+	return []string{"feedback"}
+}
+
+// Clone returns a loop entry with the same limit and convergence test and no
+// iterations.
+func (c *LoopEntry) Clone() Component {
+	clone := &LoopEntry{maxIterations: c.maxIterations, converged: c.converged}
+	clone.ComponentDescription = c.ComponentDescription
+	clone.ComponentTags = c.ComponentTags
+	clone.Inputs = c.Inputs
+	clone.Outputs = c.Outputs
+	return clone
+}
+
+// Validate requires a positive iteration limit, so every loop terminates.
+func (c *LoopEntry) Validate() error {
+	if c.maxIterations <= 0 {
+		return fmt.Errorf("loop entry %s needs a positive iteration limit, got %d", c.Name(), c.maxIterations)
+	}
+	return nil
+}
+
+// Process starts a loop on an "init" packet and continues or ends it on a
+// "feedback" packet.
+func (c *LoopEntry) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if value, ok := inputs["feedback"]; ok {
+		c.iterations++
+		c.hasConverged = c.converged != nil && c.converged(c.previous, value)
+		c.previous = value
+		if c.hasConverged || c.iterations >= c.maxIterations {
+			return map[string]interface{}{"done": value}, nil
+		}
+		return map[string]interface{}{"body": value}, nil
+	}
+
+	value, ok := inputs["init"]
+	if !ok {
+		return nil, funcInputError(c.Name(), "init", c.Inputs[0].Type(), nil)
+	}
+	c.iterations, c.previous, c.hasConverged = 0, value, false
+	return map[string]interface{}{"body": value}, nil
+}
+
+// Iterations returns the number of passes through the body of the last loop.
+func (c *LoopEntry) Iterations() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.iterations
+}
+
+// Converged reports whether the last loop ended by converging rather than by
+// reaching the iteration limit.
+func (c *LoopEntry) Converged() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hasConverged
+}
+
+// isFeedbackPort reports whether the named input port of a component is a
+// feedback port of a LoopBoundary.
+func isFeedbackPort(component Component, port string) bool {
+	boundary, ok := component.(LoopBoundary)
+	if !ok {
+		return false
+	}
+	for _, name := range boundary.FeedbackPorts() {
+		if name == port {
+			return true
+		}
+	}
+	return false
+}
+
+// validateLoops checks the feedback connections: they need AllowCycles, and
+// each must close a loop, that is its source must be reachable from the
+// loop boundary without crossing another feedback connection.
+func (p *Pipeline) validateLoops() error {
+	forward := make(map[string][]string)
+	var feedback []Connection
+	for _, conn := range p.connections {
+		if conn.Feedback {
+			feedback = append(feedback, conn)
+		} else {
+			forward[conn.FromComponent] = append(forward[conn.FromComponent], conn.ToComponent)
+		}
+	}
+	sort.Slice(feedback, func(i, j int) bool { return feedback[i].Name < feedback[j].Name })
+
+	for _, conn := range feedback {
+		if p.config == nil || !p.config.AllowCycles {
+			return fmt.Errorf("feedback connection %s closes a loop, but the pipeline does not allow cycles", conn.Name)
+		}
+		if !reachable(forward, conn.ToComponent, conn.FromComponent) {
+			return fmt.Errorf("feedback connection %s does not close a loop: %s is not downstream of %s", conn.Name, conn.FromComponent, conn.ToComponent)
+		}
+	}
+	return nil
+}
+
+// reachable reports whether to can be reached from from in graph.
+func reachable(graph map[string][]string, from, to string) bool {
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			return true
+		}
+		for _, next := range graph[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newLoopPipeline(allowCycles bool) *Pipeline {
+	p := NewPipeline("loop")
+	p.GetConfig().AllowCycles = allowCycles
+	p.AddComponent("source", Source("source", func(ctx context.Context) (int, error) { return 1, nil }))
+	p.AddComponent("loop", NewLoopEntry[int](5, nil))
+	p.AddComponent("double", Func1("double", func(ctx context.Context, n int) (int, error) { return n * 2, nil }))
+	Connect[int](p, "source", "output", "loop", "init")
+	Connect[int](p, "loop", "body", "double", "input")
+	Connect[int](p, "double", "output", "loop", "feedback")
+	return p
+}
+
+func TestFeedbackConnections(t *testing.T) {
+	p := newLoopPipeline(true)
+	for _, conn := range p.GetConnections() {
+		if want := conn.ToPort == "feedback"; conn.Feedback != want {
+			t.Errorf("Expected Feedback=%v on %s", want, conn.Name)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		t.Errorf("Expected a loop through a loop boundary to be valid, got %v", err)
+	}
+	if result := p.ValidateComprehensive(); !result.Valid {
+		t.Errorf("Expected comprehensive validation to pass, got %+v", result.Errors)
+	}
+	if order := p.lifecycleOrder(); strings.Join(order, ",") != "source,loop,double" {
+		t.Errorf("Expected feedback connections to be ignored in the lifecycle order, got %v", order)
+	}
+	plan, err := p.Plan()
+	if err != nil {
+		t.Fatalf("Expected a plan for a loop, got %v", err)
+	}
+	if plan.Connections[2].Feedback != true {
+		t.Errorf("Expected the plan to mark the feedback connection, got %+v", plan.Connections[2])
+	}
+}
+
+func TestLoopValidation(t *testing.T) {
+	t.Run("requires AllowCycles", func(t *testing.T) {
+		err := newLoopPipeline(false).Validate()
+		if err == nil || !strings.Contains(err.Error(), "does not allow cycles") {
+			t.Errorf("Expected the loop to need AllowCycles, got %v", err)
+		}
+	})
+
+	t.Run("cycle without boundary", func(t *testing.T) {
+		p := NewPipeline("cycle")
+		p.GetConfig().AllowCycles = true
+		p.AddComponent("a", Func1("a", func(ctx context.Context, n int) (int, error) { return n, nil }))
+		p.AddComponent("b", Func1("b", func(ctx context.Context, n int) (int, error) { return n, nil }))
+		Connect[int](p, "a", "output", "b", "input")
+		Connect[int](p, "b", "output", "a", "input")
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "loop boundary") {
+			t.Errorf("Expected a cycle without a loop boundary to be rejected, got %v", err)
+		}
+		if result := p.ValidateComprehensive(); result.Valid {
+			t.Error("Expected comprehensive validation to reject the cycle")
+		}
+	})
+
+	t.Run("feedback from outside the loop", func(t *testing.T) {
+		p := NewPipeline("open")
+		p.GetConfig().AllowCycles = true
+		p.AddComponent("source", Source("source", func(ctx context.Context) (int, error) { return 1, nil }))
+		p.AddComponent("loop", NewLoopEntry[int](5, nil))
+		Connect[int](p, "source", "output", "loop", "init")
+		Connect[int](p, "source", "output", "loop", "feedback")
+		if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "does not close a loop") {
+			t.Errorf("Expected feedback from upstream to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("iteration limit", func(t *testing.T) {
+		if err := NewLoopEntry[int](0, nil).Validate(); err == nil {
+			t.Error("Expected a loop entry without an iteration limit to be invalid")
+		}
+	})
+}
+
+func TestLoopEntry(t *testing.T) {
+	ctx := context.Background()
+	loop := NewLoopEntry(10, func(previous, current int) bool { return current == previous })
+
+	out, err := loop.Process(ctx, map[string]interface{}{"init": 3})
+	if err != nil || out["body"] != 3 {
+		t.Fatalf("Expected init to enter the body, got %v, %v", out, err)
+	}
+	out, _ = loop.Process(ctx, map[string]interface{}{"feedback": 5})
+	if out["body"] != 5 {
+		t.Errorf("Expected a changed value to go around again, got %v", out)
+	}
+	out, _ = loop.Process(ctx, map[string]interface{}{"feedback": 5})
+	if out["done"] != 5 || !loop.Converged() || loop.Iterations() != 2 {
+		t.Errorf("Expected convergence after 2 iterations, got %v after %d", out, loop.Iterations())
+	}
+
+	limited := NewLoopEntry[int](2, nil)
+	limited.Process(ctx, map[string]interface{}{"init": 1})
+	limited.Process(ctx, map[string]interface{}{"feedback": 2})
+	out, _ = limited.Process(ctx, map[string]interface{}{"feedback": 3})
+	if out["done"] != 3 || limited.Converged() {
+		t.Errorf("Expected the iteration limit to end the loop, got %v", out)
+	}
+}
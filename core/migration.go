@@ -0,0 +1,289 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Stateful is implemented by components whose state can be checkpointed.
+type Stateful interface {
+	SaveState() (map[string]interface{}, error)
+	RestoreState(state map[string]interface{}) error
+}
+
+// Checkpoint is a snapshot of a pipeline's component state and of the packets
+// that were in flight on its connections.
+type Checkpoint struct {
+	Pipeline  string                            `json:"pipeline"`
+	Version   string                            `json:"version"`
+	CreatedAt time.Time                         `json:"created_at"`
+	State     map[string]map[string]interface{} `json:"state"`
+	InFlight  []InFlightPacket                  `json:"in_flight,omitempty"`
+}
+
+// InFlightPacket is a value that was queued on a connection.
+type InFlightPacket struct {
+	FromComponent string      `json:"from_component"`
+	FromPort      string      `json:"from_port"`
+	ToComponent   string      `json:"to_component"`
+	ToPort        string      `json:"to_port"`
+	Value         interface{} `json:"value"`
+}
+
+// Checkpoint captures the state of every Stateful component. In-flight packets
+// are left to the caller, which knows what the engine had queued.
+func (p *Pipeline) Checkpoint() (*Checkpoint, error) {
+	cp := &Checkpoint{
+		Pipeline:  p.name,
+		Version:   p.version,
+		CreatedAt: time.Now(),
+		State:     make(map[string]map[string]interface{}),
+	}
+	for name, component := range p.components {
+		stateful, ok := component.(Stateful)
+		if !ok {
+			continue
+		}
+		state, err := stateful.SaveState()
+		if err != nil {
+			return nil, fmt.Errorf("component %s checkpoint failed: %w", name, err)
+		}
+		cp.State[name] = state
+	}
+	return cp, nil
+}
+
+// Restore loads checkpointed state into the pipeline's Stateful components.
+// The checkpoint must have the pipeline's version; migrate it first otherwise.
+func (p *Pipeline) Restore(cp *Checkpoint) error {
+	if !sameVersion(cp.Version, p.version) {
+		return fmt.Errorf("checkpoint version %s does not match pipeline version %s; migrate it first", cp.Version, p.version)
+	}
+	for name, state := range cp.State {
+		component, ok := p.components[name]
+		if !ok {
+			return fmt.Errorf("checkpoint has state for unknown component '%s'", name)
+		}
+		stateful, ok := component.(Stateful)
+		if !ok {
+			return fmt.Errorf("component '%s' cannot restore state", name)
+		}
+		if err := stateful.RestoreState(state); err != nil {
+			return fmt.Errorf("component %s restore failed: %w", name, err)
+		}
+	}
+	for _, packet := range cp.InFlight {
+		if !p.hasConnection(packet.FromComponent, packet.FromPort, packet.ToComponent, packet.ToPort) {
+			return fmt.Errorf("checkpoint has in-flight data for unknown connection %s.%s -> %s.%s",
+				packet.FromComponent, packet.FromPort, packet.ToComponent, packet.ToPort)
+		}
+	}
+	return nil
+}
+
+func (p *Pipeline) hasConnection(fromComponent, fromPort, toComponent, toPort string) bool {
+	for _, conn := range p.connections {
+		if conn.FromComponent == fromComponent && conn.FromPort == fromPort &&
+			conn.ToComponent == toComponent && conn.ToPort == toPort {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrationStep migrates a checkpoint from one pipeline version to the next.
+type MigrationStep struct {
+	From        string
+	To          string
+	Description string
+	Migrate     func(cp *Checkpoint) error
+}
+
+// Migrator holds registered migration steps and chains them to migrate
+// checkpoints across several versions.
+type Migrator struct {
+	steps []MigrationStep
+}
+
+// NewMigrator creates an empty migrator.
+func NewMigrator() *Migrator {
+	return &Migrator{}
+}
+
+// Register adds a migration step. Both versions must be semantic versions and
+// the step must move forward.
+func (m *Migrator) Register(step MigrationStep) error {
+	from, err := ParseVersion(step.From)
+	if err != nil {
+		return fmt.Errorf("migration step: %w", err)
+	}
+	to, err := ParseVersion(step.To)
+	if err != nil {
+		return fmt.Errorf("migration step: %w", err)
+	}
+	if to.Compare(from) <= 0 {
+		return fmt.Errorf("migration step %s -> %s does not move forward", step.From, step.To)
+	}
+	if step.Migrate == nil {
+		return fmt.Errorf("migration step %s -> %s has no Migrate function", step.From, step.To)
+	}
+	for _, existing := range m.steps {
+		if MustParseVersion(existing.From).Compare(from) == 0 && MustParseVersion(existing.To).Compare(to) == 0 {
+			return fmt.Errorf("migration step %s -> %s is already registered", step.From, step.To)
+		}
+	}
+	m.steps = append(m.steps, step)
+	return nil
+}
+
+// Path returns the shortest chain of steps leading from one version to another.
+// Versions are compared in their parsed form, so "1.0" and "1.0.0" are the
+// same version.
+func (m *Migrator) Path(from, to string) ([]MigrationStep, error) {
+	if sameVersion(from, to) {
+		return nil, nil
+	}
+	fromVersion, err := ParseVersion(from)
+	if err != nil {
+		return nil, fmt.Errorf("migration path: %w", err)
+	}
+	toVersion, err := ParseVersion(to)
+	if err != nil {
+		return nil, fmt.Errorf("migration path: %w", err)
+	}
+
+	// Breadth-first search over versions, trying lower targets first so the
+	// chosen path is deterministic
+	steps := append([]MigrationStep(nil), m.steps...)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return MustParseVersion(steps[i].To).Compare(MustParseVersion(steps[j].To)) < 0
+	})
+	stepFrom := make([]string, len(steps))
+	stepTo := make([]string, len(steps))
+	for i, step := range steps {
+		stepFrom[i] = MustParseVersion(step.From).String()
+		stepTo[i] = MustParseVersion(step.To).String()
+	}
+	target := toVersion.String()
+	previous := map[string]int{fromVersion.String(): -1}
+	queue := []string{fromVersion.String()}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for i := range steps {
+			if stepFrom[i] != current {
+				continue
+			}
+			if _, seen := previous[stepTo[i]]; seen {
+				continue
+			}
+			previous[stepTo[i]] = i
+			if stepTo[i] == target {
+				var path []MigrationStep
+				for v := target; previous[v] >= 0; v = stepFrom[previous[v]] {
+					path = append([]MigrationStep{steps[previous[v]]}, path...)
+				}
+				return path, nil
+			}
+			queue = append(queue, stepTo[i])
+		}
+	}
+	return nil, fmt.Errorf("no migration path from version %s to %s", from, to)
+}
+
+// Migrate returns a copy of cp migrated to version to. The original checkpoint
+// is left unchanged.
+func (m *Migrator) Migrate(cp *Checkpoint, to string) (*Checkpoint, error) {
+	path, err := m.Path(cp.Version, to)
+	if err != nil {
+		return nil, err
+	}
+	migrated := cp.clone()
+	for _, step := range path {
+		if err := step.Migrate(migrated); err != nil {
+			return nil, fmt.Errorf("migration %s -> %s failed: %w", step.From, step.To, err)
+		}
+		migrated.Version = step.To
+	}
+	return migrated, nil
+}
+
+// sameVersion reports whether a and b name the same version, comparing
+// semantic versions in their parsed form.
+func sameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	va, errA := ParseVersion(a)
+	vb, errB := ParseVersion(b)
+	return errA == nil && errB == nil && va.Compare(vb) == 0
+}
+
+// clone copies the checkpoint maps and slices. State values are shared.
+func (cp *Checkpoint) clone() *Checkpoint {
+	c := *cp
+	c.State = make(map[string]map[string]interface{}, len(cp.State))
+	for name, state := range cp.State {
+		copied := make(map[string]interface{}, len(state))
+		for k, v := range state {
+			copied[k] = v
+		}
+		c.State[name] = copied
+	}
+	c.InFlight = append([]InFlightPacket(nil), cp.InFlight...)
+	return &c
+}
+
+// MigrationStep returns a step that applies the structural part of the diff to
+// a checkpoint: state of removed components is dropped, in-flight packets on
+// removed connections are dropped, and packets on rewired connections are
+// moved to the new source so they still reach their input port. Custom
+// changes can be chained with additional registered steps.
+func (d *PipelineDiff) MigrationStep() MigrationStep {
+	return MigrationStep{
+		From:        d.FromVersion,
+		To:          d.ToVersion,
+		Description: fmt.Sprintf("structural migration of %s from %s to %s", d.Name, d.FromVersion, d.ToVersion),
+		Migrate: func(cp *Checkpoint) error {
+			for _, c := range d.Components {
+				if c.Kind == ChangeRemoved || (c.Kind == ChangeModified && c.OldType != c.NewType) {
+					delete(cp.State, c.Name)
+				}
+			}
+
+			kept := cp.InFlight[:0]
+			for _, packet := range cp.InFlight {
+				from := packet.FromComponent + "." + packet.FromPort
+				to := packet.ToComponent + "." + packet.ToPort
+				keep := true
+				for _, c := range d.Connections {
+					switch {
+					case c.Kind == ChangeRemoved && c.From == from && c.To == to:
+						keep = false
+					case c.Kind == ChangeRewired && c.OldFrom == from && c.To == to:
+						component, port, ok := splitPortRef(c.From)
+						if !ok {
+							return fmt.Errorf("invalid port reference %s", c.From)
+						}
+						packet.FromComponent, packet.FromPort = component, port
+					}
+				}
+				if keep {
+					kept = append(kept, packet)
+				}
+			}
+			cp.InFlight = kept
+			return nil
+		},
+	}
+}
+
+func splitPortRef(ref string) (string, string, bool) {
+	for i := len(ref) - 1; i > 0; i-- {
+		if ref[i] == '.' {
+			return ref[:i], ref[i+1:], i < len(ref)-1
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InvalidPacketPolicy decides what happens to a packet that fails validation.
+type InvalidPacketPolicy int
+
+const (
+	// InvalidPacketFail stops the run with the validation error.
+	InvalidPacketFail InvalidPacketPolicy = iota
+	// InvalidPacketSkip drops the packet and records the error.
+	InvalidPacketSkip
+	// InvalidPacketDeadLetter drops the packet and sends it to the dead-letter sink.
+	InvalidPacketDeadLetter
+)
+
+func (p InvalidPacketPolicy) String() string {
+	switch p {
+	case InvalidPacketFail:
+		return "FAIL"
+	case InvalidPacketSkip:
+		return "SKIP"
+	case InvalidPacketDeadLetter:
+		return "DEAD_LETTER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Packet directions reported in validation errors and dead letters.
+const (
+	DirectionInput  = "input"
+	DirectionOutput = "output"
+)
+
+// PacketValidationConfig enables runtime validation of packets against port
+// schemas and constraints.
+type PacketValidationConfig struct {
+	Policy     InvalidPacketPolicy
+	DeadLetter DeadLetterSink
+	// SkipInputs and SkipOutputs turn off validation on one side.
+	SkipInputs  bool
+	SkipOutputs bool
+}
+
+// DeadLetter is an invalid packet routed away from the pipeline.
+type DeadLetter struct {
+	Component string
+	Port      string
+	Direction string
+	Value     interface{}
+	Error     PipelineError
+	Time      time.Time
+}
+
+// DeadLetterSink receives invalid packets under the dead-letter policy.
+type DeadLetterSink interface {
+	Send(ctx context.Context, letter DeadLetter) error
+}
+
+// DeadLetterQueue is an in-memory DeadLetterSink.
+type DeadLetterQueue struct {
+	mu      sync.Mutex
+	letters []DeadLetter
+}
+
+// NewDeadLetterQueue creates an empty dead-letter queue.
+func NewDeadLetterQueue() *DeadLetterQueue {
+	return &DeadLetterQueue{}
+}
+
+// Send appends the letter to the queue.
+func (q *DeadLetterQueue) Send(ctx context.Context, letter DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters = append(q.letters, letter)
+	return nil
+}
+
+// Letters returns the queued letters.
+func (q *DeadLetterQueue) Letters() []DeadLetter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]DeadLetter(nil), q.letters...)
+}
+
+// ValidatePacket checks a value against a port's schema and constraints. It
+// returns nil for a valid packet and otherwise a ValidationError carrying the
+// component, port, direction and failed constraint in its context.
+func ValidatePacket(component string, port Port, direction string, value interface{}) *BasePipelineError {
+	if schema := port.Schema(); schema != nil {
+		if err := schema.Validate(value); err != nil {
+			return packetError(component, port, direction, failedSchemaConstraint(schema, value), err)
+		}
+	}
+	for _, constraint := range port.Constraints() {
+		if err := constraint.Validate(value); err != nil {
+			return packetError(component, port, direction, constraint.Description(), err)
+		}
+	}
+	return nil
+}
+
+// failedSchemaConstraint names the schema constraint that rejected the value,
+// falling back to "schema" for type mismatches and opaque schemas.
+func failedSchemaConstraint(schema Schema, value interface{}) string {
+	if s, ok := schema.(interface{ Constraints() []Constraint }); ok && value != nil {
+		for _, constraint := range s.Constraints() {
+			if constraint.Validate(value) != nil {
+				return constraint.Description()
+			}
+		}
+	}
+	return "schema"
+}
+
+func packetError(component string, port Port, direction, constraint string, err error) *BasePipelineError {
+	pipelineErr := NewPipelineError(
+		fmt.Sprintf("invalid %s packet on port %s: %v", direction, port.Name(), err),
+		component,
+		ValidationError,
+		Error,
+		true,
+	).WithOriginalError(err).
+		WithContext("port", port.Name()).
+		WithContext("direction", direction).
+		WithContext("constraint", constraint)
+
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		if constraint == "schema" {
+			pipelineErr.WithContext("constraint", ce.Constraint)
+		}
+		pipelineErr.WithContext("path", ce.Path)
+	}
+	return pipelineErr
+}
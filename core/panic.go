@@ -0,0 +1,33 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// NewPanicError converts a value recovered from a panicking component into a
+// Critical PipelineError. The panic value and the goroutine's stack trace are
+// available as the "panic" and "stack" context entries. Call it from the
+// deferred function that recovered the panic so the stack is still intact.
+func NewPanicError(component string, recovered interface{}) *BasePipelineError {
+	return NewPipelineError(
+		fmt.Sprintf("component panicked: %v", recovered),
+		component,
+		RuntimeError,
+		Critical,
+		false,
+	).WithContext("panic", fmt.Sprint(recovered)).
+		WithContext("stack", string(debug.Stack()))
+}
+
+// SetErrorHandler sets the handler engines consult when a component fails
+// or panics. Without a handler every failure aborts the run.
+func (p *Pipeline) SetErrorHandler(handler ErrorHandler) *Pipeline {
+	p.errorHandler = handler
+	return p
+}
+
+// ErrorHandler returns the pipeline's error handler, or nil.
+func (p *Pipeline) ErrorHandler() ErrorHandler {
+	return p.errorHandler
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -41,6 +42,25 @@ type Pipeline struct {
 	pipelineErrors  []PipelineError
 	errorCollector  *ErrorCollector
 	validator       *PipelineValidator
+
+	// Ports exposed when the pipeline is used as a component
+	exposed []portMapping
+
+	// Migrations between port schemas, DefaultSchemaMigrations if nil
+	schemaMigrations *SchemaMigrationRegistry
+
+	// Phases of Run to skip or observe
+	lifecycleHooks *LifecycleHooks
+
+	// Decides how engines react to component failures; nil aborts the run
+	errorHandler ErrorHandler
+
+	// Factories of components that Instance creates afresh for every run
+	factories map[string]func() Component
+
+	// Components whose lifecycle is managed elsewhere; Run does not
+	// initialize or clean them up
+	shared map[string]bool
 }
 
 // Connection represents a connection between two component ports with enhanced configuration.
@@ -55,6 +75,10 @@ type Connection struct {
 	Transform     DataTransform
 	BufferSize    int
 	Backpressure  *BackpressureConfig
+	// Migration converts packets between differing port schemas before the transform
+	Migration     SchemaMigrationPath
+	// Feedback marks a back edge into a feedback port of a LoopBoundary
+	Feedback      bool
 	
 	// Connection properties
 	Name         string
@@ -81,10 +105,14 @@ type PipelineConfig struct {
 	// Validation settings
 	StrictValidation bool
 	AllowCycles      bool
+	PacketValidation *PacketValidationConfig
 	
 	// Buffer configuration
 	DefaultBufferSize int
 	MaxBufferSize     int
+
+	// Stall detection, disabled if nil
+	Watchdog *WatchdogConfig
 }
 
 // PipelineContext holds runtime context and state information.
@@ -98,6 +126,24 @@ type PipelineContext struct {
 	// Context data
 	Variables      map[string]interface{}
 	Tags           map[string]string
+
+	// Guards Status and ComponentStates while engines run components concurrently
+	statesMu sync.RWMutex
+}
+
+// SetComponentState records the state of a component. It is safe to call
+// from concurrently running components.
+func (c *PipelineContext) SetComponentState(component string, state ComponentState) {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+	c.ComponentStates[component] = state
+}
+
+// GetComponentState returns the recorded state of a component.
+func (c *PipelineContext) GetComponentState(component string) ComponentState {
+	c.statesMu.RLock()
+	defer c.statesMu.RUnlock()
+	return c.ComponentStates[component]
 }
 
 // DataTransform defines a transformation function for connection data.
@@ -162,6 +208,8 @@ const (
 	ComponentStatePaused
 	ComponentStateError
 	ComponentStateCompleted
+	// ComponentStateSkipped marks components that did not run because their upstream produced nothing
+	ComponentStateSkipped
 )
 
 type BackpressureStrategy int
@@ -258,20 +306,31 @@ func NewPipelineMetrics() *PipelineMetrics {
 	}
 }
 
+// executionCounter keeps execution IDs unique for runs started in the same
+// nanosecond.
+var executionCounter uint64
+
 // generateExecutionID generates a unique execution ID.
 func generateExecutionID() string {
-	return fmt.Sprintf("exec_%d", time.Now().UnixNano())
+	return fmt.Sprintf("exec_%d_%d", time.Now().UnixNano(), atomic.AddUint64(&executionCounter, 1))
 }
 
-// AddComponent adds a component to the pipeline.
+// AddComponent adds a component to the pipeline. Adding a second component
+// with the same name is a construction error; use ReplaceComponent instead.
 func (p *Pipeline) AddComponent(name string, component Component) *Pipeline {
+	if _, ok := p.components[name]; ok {
+		p.errors = append(p.errors, fmt.Errorf("component '%s' already exists", name))
+		return p
+	}
 	component.SetName(name)
 	p.components[name] = component
 	return p
 }
 
 // Connect connects an output port of one component to an input port of another.
-// It uses generics to enforce type safety at compile time.
+// It uses generics to enforce type safety at compile time. T is the type of the
+// output port; the input port must have the same type, or the pipeline's schema
+// migrations must provide a path from the output schema to the input schema.
 func Connect[T any](p *Pipeline, fromComponent, fromPort, toComponent, toPort string) *Pipeline {
 	// Validate components exist
 	from, ok := p.components[fromComponent]
@@ -286,7 +345,8 @@ func Connect[T any](p *Pipeline, fromComponent, fromPort, toComponent, toPort st
 	}
 
 	// Validate ports exist and types match
-	if err := validatePortMatch[T](from, fromPort, to, toPort); err != nil {
+	migration, err := p.validatePortMatch(from, fromPort, to, toPort, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
 		p.errors = append(p.errors, err)
 		return p
 	}
@@ -297,8 +357,10 @@ func Connect[T any](p *Pipeline, fromComponent, fromPort, toComponent, toPort st
 		ToComponent:   toComponent,
 		ToPort:        toPort,
 		BufferSize:    p.config.DefaultBufferSize,
-		Name:          fmt.Sprintf("%s.%s -> %s.%s", fromComponent, fromPort, toComponent, toPort),
-		Description:   fmt.Sprintf("Connection from %s to %s", fromComponent, toComponent),
+		Migration:     migration,
+		Feedback:      isFeedbackPort(to, toPort),
+		Name:          connectionName(fromComponent, fromPort, toComponent, toPort),
+		Description:   connectionDescription(fromComponent, toComponent),
 		Metadata:      make(map[string]interface{}),
 	}
 	
@@ -306,6 +368,48 @@ func Connect[T any](p *Pipeline, fromComponent, fromPort, toComponent, toPort st
 	return p
 }
 
+// ConnectPorts connects an output port of one component to an input port of
+// another, checking at runtime that both ports carry the same type or that a
+// schema migration connects them. It is the counterpart of Connect for
+// pipelines built from configuration.
+func (p *Pipeline) ConnectPorts(fromComponent, fromPort, toComponent, toPort string) *Pipeline {
+	from, ok := p.components[fromComponent]
+	if !ok {
+		p.errors = append(p.errors, fmt.Errorf("source component '%s' not found", fromComponent))
+		return p
+	}
+	to, ok := p.components[toComponent]
+	if !ok {
+		p.errors = append(p.errors, fmt.Errorf("target component '%s' not found", toComponent))
+		return p
+	}
+
+	outPort := portByName(from.OutputPorts(), fromPort)
+	if outPort == nil {
+		p.errors = append(p.errors, fmt.Errorf("output port validation failed for %s: port '%s' not found", fromComponent, fromPort))
+		return p
+	}
+	migration, err := p.validatePortMatch(from, fromPort, to, toPort, outPort.Type())
+	if err != nil {
+		p.errors = append(p.errors, err)
+		return p
+	}
+
+	p.connections = append(p.connections, Connection{
+		FromComponent: fromComponent,
+		FromPort:      fromPort,
+		ToComponent:   toComponent,
+		ToPort:        toPort,
+		BufferSize:    p.config.DefaultBufferSize,
+		Migration:     migration,
+		Feedback:      isFeedbackPort(to, toPort),
+		Name:          connectionName(fromComponent, fromPort, toComponent, toPort),
+		Description:   connectionDescription(fromComponent, toComponent),
+		Metadata:      make(map[string]interface{}),
+	})
+	return p
+}
+
 // Errors returns any errors that occurred during pipeline construction.
 func (p *Pipeline) Errors() []error {
 	return p.errors
@@ -317,15 +421,34 @@ func (p *Pipeline) SetEngine(engine ExecutionEngine) *Pipeline {
 	return p
 }
 
-// Run executes the pipeline using the configured execution engine.
+// GetEngine returns the execution engine configured for the pipeline, if any.
+func (p *Pipeline) GetEngine() ExecutionEngine {
+	return p.engine
+}
+
+// Run executes the pipeline using the configured execution engine. Components
+// are validated, initialized in topological order and health-checked before
+// execution, and cleaned up in reverse order afterwards, even if a phase
+// fails or panics. Failures are returned as PipelineErrors whose "phase"
+// context names the phase. See SetLifecycleHooks to skip phases.
+//
+// Run uses the pipeline's own components and context, so a pipeline runs once
+// at a time. Use RunInstance or Instance to run a definition concurrently.
 func (p *Pipeline) Run(ctx context.Context) error {
+	return p.RunWithChannels(ctx, nil, nil)
+}
+
+// RunWithChannels runs the pipeline like Run, passing the channels of its
+// exposed ports to the engine: inputs feed exposed input ports and outputs
+// receive the packets of exposed output ports.
+func (p *Pipeline) RunWithChannels(ctx context.Context, inputs map[string]chan interface{}, outputs map[string]chan interface{}) error {
 	if len(p.errors) > 0 {
 		return fmt.Errorf("pipeline has %d construction errors", len(p.errors))
 	}
 	if p.engine == nil {
 		return fmt.Errorf("execution engine is not set")
 	}
-	return p.engine.Run(ctx, p, nil, nil)
+	return p.runLifecycle(ctx, inputs, outputs)
 }
 
 // GetComponents returns the components in the pipeline.
@@ -338,6 +461,33 @@ func (p *Pipeline) GetConnections() []Connection {
 	return p.connections
 }
 
+// Decorate returns a copy of the pipeline in which every component is replaced by
+// the result of wrap. Connections are copied; configuration, metadata and context
+// are shared with the original. Components are not renamed.
+func (p *Pipeline) Decorate(wrap func(name string, component Component) Component) *Pipeline {
+	decorated := *p
+	decorated.components = make(map[string]Component, len(p.components))
+	for name, component := range p.components {
+		decorated.components[name] = wrap(name, component)
+	}
+	decorated.connections = append([]Connection(nil), p.connections...)
+	return &decorated
+}
+
+// DecorateConnections returns a copy of the pipeline in which the transform of
+// every connection is replaced by the result of wrap, which receives the
+// connection with its current transform. Components, configuration, metadata
+// and context are shared with the original.
+func (p *Pipeline) DecorateConnections(wrap func(conn Connection) DataTransform) *Pipeline {
+	decorated := *p
+	decorated.connections = make([]Connection, len(p.connections))
+	for i, conn := range p.connections {
+		conn.Transform = wrap(conn)
+		decorated.connections[i] = conn
+	}
+	return &decorated
+}
+
 // Name returns the name of the pipeline.
 func (p *Pipeline) Name() string {
 	return p.name
@@ -348,109 +498,6 @@ func (p *Pipeline) SetName(name string) {
 	p.name = name
 }
 
-// InputPorts returns the input ports of the pipeline.
-func (p *Pipeline) InputPorts() []Port {
-	// For a pipeline to be a component, we need to define its public-facing ports.
-	// This is a simplified implementation where we expose all unconnected input ports.
-	var ports []Port
-	for _, component := range p.components {
-		for _, port := range component.InputPorts() {
-			isConnected := false
-			for _, conn := range p.connections {
-				if conn.ToComponent == component.Name() && conn.ToPort == port.Name() {
-					isConnected = true
-					break
-				}
-			}
-			if !isConnected {
-				ports = append(ports, port)
-			}
-		}
-	}
-	return ports
-}
-
-// OutputPorts returns the output ports of the pipeline.
-func (p *Pipeline) OutputPorts() []Port {
-	// Similar to InputPorts, we expose all unconnected output ports.
-	var ports []Port
-	for _, component := range p.components {
-		for _, port := range component.OutputPorts() {
-			isConnected := false
-			for _, conn := range p.connections {
-				if conn.FromComponent == component.Name() && conn.FromPort == port.Name() {
-					isConnected = true
-					break
-				}
-			}
-			if !isConnected {
-				ports = append(ports, port)
-			}
-		}
-	}
-	return ports
-}
-
-// Process runs the pipeline as a component.
-func (p *Pipeline) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
-	var wg sync.WaitGroup
-	// Create a new engine for the sub-pipeline
-	if defaultEngineCreator == nil {
-		return nil, fmt.Errorf("no default engine creator is registered")
-	}
-	engine := defaultEngineCreator()
-
-	// Create channels for the pipeline's inputs and outputs
-	inputChans := make(map[string]chan interface{})
-	for _, port := range p.InputPorts() {
-		inputChans[port.Name()] = make(chan interface{}, 1)
-	}
-	outputChans := make(map[string]chan interface{})
-	for _, port := range p.OutputPorts() {
-		outputChans[port.Name()] = make(chan interface{}, 1)
-	}
-
-	wg.Add(1)
-	// Start the pipeline in a goroutine
-	go func() {
-		defer wg.Done()
-		if err := engine.Run(ctx, p, inputChans, outputChans); err != nil {
-			// In a real implementation, we would propagate this error.
-			fmt.Printf("error running sub-pipeline: %v\n", err)
-		}
-		engine.Close()
-	}()
-
-	// Write the external inputs to the pipeline's input channels
-	for name, data := range inputs {
-		if ch, ok := inputChans[name]; ok {
-			ch <- data
-		}
-	}
-
-	// Close the output channels
-	for _, ch := range outputChans {
-		close(ch)
-	}
-
-	wg.Wait()
-
-	// Read from the output channels and return the data
-	outputs := make(map[string]interface{})
-	var outputWg sync.WaitGroup
-	for name, ch := range outputChans {
-		outputWg.Add(1)
-		go func(name string, ch chan interface{}) {
-			defer outputWg.Done()
-			for data := range ch {
-				outputs[name] = data
-			}
-		}(name, ch)
-	}
-	outputWg.Wait()
-	return outputs, nil
-}
-
 // Validate validates the pipeline.
 func (p *Pipeline) Validate() error {
 	if len(p.errors) > 0 {
@@ -462,6 +509,9 @@ func (p *Pipeline) Validate() error {
 	if err := p.detectCycles(); err != nil {
 		return err
 	}
+	if err := p.validateLoops(); err != nil {
+		return err
+	}
 
 	// Validate all components
 	for _, component := range p.components {
@@ -475,19 +525,19 @@ func (p *Pipeline) Validate() error {
 
 // HealthCheck performs health checks on all components in the pipeline
 func (p *Pipeline) HealthCheck(ctx context.Context) error {
-	for _, component := range p.components {
-		if err := component.HealthCheck(ctx); err != nil {
-			return fmt.Errorf("component %s health check failed: %w", component.Name(), err)
+	for _, name := range p.lifecycleOrder() {
+		if err := p.components[name].HealthCheck(ctx); err != nil {
+			return fmt.Errorf("component %s health check failed: %w", name, err)
 		}
 	}
 	return nil
 }
 
-// Initialize initializes all components in the pipeline
+// Initialize initializes all components in the pipeline in topological order
 func (p *Pipeline) Initialize(ctx context.Context) error {
-	for _, component := range p.components {
-		if err := component.Initialize(ctx); err != nil {
-			return fmt.Errorf("component %s initialization failed: %w", component.Name(), err)
+	for _, name := range p.lifecycleOrder() {
+		if err := p.components[name].Initialize(ctx); err != nil {
+			return fmt.Errorf("component %s initialization failed: %w", name, err)
 		}
 	}
 	return nil
@@ -497,13 +547,8 @@ func (p *Pipeline) Initialize(ctx context.Context) error {
 func (p *Pipeline) Cleanup(ctx context.Context) error {
 	var errors []error
 	
-	// Clean up components in reverse order
-	componentNames := make([]string, 0, len(p.components))
-	for name := range p.components {
-		componentNames = append(componentNames, name)
-	}
-	
-	// Cleanup in reverse order
+	// Clean up components in reverse topological order
+	componentNames := p.lifecycleOrder()
 	for i := len(componentNames) - 1; i >= 0; i-- {
 		component := p.components[componentNames[i]]
 		if err := component.Cleanup(ctx); err != nil {
@@ -532,7 +577,7 @@ func (p *Pipeline) Description() string {
 
 // Version returns the version of the pipeline
 func (p *Pipeline) Version() string {
-	return "1.0.0"
+	return p.version
 }
 
 // Tags returns tags associated with the pipeline
@@ -540,10 +585,15 @@ func (p *Pipeline) Tags() []string {
 	return []string{"pipeline", "composite"}
 }
 
-// detectCycles checks for cycles in the pipeline graph.
+// detectCycles checks for cycles in the pipeline graph. Feedback
+// connections are ignored, so only cycles that do not pass through a loop
+// boundary are reported.
 func (p *Pipeline) detectCycles() error {
 	graph := make(map[string][]string)
 	for _, conn := range p.GetConnections() {
+		if conn.Feedback {
+			continue
+		}
 		graph[conn.FromComponent] = append(graph[conn.FromComponent], conn.ToComponent)
 	}
 
@@ -553,7 +603,7 @@ func (p *Pipeline) detectCycles() error {
 	for component := range p.GetComponents() {
 		if !visited[component] {
 			if p.hasCycle(component, visited, recursionStack, graph) {
-				return fmt.Errorf("cycle detected in pipeline graph involving component %s", component)
+				return fmt.Errorf("cycle detected in pipeline graph involving component %s; cycles must pass through the feedback port of a loop boundary", component)
 			}
 		}
 	}
@@ -580,26 +630,25 @@ func (p *Pipeline) hasCycle(component string, visited, recursionStack map[string
 }
 
 // validatePortMatch checks if the ports of two components can be connected.
-func validatePortMatch[T any](from Component, fromPort string, to Component, toPort string) error {
-	var zero T
-	expectedType := reflect.TypeOf(zero)
-
+// The output port must have the expected type. It returns the schema
+// migration needed when the input port carries a different schema.
+func (p *Pipeline) validatePortMatch(from Component, fromPort string, to Component, toPort string, expectedType reflect.Type) (SchemaMigrationPath, error) {
 	outPort, err := findPort(from.OutputPorts(), fromPort, expectedType)
 	if err != nil {
-		return fmt.Errorf("output port validation failed for %s: %w", from.Name(), err)
+		return nil, fmt.Errorf("output port validation failed for %s: %w", from.Name(), err)
 	}
 
-	inPort, err := findPort(to.InputPorts(), toPort, expectedType)
-	if err != nil {
-		return fmt.Errorf("input port validation failed for %s: %w", to.Name(), err)
+	inPort := portByName(to.InputPorts(), toPort)
+	if inPort == nil {
+		return nil, fmt.Errorf("input port validation failed for %s: port '%s' not found", to.Name(), toPort)
 	}
 
-	if outPort.Type() != inPort.Type() {
-		return fmt.Errorf("type mismatch: cannot connect %s (%s) to %s (%s)",
-			outPort.Type(), fromPort, inPort.Type(), toPort)
+	migration, err := p.migrationBetween(outPort, inPort)
+	if err != nil {
+		return nil, fmt.Errorf("type mismatch: cannot connect %s (%s) to %s (%s): %w",
+			outPort.Type(), fromPort, inPort.Type(), toPort, err)
 	}
-
-	return nil
+	return migration, nil
 }
 
 func findPort(ports []Port, name string, expectedType reflect.Type) (Port, error) {
@@ -706,8 +755,9 @@ func (p *Pipeline) ConnectWithTransform(fromComponent, fromPort, toComponent, to
 			ToComponent:   toComponent,
 			ToPort:        toPort,
 			BufferSize:    p.config.DefaultBufferSize,
-			Name:          fmt.Sprintf("%s.%s -> %s.%s", fromComponent, fromPort, toComponent, toPort),
-			Description:   fmt.Sprintf("Connection from %s to %s with transform", fromComponent, toComponent),
+			Name:          connectionName(fromComponent, fromPort, toComponent, toPort),
+			Description:   connectionDescription(fromComponent, toComponent) + " with transform",
+			Feedback:      p.components[toComponent] != nil && isFeedbackPort(p.components[toComponent], toPort),
 			Metadata:      make(map[string]interface{}),
 		}
 		p.connections = append(p.connections, newConnection)
@@ -739,8 +789,9 @@ func (p *Pipeline) ConnectWithBackpressure(fromComponent, fromPort, toComponent,
 			ToComponent:   toComponent,
 			ToPort:        toPort,
 			BufferSize:    p.config.DefaultBufferSize,
-			Name:          fmt.Sprintf("%s.%s -> %s.%s", fromComponent, fromPort, toComponent, toPort),
-			Description:   fmt.Sprintf("Connection from %s to %s with backpressure", fromComponent, toComponent),
+			Name:          connectionName(fromComponent, fromPort, toComponent, toPort),
+			Description:   connectionDescription(fromComponent, toComponent) + " with backpressure",
+			Feedback:      p.components[toComponent] != nil && isFeedbackPort(p.components[toComponent], toPort),
 			Metadata:      make(map[string]interface{}),
 		}
 		p.connections = append(p.connections, newConnection)
@@ -751,6 +802,27 @@ func (p *Pipeline) ConnectWithBackpressure(fromComponent, fromPort, toComponent,
 	return p
 }
 
+// Capacity returns the number of packets the channel backing the connection
+// holds: the backpressure buffer of a BackpressureBuffer strategy, otherwise
+// BufferSize, limited to the MaxBufferSize of config and at least 1.
+func (c Connection) Capacity(config *PipelineConfig) int {
+	capacity := c.requestedBufferSize()
+	if config != nil && config.MaxBufferSize > 0 && capacity > config.MaxBufferSize {
+		capacity = config.MaxBufferSize
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	return capacity
+}
+
+func (c Connection) requestedBufferSize() int {
+	if bp := c.Backpressure; bp != nil && bp.Strategy == BackpressureBuffer && bp.BufferSize > 0 {
+		return bp.BufferSize
+	}
+	return c.BufferSize
+}
+
 // SetConnectionBufferSize sets the buffer size for a specific connection
 func (p *Pipeline) SetConnectionBufferSize(fromComponent, fromPort, toComponent, toPort string, bufferSize int) *Pipeline {
 	for i := range p.connections {
@@ -794,6 +866,8 @@ func (cs ComponentState) String() string {
 		return "ERROR"
 	case ComponentStateCompleted:
 		return "COMPLETED"
+	case ComponentStateSkipped:
+		return "SKIPPED"
 	default:
 		return "UNKNOWN"
 	}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExecutionPlan describes how a pipeline will be scheduled without running any component.
+type ExecutionPlan struct {
+	Pipeline       string           `json:"pipeline"`
+	Version        string           `json:"version"`
+	MaxConcurrency int              `json:"max_concurrency"`
+	Timeout        string           `json:"timeout"`
+	Stages         []PlanStage      `json:"stages"`
+	Components     []PlanComponent  `json:"components"`
+	Connections    []PlanConnection `json:"connections"`
+	CriticalPath   []string         `json:"critical_path"`
+	RetryPolicy    *PlanRetryPolicy `json:"retry_policy,omitempty"`
+	Warnings       []string         `json:"warnings,omitempty"`
+}
+
+// PlanStage groups components that have no dependencies on each other and may run in parallel.
+type PlanStage struct {
+	Index       int      `json:"index"`
+	Components  []string `json:"components"`
+	Parallelism int      `json:"parallelism"`
+}
+
+// PlanComponent describes a single component in the execution plan.
+type PlanComponent struct {
+	Name         string     `json:"name"`
+	Version      string     `json:"version"`
+	Stage        int        `json:"stage"`
+	Inputs       []PlanPort `json:"inputs,omitempty"`
+	Outputs      []PlanPort `json:"outputs,omitempty"`
+	Dependencies []string   `json:"dependencies,omitempty"`
+	Dependents   []string   `json:"dependents,omitempty"`
+}
+
+// PlanPort describes a component port in the execution plan.
+type PlanPort struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// PlanConnection describes the channel that will back a connection.
+type PlanConnection struct {
+	Name         string `json:"name"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	BufferSize   int    `json:"buffer_size"`
+	Transform    string `json:"transform,omitempty"`
+	Backpressure string `json:"backpressure,omitempty"`
+	Feedback     bool   `json:"feedback,omitempty"`
+}
+
+// PlanRetryPolicy describes the retry policy in effect.
+type PlanRetryPolicy struct {
+	MaxRetries      int      `json:"max_retries"`
+	InitialDelay    string   `json:"initial_delay"`
+	MaxDelay        string   `json:"max_delay"`
+	BackoffFactor   float64  `json:"backoff_factor"`
+	RetryableErrors []string `json:"retryable_errors,omitempty"`
+}
+
+// Plan builds the execution plan for the pipeline without calling Process on any component.
+func (p *Pipeline) Plan() (*ExecutionPlan, error) {
+	if len(p.errors) > 0 {
+		return nil, fmt.Errorf("pipeline has %d construction errors", len(p.errors))
+	}
+
+	graph, err := p.GetComponentGraph()
+	if err != nil {
+		return nil, err
+	}
+	if graph.TopologyOrder == nil {
+		return nil, fmt.Errorf("cannot plan pipeline %s: cycle detected in component graph", p.name)
+	}
+
+	config := p.config
+	if config == nil {
+		config = NewDefaultPipelineConfig()
+	}
+
+	plan := &ExecutionPlan{
+		Pipeline:       p.name,
+		Version:        p.version,
+		MaxConcurrency: config.MaxConcurrency,
+		Timeout:        config.Timeout.String(),
+		Stages:         make([]PlanStage, 0),
+		Components:     make([]PlanComponent, 0, len(graph.Nodes)),
+		Connections:    make([]PlanConnection, 0, len(p.connections)),
+		CriticalPath:   graph.CriticalPath,
+	}
+
+	// A component's stage is the length of the longest dependency chain leading to it
+	stages := make(map[string]int)
+	for _, name := range graph.TopologyOrder {
+		stage := 0
+		for _, dep := range graph.Nodes[name].Dependencies {
+			if s, ok := stages[dep]; ok && s+1 > stage {
+				stage = s + 1
+			}
+		}
+		stages[name] = stage
+	}
+
+	for name, stage := range stages {
+		for len(plan.Stages) <= stage {
+			plan.Stages = append(plan.Stages, PlanStage{Index: len(plan.Stages)})
+		}
+		plan.Stages[stage].Components = append(plan.Stages[stage].Components, name)
+	}
+	for i := range plan.Stages {
+		stage := &plan.Stages[i]
+		sort.Strings(stage.Components)
+		stage.Parallelism = len(stage.Components)
+		if config.MaxConcurrency > 0 && stage.Parallelism > config.MaxConcurrency {
+			stage.Parallelism = config.MaxConcurrency
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"stage %d has %d components but MaxConcurrency is %d", stage.Index, len(stage.Components), config.MaxConcurrency))
+		}
+	}
+
+	names := make([]string, 0, len(graph.Nodes))
+	for name := range graph.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		node := graph.Nodes[name]
+		plan.Components = append(plan.Components, PlanComponent{
+			Name:         name,
+			Version:      node.Component.Version(),
+			Stage:        stages[name],
+			Inputs:       planPorts(node.Component.InputPorts()),
+			Outputs:      planPorts(node.Component.OutputPorts()),
+			Dependencies: uniqueSorted(node.Dependencies),
+			Dependents:   uniqueSorted(node.Dependents),
+		})
+	}
+
+	for _, conn := range p.connections {
+		pc := PlanConnection{
+			Name:       conn.Name,
+			From:       fmt.Sprintf("%s.%s", conn.FromComponent, conn.FromPort),
+			To:         fmt.Sprintf("%s.%s", conn.ToComponent, conn.ToPort),
+			BufferSize: conn.Capacity(config),
+			Feedback:   conn.Feedback,
+		}
+		if conn.Transform != nil {
+			pc.Transform = conn.Transform.Name()
+		}
+		if bp := conn.Backpressure; bp != nil {
+			pc.Backpressure = bp.Strategy.String()
+			if bp.Strategy == BackpressureDrop {
+				pc.Backpressure += "/" + bp.DropPolicy.String()
+			}
+		}
+		if requested := conn.requestedBufferSize(); config.MaxBufferSize > 0 && requested > config.MaxBufferSize {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"connection %s buffer size %d exceeds MaxBufferSize %d and is capped", conn.Name, requested, config.MaxBufferSize))
+		}
+		plan.Connections = append(plan.Connections, pc)
+	}
+
+	if rp := config.RetryPolicy; rp != nil {
+		retryable := make([]string, len(rp.RetryableErrors))
+		for i, et := range rp.RetryableErrors {
+			retryable[i] = et.String()
+		}
+		plan.RetryPolicy = &PlanRetryPolicy{
+			MaxRetries:      rp.MaxRetries,
+			InitialDelay:    rp.InitialDelay.String(),
+			MaxDelay:        rp.MaxDelay.String(),
+			BackoffFactor:   rp.BackoffFactor,
+			RetryableErrors: retryable,
+		}
+	}
+	return plan, nil
+}
+
+// JSON renders the plan as indented JSON.
+func (pl *ExecutionPlan) JSON() ([]byte, error) {
+	return json.MarshalIndent(pl, "", "  ")
+}
+
+// String renders the plan as human-readable text.
+func (pl *ExecutionPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Execution plan for pipeline %q (version %s)\n", pl.Pipeline, pl.Version)
+	fmt.Fprintf(&b, "  max concurrency: %d, timeout: %s\n", pl.MaxConcurrency, pl.Timeout)
+	if rp := pl.RetryPolicy; rp != nil {
+		fmt.Fprintf(&b, "  retry: max %d, delay %s..%s, backoff x%g, retryable %s\n",
+			rp.MaxRetries, rp.InitialDelay, rp.MaxDelay, rp.BackoffFactor, strings.Join(rp.RetryableErrors, ","))
+	} else {
+		b.WriteString("  retry: disabled\n")
+	}
+
+	b.WriteString("\nStages:\n")
+	for _, stage := range pl.Stages {
+		fmt.Fprintf(&b, "  [%d] parallelism %d: %s\n", stage.Index, stage.Parallelism, strings.Join(stage.Components, ", "))
+	}
+
+	b.WriteString("\nConnections:\n")
+	for _, conn := range pl.Connections {
+		fmt.Fprintf(&b, "  %s -> %s (buffer %d", conn.From, conn.To, conn.BufferSize)
+		if conn.Transform != "" {
+			fmt.Fprintf(&b, ", transform %s", conn.Transform)
+		}
+		if conn.Backpressure != "" {
+			fmt.Fprintf(&b, ", backpressure %s", conn.Backpressure)
+		}
+		b.WriteString(")\n")
+	}
+
+	fmt.Fprintf(&b, "\nCritical path: %s\n", strings.Join(pl.CriticalPath, " -> "))
+
+	if len(pl.Warnings) > 0 {
+		b.WriteString("\nWarnings:\n")
+		for _, w := range pl.Warnings {
+			fmt.Fprintf(&b, "  - %s\n", w)
+		}
+	}
+	return b.String()
+}
+
+func planPorts(ports []Port) []PlanPort {
+	result := make([]PlanPort, 0, len(ports))
+	for _, port := range ports {
+		typeName := "<nil>"
+		if port.Type() != nil {
+			typeName = port.Type().String()
+		}
+		result = append(result, PlanPort{Name: port.Name(), Type: typeName, Required: port.Required()})
+	}
+	return result
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
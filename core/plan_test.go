@@ -0,0 +1,121 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPipelinePlan(t *testing.T) {
+	pipeline := NewPipeline("plan_test")
+
+	pipeline.AddComponent("comp1", NewTestValidationComponent("comp1"))
+	pipeline.AddComponent("comp2", NewTestValidationComponent("comp2"))
+	pipeline.AddComponent("comp3", NewTestValidationComponent("comp3"))
+	pipeline.AddComponent("comp4", NewTestValidationComponent("comp4"))
+
+	// Diamond: comp1 -> comp2, comp1 -> comp3, comp2 -> comp4, comp3 -> comp4
+	Connect[string](pipeline, "comp1", "output", "comp2", "input")
+	Connect[string](pipeline, "comp1", "output", "comp3", "input")
+	Connect[string](pipeline, "comp2", "output", "comp4", "input")
+	pipeline.ConnectWithTransform("comp3", "output", "comp4", "input", NewStringToUpperTransform())
+
+	plan, err := pipeline.Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	if len(plan.Stages) != 3 {
+		t.Fatalf("Expected 3 stages, got %d", len(plan.Stages))
+	}
+	if got := strings.Join(plan.Stages[1].Components, ","); got != "comp2,comp3" {
+		t.Errorf("Expected comp2 and comp3 to run in parallel in stage 1, got %s", got)
+	}
+	if plan.Stages[1].Parallelism != 2 {
+		t.Errorf("Expected parallelism 2 for stage 1, got %d", plan.Stages[1].Parallelism)
+	}
+
+	if len(plan.Connections) != 4 {
+		t.Errorf("Expected 4 connections, got %d", len(plan.Connections))
+	}
+	if plan.Connections[3].Transform != "string_to_upper" {
+		t.Errorf("Expected transform on last connection, got '%s'", plan.Connections[3].Transform)
+	}
+	if plan.RetryPolicy == nil || plan.RetryPolicy.MaxRetries != 3 {
+		t.Error("Expected default retry policy in plan")
+	}
+	if len(plan.CriticalPath) != 3 {
+		t.Errorf("Expected critical path of length 3, got %v", plan.CriticalPath)
+	}
+
+	text := plan.String()
+	if !strings.Contains(text, "[1] parallelism 2: comp2, comp3") {
+		t.Errorf("Expected text plan to list parallel stage, got:\n%s", text)
+	}
+
+	data, err := plan.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded ExecutionPlan
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode plan JSON: %v", err)
+	}
+	if decoded.Pipeline != "plan_test" || len(decoded.Stages) != 3 {
+		t.Errorf("Decoded plan does not match: %+v", decoded)
+	}
+}
+
+func TestPipelinePlanMaxConcurrency(t *testing.T) {
+	pipeline := NewPipeline("plan_concurrency_test")
+	pipeline.GetConfig().MaxConcurrency = 2
+	for _, name := range []string{"a", "b", "c"} {
+		pipeline.AddComponent(name, NewTestValidationComponent(name))
+	}
+
+	plan, err := pipeline.Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if plan.Stages[0].Parallelism != 2 {
+		t.Errorf("Expected parallelism capped at 2, got %d", plan.Stages[0].Parallelism)
+	}
+	if len(plan.Warnings) == 0 {
+		t.Error("Expected a warning when a stage exceeds MaxConcurrency")
+	}
+}
+
+func TestPipelinePlanCycle(t *testing.T) {
+	pipeline := NewPipeline("plan_cycle_test")
+	pipeline.AddComponent("comp1", NewTestValidationComponent("comp1"))
+	pipeline.AddComponent("comp2", NewTestValidationComponent("comp2"))
+	Connect[string](pipeline, "comp1", "output", "comp2", "input")
+	Connect[string](pipeline, "comp2", "output", "comp1", "input")
+
+	if _, err := pipeline.Plan(); err == nil {
+		t.Error("Expected Plan to fail for a cyclic pipeline")
+	}
+}
+
+func TestPipelinePlanBufferCapacity(t *testing.T) {
+	pipeline := NewPipeline("plan_buffer_test")
+	pipeline.GetConfig().MaxBufferSize = 10
+	pipeline.AddComponent("comp1", NewTestValidationComponent("comp1"))
+	pipeline.AddComponent("comp2", NewTestValidationComponent("comp2"))
+	Connect[string](pipeline, "comp1", "output", "comp2", "input")
+	pipeline.SetConnectionBufferSize("comp1", "output", "comp2", "input", 50)
+
+	plan, err := pipeline.Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if got := plan.Connections[0].BufferSize; got != 10 {
+		t.Errorf("Expected the channel capacity capped at 10, got %d", got)
+	}
+	if got := pipeline.GetConnections()[0].Capacity(pipeline.GetConfig()); got != 10 {
+		t.Errorf("Expected the engines to use capacity 10, got %d", got)
+	}
+	if len(plan.Warnings) == 0 {
+		t.Error("Expected a warning for the capped buffer")
+	}
+}
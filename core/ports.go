@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// OutPort is a typed handle on a component's output port. Handles returned
+// by AddSource, AddFunc1 and AddFunc2 always name an existing port of type
+// T, so linking them cannot fail on a typo or a type mismatch.
+type OutPort[T any] struct {
+	pipeline  *Pipeline
+	component string
+	port      string
+}
+
+// InPort is a typed handle on a component's input port.
+type InPort[T any] struct {
+	pipeline  *Pipeline
+	component string
+	port      string
+}
+
+// Component returns the name of the component owning the port.
+func (o OutPort[T]) Component() string { return o.component }
+
+// Port returns the port name.
+func (o OutPort[T]) Port() string { return o.port }
+
+// String returns "component.port".
+func (o OutPort[T]) String() string { return o.component + "." + o.port }
+
+// Component returns the name of the component owning the port.
+func (i InPort[T]) Component() string { return i.component }
+
+// Port returns the port name.
+func (i InPort[T]) Port() string { return i.port }
+
+// String returns "component.port".
+func (i InPort[T]) String() string { return i.component + "." + i.port }
+
+// Link connects an output port to an input port of the same type. The
+// compiler rejects handles of different types; use Connect or ConnectPorts
+// for connections that rely on schema migrations.
+func Link[T any](out OutPort[T], in InPort[T]) *Pipeline {
+	p := out.pipeline
+	if p == nil {
+		p = in.pipeline
+	}
+	if p == nil {
+		return nil
+	}
+	if out.pipeline == nil || in.pipeline == nil {
+		p.errors = append(p.errors, fmt.Errorf("cannot link %s to %s: uninitialized port handle", out, in))
+		return p
+	}
+	if out.pipeline != in.pipeline {
+		p.errors = append(p.errors, fmt.Errorf("cannot link %s to %s: ports belong to different pipelines", out, in))
+		return p
+	}
+	return Connect[T](p, out.component, out.port, in.component, in.port)
+}
+
+// AddSource adds a Source component and returns its output port.
+func AddSource[Out any](p *Pipeline, name string, fn func(ctx context.Context) (Out, error)) OutPort[Out] {
+	p.AddComponent(name, Source(name, fn))
+	return OutPort[Out]{pipeline: p, component: name, port: "output"}
+}
+
+// AddSink adds a Sink component and returns its input port.
+func AddSink[In any](p *Pipeline, name string, fn func(ctx context.Context, in In) error) InPort[In] {
+	p.AddComponent(name, Sink(name, fn))
+	return InPort[In]{pipeline: p, component: name, port: "input"}
+}
+
+// AddFunc1 adds a Func1 component and returns its ports:
+//
+//	text := core.AddSource(p, "source", readText)
+//	in, out := core.AddFunc1(p, "upper", toUpper)
+//	core.Link(text, in)
+func AddFunc1[In, Out any](p *Pipeline, name string, fn func(ctx context.Context, in In) (Out, error)) (InPort[In], OutPort[Out]) {
+	p.AddComponent(name, Func1(name, fn))
+	return InPort[In]{pipeline: p, component: name, port: "input"},
+		OutPort[Out]{pipeline: p, component: name, port: "output"}
+}
+
+// AddFunc2 adds a Func2 component and returns its ports.
+func AddFunc2[In1, In2, Out any](p *Pipeline, name string, fn func(ctx context.Context, in1 In1, in2 In2) (Out, error)) (InPort[In1], InPort[In2], OutPort[Out]) {
+	p.AddComponent(name, Func2(name, fn))
+	return InPort[In1]{pipeline: p, component: name, port: "input1"},
+		InPort[In2]{pipeline: p, component: name, port: "input2"},
+		OutPort[Out]{pipeline: p, component: name, port: "output"}
+}
+
+// OutputOf returns a typed handle on an output port of a component that is
+// already in the pipeline, such as a hand-written or spec-loaded component.
+// The port is checked once, here; a missing port or a different type is
+// recorded as a construction error and the handle links to nothing.
+func OutputOf[T any](p *Pipeline, component, port string) OutPort[T] {
+	if err := p.checkHandle(component, port, reflect.TypeOf((*T)(nil)).Elem(), false); err != nil {
+		p.errors = append(p.errors, err)
+		return OutPort[T]{component: component, port: port}
+	}
+	return OutPort[T]{pipeline: p, component: component, port: port}
+}
+
+// InputOf returns a typed handle on an input port of a component that is
+// already in the pipeline. See OutputOf.
+func InputOf[T any](p *Pipeline, component, port string) InPort[T] {
+	if err := p.checkHandle(component, port, reflect.TypeOf((*T)(nil)).Elem(), true); err != nil {
+		p.errors = append(p.errors, err)
+		return InPort[T]{component: component, port: port}
+	}
+	return InPort[T]{pipeline: p, component: component, port: port}
+}
+
+func (p *Pipeline) checkHandle(component, port string, t reflect.Type, input bool) error {
+	c, ok := p.components[component]
+	if !ok {
+		return fmt.Errorf("component '%s' not found", component)
+	}
+	ports, kind := c.OutputPorts(), "output"
+	if input {
+		ports, kind = c.InputPorts(), "input"
+	}
+	if _, err := findPort(ports, port, t); err != nil {
+		return fmt.Errorf("%s port of %s: %w", kind, component, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLinkTypedPorts(t *testing.T) {
+	p := NewPipeline("handles")
+	text := AddSource(p, "text", func(ctx context.Context) (string, error) { return "go", nil })
+	count := AddSource(p, "count", func(ctx context.Context) (int, error) { return 2, nil })
+	s, n, repeated := AddFunc2(p, "repeat", func(ctx context.Context, s string, n int) (string, error) {
+		return strings.Repeat(s, n), nil
+	})
+	in, out := AddFunc1(p, "upper", func(ctx context.Context, s string) (string, error) {
+		return strings.ToUpper(s), nil
+	})
+	sink := AddSink(p, "sink", func(ctx context.Context, s string) error { return nil })
+
+	Link(text, s)
+	Link(count, n)
+	Link(repeated, in)
+	Link(out, sink)
+
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("Unexpected construction errors: %v", errs)
+	}
+	conns := p.GetConnections()
+	if len(conns) != 4 {
+		t.Fatalf("Expected 4 connections, got %d", len(conns))
+	}
+	if conns[2].FromComponent != "repeat" || conns[2].ToComponent != "upper" || conns[2].ToPort != "input" {
+		t.Errorf("Unexpected connection %+v", conns[2])
+	}
+	if out.String() != "upper.output" || in.Component() != "upper" || in.Port() != "input" {
+		t.Errorf("Unexpected handle names %s, %s", out, in)
+	}
+	if result := p.ValidateComprehensive(); !result.Valid {
+		t.Errorf("Expected linked pipeline to validate, got %v", result.Errors)
+	}
+}
+
+func TestPortHandlesForExistingComponents(t *testing.T) {
+	p := NewPipeline("lookup")
+	p.AddComponent("source", Func1("source", func(ctx context.Context, n int) (string, error) { return "", nil }))
+	sink := AddSink(p, "sink", func(ctx context.Context, s string) error { return nil })
+
+	Link(OutputOf[string](p, "source", "output"), sink)
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("Unexpected construction errors: %v", errs)
+	}
+
+	Link(OutputOf[int](p, "source", "output"), InputOf[int](p, "source", "input"))
+	OutputOf[string](p, "source", "outptu")
+	if errs := p.Errors(); len(errs) != 3 {
+		t.Errorf("Expected type, link and name errors, got %v", errs)
+	}
+
+	other := NewPipeline("other")
+	foreign := AddSink(other, "sink", func(ctx context.Context, s string) error { return nil })
+	Link(OutputOf[string](p, "source", "output"), foreign)
+	if errs := p.Errors(); len(errs) != 4 || !strings.Contains(errs[3].Error(), "different pipelines") {
+		t.Errorf("Expected a cross-pipeline link error, got %v", errs)
+	}
+	if len(p.GetConnections()) != 1 {
+		t.Errorf("Expected only the valid link to connect, got %d", len(p.GetConnections()))
+	}
+}
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ComponentFactory creates a component from its configuration.
+type ComponentFactory func(config map[string]interface{}) (Component, error)
+
+// ComponentInfo describes a registered component type.
+type ComponentInfo struct {
+	Name        string
+	Version     string
+	Description string
+	Tags        []string
+	InputPorts  []PortInfo
+	OutputPorts []PortInfo
+	Parameters  []Parameter
+}
+
+// PortInfo describes a port of a registered component type.
+type PortInfo struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// ComponentRegistry maps component type names to factories so pipelines can be
+// built from configuration, for example from spec files.
+type ComponentRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]ComponentFactory
+	templates map[string]PipelineTemplate
+}
+
+// DefaultRegistry is the registry used when none is given explicitly.
+// Built-in components register themselves here.
+var DefaultRegistry = NewComponentRegistry()
+
+// NewComponentRegistry creates an empty registry.
+func NewComponentRegistry() *ComponentRegistry {
+	return &ComponentRegistry{
+		factories: make(map[string]ComponentFactory),
+		templates: make(map[string]PipelineTemplate),
+	}
+}
+
+// Register adds a component factory under name.
+func (r *ComponentRegistry) Register(name string, factory ComponentFactory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("component type '%s' is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// RegisterTemplate adds a pipeline template under its name. Instances of the
+// template can then be created like any other component, with the template
+// parameters as configuration.
+func (r *ComponentRegistry) RegisterTemplate(template PipelineTemplate) error {
+	if err := r.Register(template.Name(), func(config map[string]interface{}) (Component, error) {
+		return template.Instantiate(config)
+	}); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.templates[template.Name()] = template
+	r.mu.Unlock()
+	return nil
+}
+
+// Create instantiates a component of the named type.
+func (r *ComponentRegistry) Create(name string, config map[string]interface{}) (Component, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown component type '%s'", name)
+	}
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	component, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create component of type '%s': %w", name, err)
+	}
+	return component, nil
+}
+
+// Template returns the template registered under name, if any.
+func (r *ComponentRegistry) Template(name string) (PipelineTemplate, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	template, ok := r.templates[name]
+	return template, ok
+}
+
+// List returns information about every registered type, sorted by name.
+func (r *ComponentRegistry) List() []ComponentInfo {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	infos := make([]ComponentInfo, 0, len(names))
+	for _, name := range names {
+		if info, err := r.GetInfo(name); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// GetInfo describes the named type. Port information is taken from an instance
+// created with an empty configuration; it is omitted when that fails.
+func (r *ComponentRegistry) GetInfo(name string) (ComponentInfo, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	template, isTemplate := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return ComponentInfo{}, fmt.Errorf("unknown component type '%s'", name)
+	}
+
+	info := ComponentInfo{Name: name}
+	if isTemplate {
+		info.Version = template.Version()
+		info.Description = template.Description()
+		info.Parameters = template.Parameters()
+	}
+	component, err := factory(make(map[string]interface{}))
+	if err != nil {
+		return info, nil
+	}
+	info.Version = component.Version()
+	if info.Description == "" {
+		info.Description = component.Description()
+	}
+	info.Tags = component.Tags()
+	info.InputPorts = portInfos(component.InputPorts())
+	info.OutputPorts = portInfos(component.OutputPorts())
+	return info, nil
+}
+
+func portInfos(ports []Port) []PortInfo {
+	infos := make([]PortInfo, 0, len(ports))
+	for _, port := range ports {
+		typeName := "<nil>"
+		if port.Type() != nil {
+			typeName = port.Type().String()
+		}
+		infos = append(infos, PortInfo{
+			Name:        port.Name(),
+			Type:        typeName,
+			Required:    port.Required(),
+			Description: port.Description(),
+		})
+	}
+	return infos
+}
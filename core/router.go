@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Route sends the packets of a Router that satisfy When to the output port
+// Port.
+type Route[T any] struct {
+	Port string
+	When func(value T) bool
+}
+
+// Router sends each packet on its "input" port to the output ports whose
+// predicate it satisfies, or to the default port if it satisfies none.
+// Output ports that receive nothing produce nothing, so engines skip the
+// components behind them. Create one with NewRouter or NewSwitch.
+type Router struct {
+	BaseComponent
+	routes      []Route[interface{}]
+	defaultPort string
+	firstMatch  bool
+	input       func(inputs map[string]interface{}) (interface{}, error)
+	err         error
+}
+
+// NewRouter creates a router that sends a packet to every route it
+// satisfies. Packets that satisfy no route go to defaultPort, or are dropped
+// if defaultPort is empty.
+//
+//	router := core.NewRouter("other",
+//		core.Route[Order]{Port: "express", When: func(o Order) bool { return o.Express }},
+//		core.Route[Order]{Port: "bulk", When: func(o Order) bool { return o.Items > 100 }},
+//	)
+func NewRouter[T any](defaultPort string, routes ...Route[T]) *Router {
+	return newRouter(defaultPort, false, routes)
+}
+
+// NewSwitch creates a router that sends a packet to the first route it
+// satisfies, in the order given, and otherwise to defaultPort.
+func NewSwitch[T any](defaultPort string, routes ...Route[T]) *Router {
+	return newRouter(defaultPort, true, routes)
+}
+
+func newRouter[T any](defaultPort string, firstMatch bool, routes []Route[T]) *Router {
+	c := &Router{defaultPort: defaultPort, firstMatch: firstMatch}
+	c.ComponentDescription = "Routes packets by predicate"
+	c.ComponentTags = []string{"router"}
+	if firstMatch {
+		c.ComponentDescription = "Routes each packet to the first matching port"
+		c.ComponentTags = []string{"router", "switch"}
+	}
+	c.Inputs = []Port{typedPort[T]("input", true)}
+	c.input = func(inputs map[string]interface{}) (interface{}, error) {
+		return typedInput[T](c.Name(), inputs, "input")
+	}
+
+	seen := make(map[string]bool)
+	addPort := func(name string) {
+		if seen[name] {
+			c.err = fmt.Errorf("router port %q is used more than once", name)
+			return
+		}
+		seen[name] = true
+		c.Outputs = append(c.Outputs, typedPort[T](name, false))
+	}
+	for _, route := range routes {
+		if route.Port == "" || route.When == nil {
+			c.err = fmt.Errorf("router routes need a port and a predicate")
+			continue
+		}
+		addPort(route.Port)
+		when := route.When
+		c.routes = append(c.routes, Route[interface{}]{Port: route.Port, When: func(value interface{}) bool {
+			// Process has checked the type, so only a nil interface value
+			// fails the assertion, and T's zero value is then nil as well
+			typed, _ := value.(T)
+			return when(typed)
+		}})
+	}
+	if defaultPort != "" {
+		addPort(defaultPort)
+	}
+	return c
+}
+
+// Validate reports invalid routes.
+func (c *Router) Validate() error {
+	return c.err
+}
+
+// DefaultPort returns the port for packets that match no route, or "" if
+// they are dropped.
+func (c *Router) DefaultPort() string {
+	return c.defaultPort
+}
+
+// Process evaluates the routes for the input packet. A packet of the wrong
+// type is an error.
+func (c *Router) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := inputs["input"]; !ok {
+		return nil, funcInputError(c.Name(), "input", c.Inputs[0].Type(), nil)
+	}
+	value, err := c.input(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]interface{})
+	for _, route := range c.routes {
+		if route.When(value) {
+			outputs[route.Port] = value
+			if c.firstMatch {
+				break
+			}
+		}
+	}
+	if len(outputs) == 0 && c.defaultPort != "" {
+		outputs[c.defaultPort] = value
+	}
+	return outputs, nil
+}
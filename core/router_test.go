@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	routes := []Route[int]{
+		{Port: "small", When: func(n int) bool { return n < 10 }},
+		{Port: "even", When: func(n int) bool { return n%2 == 0 }},
+	}
+
+	tests := []struct {
+		name   string
+		router *Router
+		input  int
+		want   map[string]interface{}
+	}{
+		{"router sends to every match", NewRouter("other", routes...), 4, map[string]interface{}{"small": 4, "even": 4}},
+		{"switch sends to the first match", NewSwitch("other", routes...), 4, map[string]interface{}{"small": 4}},
+		{"switch falls through", NewSwitch("other", routes...), 12, map[string]interface{}{"even": 12}},
+		{"default port", NewRouter("other", routes...), 13, map[string]interface{}{"other": 13}},
+		{"no default drops", NewRouter("", routes...), 13, map[string]interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.router.Process(ctx, map[string]interface{}{"input": tt.input})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRouterPorts(t *testing.T) {
+	router := NewSwitch("other", Route[string]{Port: "a", When: func(s string) bool { return s == "a" }})
+	var names []string
+	for _, port := range router.OutputPorts() {
+		names = append(names, port.Name())
+		if port.Type() != reflect.TypeOf("") {
+			t.Errorf("Expected port %s to carry strings, got %s", port.Name(), port.Type())
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"a", "other"}) || router.DefaultPort() != "other" {
+		t.Errorf("Unexpected output ports %v", names)
+	}
+	if err := router.Validate(); err != nil {
+		t.Errorf("Expected a valid router, got %v", err)
+	}
+
+	duplicate := NewRouter("a", Route[string]{Port: "a", When: func(s string) bool { return true }})
+	if err := duplicate.Validate(); err == nil {
+		t.Error("Expected a duplicate port to be invalid")
+	}
+	if err := NewRouter[string]("", Route[string]{Port: "a"}).Validate(); err == nil {
+		t.Error("Expected a route without a predicate to be invalid")
+	}
+}
+
+func TestRouterRejectsWrongType(t *testing.T) {
+	router := NewRouter("other", Route[int]{Port: "zero", When: func(n int) bool { return n == 0 }})
+	router.SetName("router")
+	_, err := router.Process(context.Background(), map[string]interface{}{"input": "0"})
+	if err == nil || !strings.Contains(err.Error(), "input input expects int, got string") {
+		t.Errorf("Expected a type error, got %v", err)
+	}
+	if _, err := router.Process(context.Background(), map[string]interface{}{}); err == nil {
+		t.Error("Expected a missing packet to be an error")
+	}
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RunControl pauses and stops the sources of a run. Engines find it in the
+// run's context and call Admit before a source emits, so pausing stops new
+// packets from entering the pipeline while packets already in flight are
+// processed as usual and nothing buffered is lost.
+type RunControl struct {
+	mu      sync.Mutex
+	paused  bool
+	stopped bool
+	// resume is closed when the run is resumed or stopped
+	resume chan struct{}
+}
+
+// NewRunControl creates a RunControl that admits sources.
+func NewRunControl() *RunControl {
+	return &RunControl{}
+}
+
+// Pause holds sources back until Resume or Stop.
+func (c *RunControl) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused || c.stopped {
+		return
+	}
+	c.paused = true
+	c.resume = make(chan struct{})
+}
+
+// Resume lets held sources emit.
+func (c *RunControl) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	close(c.resume)
+}
+
+// Stop refuses every source that has not emitted yet, so the run drains and
+// ends.
+func (c *RunControl) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.paused {
+		c.paused = false
+		close(c.resume)
+	}
+}
+
+// Paused reports whether sources are held back.
+func (c *RunControl) Paused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Stopped reports whether Stop was called.
+func (c *RunControl) Stopped() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stopped
+}
+
+// Admit blocks while the run is paused. It reports whether the source may
+// emit: false once the run is stopped or ctx is done. A nil RunControl is
+// never paused or stopped.
+func (c *RunControl) Admit(ctx context.Context) bool {
+	if c == nil {
+		return ctx.Err() == nil
+	}
+	c.mu.Lock()
+	stopped, resume := c.stopped, c.resume
+	paused := c.paused
+	c.mu.Unlock()
+
+	if stopped {
+		return false
+	}
+	if paused {
+		select {
+		case <-resume:
+			return !c.Stopped()
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+type runControlKey struct{}
+
+// WithRunControl returns a context carrying c for the engines.
+func WithRunControl(ctx context.Context, c *RunControl) context.Context {
+	return context.WithValue(ctx, runControlKey{}, c)
+}
+
+// RunControlFromContext returns the RunControl of a run, or nil.
+func RunControlFromContext(ctx context.Context) *RunControl {
+	c, _ := ctx.Value(runControlKey{}).(*RunControl)
+	return c
+}
+
+// RunHandle controls a run started with Pipeline.Start.
+type RunHandle struct {
+	pipeline *Pipeline
+	control  *RunControl
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+
+	// Guards the status changes of Pause and Resume against the end of the
+	// run; ended is set once the run has returned
+	mu    sync.Mutex
+	ended bool
+}
+
+// Start runs the pipeline like Run, but in the background. The returned
+// handle pauses, resumes and stops the run and waits for it to end. Errors
+// that prevent the run from starting are returned immediately.
+func (p *Pipeline) Start(ctx context.Context) (*RunHandle, error) {
+	if len(p.errors) > 0 {
+		return nil, fmt.Errorf("pipeline has %d construction errors", len(p.errors))
+	}
+	if p.engine == nil {
+		return nil, fmt.Errorf("execution engine is not set")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	h := &RunHandle{
+		pipeline: p,
+		control:  NewRunControl(),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	p.context.SetStatus(PipelineStatusRunning)
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		err := p.Run(WithRunControl(ctx, h.control))
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.err = err
+		h.ended = true
+		switch status := p.context.GetStatus(); {
+		case h.control.Stopped():
+			p.context.SetStatus(PipelineStatusStopped)
+		case status == PipelineStatusRunning || status == PipelineStatusPaused:
+			// Pause or Resume raced with the end of the run
+			if err != nil {
+				p.context.SetStatus(PipelineStatusError)
+			} else {
+				p.context.SetStatus(PipelineStatusIdle)
+			}
+		}
+	}()
+	return h, nil
+}
+
+// Pause stops sources from emitting. Packets already in the pipeline are
+// still processed. The pipeline context reports PipelineStatusPaused, and
+// held sources ComponentStatePaused.
+func (h *RunHandle) Pause() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ended {
+		return
+	}
+	h.control.Pause()
+	if h.control.Paused() {
+		h.pipeline.context.SetStatus(PipelineStatusPaused)
+	}
+}
+
+// Resume lets paused sources emit again.
+func (h *RunHandle) Resume() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.control.Paused() && !h.ended {
+		h.control.Resume()
+		h.pipeline.context.SetStatus(PipelineStatusRunning)
+	}
+}
+
+// Stop ends the run. A graceful stop refuses sources that have not emitted
+// yet and lets the packets in flight drain; otherwise the run's context is
+// cancelled. Stop does not wait; call Wait for the outcome.
+func (h *RunHandle) Stop(graceful bool) {
+	h.control.Stop()
+	if !graceful {
+		h.cancel()
+	}
+}
+
+// Wait blocks until the run ends and returns its error.
+func (h *RunHandle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// Done is closed when the run ends.
+func (h *RunHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Status returns the status of the run.
+func (h *RunHandle) Status() PipelineStatus {
+	return h.pipeline.context.GetStatus()
+}
+
+// SetStatus records the status of the pipeline. It is safe to call while the
+// pipeline runs.
+func (c *PipelineContext) SetStatus(status PipelineStatus) {
+	c.statesMu.Lock()
+	defer c.statesMu.Unlock()
+	c.Status = status
+}
+
+// GetStatus returns the recorded status of the pipeline.
+func (c *PipelineContext) GetStatus() PipelineStatus {
+	c.statesMu.RLock()
+	defer c.statesMu.RUnlock()
+	return c.Status
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 )
 
 // BaseSchema provides a default implementation of the Schema interface
@@ -69,30 +71,97 @@ func (s *BaseSchema) Migrate(data interface{}, targetSchema Schema) (interface{}
 	return nil, fmt.Errorf("no migration path from %s to target schema", s.schemaType)
 }
 
-// JSONSchema returns a JSON Schema representation
+// JSONSchema returns a draft 2020-12 JSON Schema document. Struct types are
+// described field by field; constraints with a JSON Schema equivalent are
+// mapped to keywords and the others are listed in $comment.
 func (s *BaseSchema) JSONSchema() string {
-	schema := map[string]interface{}{
-		"type":        s.getJSONType(s.schemaType),
-		"description": s.description,
+	root := &SchemaNode{}
+	if derived, err := SchemaOf(s.schemaType); err == nil {
+		root = derived.root
 	}
+	root.Schema = JSONSchemaDialect
+	root.Description = s.description
 
-	if len(s.constraints) > 0 {
-		constraints := make([]string, len(s.constraints))
-		for i, c := range s.constraints {
-			constraints[i] = c.Description()
+	var unmapped []string
+	for _, c := range s.constraints {
+		if !applyConstraintKeywords(root, c) {
+			unmapped = append(unmapped, c.Description())
 		}
-		schema["constraints"] = constraints
+	}
+	if len(unmapped) > 0 {
+		root.Comment = "constraints: " + strings.Join(unmapped, "; ")
 	}
 
-	jsonBytes, _ := json.MarshalIndent(schema, "", "  ")
+	jsonBytes, _ := json.MarshalIndent(root, "", "  ")
 	return string(jsonBytes)
 }
 
+// applyConstraintKeywords expresses a constraint as JSON Schema keywords. It
+// reports false for constraints that have no equivalent.
+func applyConstraintKeywords(node *SchemaNode, c Constraint) bool {
+	if jc, ok := c.(JSONSchemaConstraint); ok {
+		return jc.ApplyJSONSchema(node)
+	}
+	switch c := c.(type) {
+	case *NotNilConstraint:
+		// Implied by the type keyword
+		if len(node.Type) > 0 {
+			return !node.Type.Has("null")
+		}
+		if node.Not == nil {
+			node.Not = &SchemaNode{Type: SchemaTypes{"null"}}
+			return true
+		}
+		return false
+	case *StringLengthConstraint:
+		if c.MinLength > 0 {
+			min := c.MinLength
+			node.MinLength = &min
+		}
+		if c.MaxLength > 0 {
+			max := c.MaxLength
+			node.MaxLength = &max
+		}
+		return true
+	case *NumericRangeConstraint:
+		min, minOK := toFloat(c.Min)
+		max, maxOK := toFloat(c.Max)
+		if (c.Min != nil && !minOK) || (c.Max != nil && !maxOK) {
+			return false
+		}
+		if minOK {
+			node.Minimum = &min
+		}
+		if maxOK {
+			node.Maximum = &max
+		}
+		return true
+	case *RegexConstraint:
+		node.Pattern = c.Pattern
+		return true
+	default:
+		return false
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() || !isNumberKind(val.Kind()) {
+		return 0, false
+	}
+	return numberOf(val), true
+}
+
 // AddConstraint adds a constraint to the schema
 func (s *BaseSchema) AddConstraint(constraint Constraint) {
 	s.constraints = append(s.constraints, constraint)
 }
 
+// Constraints returns the constraints added to the schema
+func (s *BaseSchema) Constraints() []Constraint {
+	return s.constraints
+}
+
 // SetMigrationFunc sets a custom migration function
 func (s *BaseSchema) SetMigrationFunc(fn func(interface{}, Schema) (interface{}, error)) {
 	s.migrationFunc = fn
@@ -130,27 +199,6 @@ func (s *BaseSchema) isCompatibleType(t1, t2 reflect.Type) bool {
 	return false
 }
 
-// getJSONType converts Go type to JSON Schema type
-func (s *BaseSchema) getJSONType(t reflect.Type) string {
-	switch t.Kind() {
-	case reflect.String:
-		return "string"
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		 reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return "integer"
-	case reflect.Float32, reflect.Float64:
-		return "number"
-	case reflect.Bool:
-		return "boolean"
-	case reflect.Slice, reflect.Array:
-		return "array"
-	case reflect.Map, reflect.Struct:
-		return "object"
-	default:
-		return "any"
-	}
-}
-
 // Common constraint implementations
 
 // NotNilConstraint ensures the value is not nil
@@ -274,7 +322,10 @@ func (c *NumericRangeConstraint) Description() string {
 // RegexConstraint validates strings against a regular expression
 type RegexConstraint struct {
 	Pattern string
-	regex   interface{} // Would be *regexp.Regexp in real implementation
+
+	once  sync.Once
+	regex *regexp.Regexp
+	err   error
 }
 
 func (c *RegexConstraint) Validate(data interface{}) error {
@@ -283,14 +334,17 @@ func (c *RegexConstraint) Validate(data interface{}) error {
 		return fmt.Errorf("regex constraint can only be applied to strings, got %T", data)
 	}
 
-	// In a real implementation, we would compile and use the regex
-	// For now, just check if the pattern is not empty
 	if c.Pattern == "" {
 		return fmt.Errorf("regex pattern is empty")
 	}
 
-	// Placeholder validation - in real implementation would use regexp.MatchString
-	if len(str) == 0 && c.Pattern != ".*" {
+	c.once.Do(func() {
+		c.regex, c.err = regexp.Compile(c.Pattern)
+	})
+	if c.err != nil {
+		return fmt.Errorf("invalid regex pattern %s: %w", c.Pattern, c.err)
+	}
+	if !c.regex.MatchString(str) {
 		return fmt.Errorf("string does not match pattern %s", c.Pattern)
 	}
 
@@ -0,0 +1,231 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IdentifiedSchema is implemented by schemas with a stable identifier, such
+// as a JSON Schema $id. Schema migrations are registered between identifiers.
+type IdentifiedSchema interface {
+	Schema
+	SchemaID() string
+}
+
+// TypeID returns the schema identifier of a Go type: the import path and name
+// for named types, the type string otherwise.
+func TypeID(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
+
+// PortSchemaID returns the identifier of the data carried by a port: its
+// schema's identifier if it has one, otherwise the TypeID of its type.
+func PortSchemaID(port Port) string {
+	if s, ok := port.Schema().(IdentifiedSchema); ok {
+		if id := s.SchemaID(); id != "" {
+			return id
+		}
+	}
+	return TypeID(port.Type())
+}
+
+// SchemaMigration converts a packet from one schema to the next.
+type SchemaMigration struct {
+	From        string
+	To          string
+	Description string
+	Migrate     func(data interface{}) (interface{}, error)
+}
+
+// SchemaMigrationPath is a chain of migrations applied in order.
+type SchemaMigrationPath []SchemaMigration
+
+// Apply migrates data along the path.
+func (p SchemaMigrationPath) Apply(data interface{}) (interface{}, error) {
+	for _, step := range p {
+		migrated, err := step.Migrate(data)
+		if err != nil {
+			return nil, fmt.Errorf("schema migration %s -> %s failed: %w", step.From, step.To, err)
+		}
+		data = migrated
+	}
+	return data, nil
+}
+
+// String lists the schemas along the path, e.g. "a -> b -> c".
+func (p SchemaMigrationPath) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	ids := []string{p[0].From}
+	for _, step := range p {
+		ids = append(ids, step.To)
+	}
+	return strings.Join(ids, " -> ")
+}
+
+// SchemaMigrationRegistry holds packet migrations between schemas and chains
+// them to connect ports several versions apart.
+type SchemaMigrationRegistry struct {
+	mu    sync.RWMutex
+	steps map[string]map[string]SchemaMigration
+}
+
+// DefaultSchemaMigrations is used by pipelines without a registry of their own.
+var DefaultSchemaMigrations = NewSchemaMigrationRegistry()
+
+// NewSchemaMigrationRegistry creates an empty registry.
+func NewSchemaMigrationRegistry() *SchemaMigrationRegistry {
+	return &SchemaMigrationRegistry{steps: make(map[string]map[string]SchemaMigration)}
+}
+
+// Register adds a migration between two schema identifiers.
+func (r *SchemaMigrationRegistry) Register(migration SchemaMigration) error {
+	if migration.From == "" || migration.To == "" {
+		return fmt.Errorf("schema migration needs both a source and a target schema")
+	}
+	if migration.From == migration.To {
+		return fmt.Errorf("schema migration %s -> %s does not change the schema", migration.From, migration.To)
+	}
+	if migration.Migrate == nil {
+		return fmt.Errorf("schema migration %s -> %s has no Migrate function", migration.From, migration.To)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.steps[migration.From][migration.To]; exists {
+		return fmt.Errorf("schema migration %s -> %s is already registered", migration.From, migration.To)
+	}
+	if r.steps[migration.From] == nil {
+		r.steps[migration.From] = make(map[string]SchemaMigration)
+	}
+	r.steps[migration.From][migration.To] = migration
+	return nil
+}
+
+// RegisterSchemas registers a migration that calls from.Migrate with the
+// target schema, using the schemas' identifiers.
+func (r *SchemaMigrationRegistry) RegisterSchemas(fromID string, from Schema, toID string, to Schema) error {
+	return r.Register(SchemaMigration{
+		From:        fromID,
+		To:          toID,
+		Description: fmt.Sprintf("%s.Migrate", fromID),
+		Migrate: func(data interface{}) (interface{}, error) {
+			return from.Migrate(data, to)
+		},
+	})
+}
+
+// RegisterTypeMigration registers a migration between two Go types, keyed by
+// their TypeIDs.
+func RegisterTypeMigration[From, To any](r *SchemaMigrationRegistry, migrate func(From) (To, error)) error {
+	fromType := reflect.TypeOf((*From)(nil)).Elem()
+	toType := reflect.TypeOf((*To)(nil)).Elem()
+	return r.Register(SchemaMigration{
+		From:        TypeID(fromType),
+		To:          TypeID(toType),
+		Description: fmt.Sprintf("%s to %s", fromType, toType),
+		Migrate: func(data interface{}) (interface{}, error) {
+			value, ok := data.(From)
+			if !ok {
+				return nil, fmt.Errorf("expected %s, got %T", fromType, data)
+			}
+			return migrate(value)
+		},
+	})
+}
+
+// Path returns the shortest chain of migrations from one schema to another.
+func (r *SchemaMigrationRegistry) Path(from, to string) (SchemaMigrationPath, error) {
+	if from == to {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Breadth-first search, visiting targets in sorted order so the chosen
+	// path is deterministic
+	previous := map[string]SchemaMigration{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		targets := make([]string, 0, len(r.steps[current]))
+		for target := range r.steps[current] {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			if visited[target] {
+				continue
+			}
+			visited[target] = true
+			previous[target] = r.steps[current][target]
+			if target == to {
+				var path SchemaMigrationPath
+				for id := to; id != from; id = previous[id].From {
+					path = append(SchemaMigrationPath{previous[id]}, path...)
+				}
+				return path, nil
+			}
+			queue = append(queue, target)
+		}
+	}
+	return nil, fmt.Errorf("no schema migration path from %s to %s", from, to)
+}
+
+// Migrate converts data from one schema to another along the shortest path.
+func (r *SchemaMigrationRegistry) Migrate(data interface{}, from, to string) (interface{}, error) {
+	path, err := r.Path(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return path.Apply(data)
+}
+
+// SetSchemaMigrations sets the registry used to connect ports with different
+// schemas. Pipelines use DefaultSchemaMigrations otherwise.
+func (p *Pipeline) SetSchemaMigrations(r *SchemaMigrationRegistry) *Pipeline {
+	p.schemaMigrations = r
+	return p
+}
+
+// SchemaMigrations returns the registry used to connect ports with different
+// schemas.
+func (p *Pipeline) SchemaMigrations() *SchemaMigrationRegistry {
+	if p.schemaMigrations != nil {
+		return p.schemaMigrations
+	}
+	return DefaultSchemaMigrations
+}
+
+// migrationBetween returns the migration needed to connect out to in. It
+// returns nil when the ports carry the same schema, and an error when they
+// differ in type and no migration path exists.
+func (p *Pipeline) migrationBetween(out, in Port) (SchemaMigrationPath, error) {
+	fromID, toID := PortSchemaID(out), PortSchemaID(in)
+	if fromID == toID {
+		if out.Type() != in.Type() {
+			return nil, fmt.Errorf("ports share schema %s but carry different types", fromID)
+		}
+		return nil, nil
+	}
+	path, err := p.SchemaMigrations().Path(fromID, toID)
+	if err != nil && out.Type() == in.Type() {
+		// Same Go type with differently identified schemas; leave it to
+		// schema compatibility checks
+		return nil, nil
+	}
+	return path, err
+}
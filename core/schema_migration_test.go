@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type recordV1 struct{ Name string }
+type recordV2 struct{ First, Last string }
+type recordV3 struct {
+	First, Last string
+	Version     int
+}
+
+// recordComponent has a single input or output port of the given type.
+type recordComponent struct {
+	BaseComponent
+}
+
+func newRecordComponent(input, output reflect.Type) *recordComponent {
+	c := &recordComponent{}
+	if input != nil {
+		c.Inputs = []Port{&BasePort{PortName: "input", PortType: input, IsRequired: true}}
+	}
+	if output != nil {
+		c.Outputs = []Port{&BasePort{PortName: "output", PortType: output}}
+	}
+	return c
+}
+
+func (c *recordComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func newRecordMigrations(t *testing.T) *SchemaMigrationRegistry {
+	r := NewSchemaMigrationRegistry()
+	err := RegisterTypeMigration(r, func(v recordV1) (recordV2, error) {
+		first, last, _ := strings.Cut(v.Name, " ")
+		return recordV2{First: first, Last: last}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RegisterTypeMigration(r, func(v recordV2) (recordV3, error) {
+		return recordV3{First: v.First, Last: v.Last, Version: 3}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSchemaMigrationPath(t *testing.T) {
+	r := newRecordMigrations(t)
+	v1, v3 := TypeID(reflect.TypeOf(recordV1{})), TypeID(reflect.TypeOf(recordV3{}))
+
+	path, err := r.Path(v1, v3)
+	if err != nil {
+		t.Fatalf("Expected a path: %v", err)
+	}
+	if len(path) != 2 {
+		t.Fatalf("Expected a two-step path, got %s", path)
+	}
+	migrated, err := path.Apply(recordV1{Name: "Ada Lovelace"})
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if want := (recordV3{First: "Ada", Last: "Lovelace", Version: 3}); migrated != want {
+		t.Errorf("Expected %+v, got %+v", want, migrated)
+	}
+
+	if _, err := r.Path(v3, v1); err == nil {
+		t.Error("Expected no path backwards")
+	}
+	if err := RegisterTypeMigration(r, func(v recordV1) (recordV2, error) { return recordV2{}, nil }); err == nil {
+		t.Error("Expected duplicate registration to fail")
+	}
+	if _, err := path.Apply("not a record"); err == nil {
+		t.Error("Expected a type error for the wrong input")
+	}
+}
+
+func TestConnectWithSchemaMigration(t *testing.T) {
+	p := NewPipeline("migration_test").SetSchemaMigrations(newRecordMigrations(t))
+	p.AddComponent("producer", newRecordComponent(nil, reflect.TypeOf(recordV1{})))
+	p.AddComponent("consumer", newRecordComponent(reflect.TypeOf(recordV3{}), nil))
+	Connect[recordV1](p, "producer", "output", "consumer", "input")
+
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatalf("Expected the migration to be accepted, got %v", errs)
+	}
+	conn := p.GetConnections()[0]
+	if len(conn.Migration) != 2 {
+		t.Fatalf("Expected a two-step migration on the connection, got %s", conn.Migration)
+	}
+	if result := p.ValidateComprehensive(); !result.Valid {
+		t.Errorf("Expected validation to pass, got %v", result.Errors)
+	}
+
+	// Without a registered path the types must still match exactly
+	q := NewPipeline("no_migration")
+	q.AddComponent("producer", newRecordComponent(nil, reflect.TypeOf(recordV1{})))
+	q.AddComponent("consumer", newRecordComponent(reflect.TypeOf(recordV3{}), nil))
+	q.ConnectPorts("producer", "output", "consumer", "input")
+	if len(q.Errors()) != 1 {
+		t.Errorf("Expected a type mismatch error, got %v", q.Errors())
+	}
+}
+
+func TestSchemaMigrationByID(t *testing.T) {
+	v1, err := LoadJSONSchema([]byte(`{"$id": "urn:record:1", "type": "object", "required": ["name"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := LoadJSONSchema([]byte(`{"$id": "urn:record:2", "type": "object", "required": ["first"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewSchemaMigrationRegistry()
+	err = r.Register(SchemaMigration{From: v1.SchemaID(), To: v2.SchemaID(), Migrate: func(data interface{}) (interface{}, error) {
+		record, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a record, got %T", data)
+		}
+		return map[string]interface{}{"first": record["name"]}, nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapType := reflect.TypeOf(map[string]interface{}{})
+	producer := newRecordComponent(nil, mapType)
+	producer.Outputs[0].(*BasePort).PortSchema = v1
+	consumer := newRecordComponent(mapType, nil)
+	consumer.Inputs[0].(*BasePort).PortSchema = v2
+
+	p := NewPipeline("id_migration").SetSchemaMigrations(r)
+	p.AddComponent("producer", producer)
+	p.AddComponent("consumer", consumer)
+	Connect[map[string]interface{}](p, "producer", "output", "consumer", "input")
+
+	conn := p.GetConnections()[0]
+	if conn.Migration.String() != "urn:record:1 -> urn:record:2" {
+		t.Fatalf("Expected a migration between schema IDs, got %q", conn.Migration)
+	}
+	migrated, err := conn.Migration.Apply(map[string]interface{}{"name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v2.Validate(migrated); err != nil {
+		t.Errorf("Migrated record should match the target schema: %v", err)
+	}
+}
@@ -0,0 +1,515 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaEnum is implemented by types whose values are restricted to a fixed
+// set. Derived schemas list the values in an "enum" keyword.
+type SchemaEnum interface {
+	EnumValues() []interface{}
+}
+
+// StructuredSchema validates structs and map-shaped data against a JSON
+// Schema document. It is derived from Go types with SchemaOf and SchemaFor,
+// or loaded from a document with LoadJSONSchema.
+type StructuredSchema struct {
+	root   *SchemaNode
+	goType reflect.Type
+}
+
+// SchemaFor derives a schema from the Go type T.
+func SchemaFor[T any]() (*StructuredSchema, error) {
+	return SchemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// MustSchemaFor is like SchemaFor but panics if T cannot be described.
+func MustSchemaFor[T any]() *StructuredSchema {
+	s, err := SchemaFor[T]()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// SchemaOf derives a schema from a Go type. Struct fields are named after
+// their json tags and are required unless they are pointers, tagged
+// omitempty, or tagged schema:"optional". Field-level keywords come from the
+// schema tag, for example
+//
+//	Name  string   `json:"name" schema:"minLength=1,maxLength=64"`
+//	Email string   `json:"email" schema:"format=email" pattern:"@"`
+//	Tags  []string `json:"tags,omitempty" schema:"maxItems=10"`
+//	Kind  string   `json:"kind" schema:"enum=a|b|c" description:"Record kind"`
+//
+// Supported schema tag keys are required, optional, enum, format, minLength,
+// maxLength, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// multipleOf, minItems, maxItems and uniqueItems. Recursive types are
+// described with "$defs" and "$ref".
+func SchemaOf(t reflect.Type) (*StructuredSchema, error) {
+	d := &schemaDeriver{defs: make(map[reflect.Type]*SchemaNode), visiting: make(map[reflect.Type]bool)}
+	root, err := d.node(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.refs) > 0 {
+		defs := make(map[string]*SchemaNode, len(d.refs))
+		for typ, name := range d.refs {
+			// The definition may be the root itself; copy it so $defs does not contain itself
+			def := *d.defs[typ]
+			def.Defs = nil
+			defs[name] = &def
+		}
+		root.Defs = defs
+	}
+	return &StructuredSchema{root: root, goType: t}, nil
+}
+
+// LoadJSONSchema parses a JSON Schema document. Data validated by the
+// resulting schema is usually map-shaped, as produced by encoding/json.
+func LoadJSONSchema(data []byte) (*StructuredSchema, error) {
+	var root SchemaNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Schema != "" && root.Schema != JSONSchemaDialect {
+		return nil, fmt.Errorf("unsupported JSON Schema dialect %q, expected %s", root.Schema, JSONSchemaDialect)
+	}
+	return &StructuredSchema{root: &root}, nil
+}
+
+// NewStructuredSchema wraps an existing schema node.
+func NewStructuredSchema(root *SchemaNode) *StructuredSchema {
+	return &StructuredSchema{root: root}
+}
+
+// Root returns the root node of the schema document.
+func (s *StructuredSchema) Root() *SchemaNode {
+	return s.root
+}
+
+// GoType returns the type the schema was derived from, or nil for loaded
+// schemas.
+func (s *StructuredSchema) GoType() reflect.Type {
+	return s.goType
+}
+
+// SchemaID returns the document's $id, or the TypeID of the Go type the
+// schema was derived from.
+func (s *StructuredSchema) SchemaID() string {
+	if s.root.ID != "" {
+		return s.root.ID
+	}
+	return TypeID(s.goType)
+}
+
+// Validate checks data against the schema. Errors name the offending value
+// with a path such as $.address.zip.
+func (s *StructuredSchema) Validate(data interface{}) error {
+	v := &schemaValidator{root: s.root}
+	return v.validate(s.root, data, "$")
+}
+
+// Compatible reports whether data valid for other is accepted by this schema
+// as far as types and required properties are concerned.
+func (s *StructuredSchema) Compatible(other Schema) bool {
+	switch o := other.(type) {
+	case *StructuredSchema:
+		return nodesCompatible(s.root, o.root, s.root, o.root, 0)
+	case *BaseSchema:
+		derived, err := SchemaOf(o.schemaType)
+		if err != nil {
+			return false
+		}
+		return nodesCompatible(s.root, derived.root, s.root, derived.root, 0)
+	default:
+		return false
+	}
+}
+
+// Migrate returns data unchanged when the target schema is compatible.
+func (s *StructuredSchema) Migrate(data interface{}, targetSchema Schema) (interface{}, error) {
+	if targetSchema.Compatible(s) {
+		return data, nil
+	}
+	return nil, fmt.Errorf("no migration path to target schema")
+}
+
+// JSONSchema returns the schema as a draft 2020-12 JSON Schema document.
+func (s *StructuredSchema) JSONSchema() string {
+	root := *s.root
+	root.Schema = JSONSchemaDialect
+	jsonBytes, _ := json.MarshalIndent(&root, "", "  ")
+	return string(jsonBytes)
+}
+
+// nodesCompatible reports whether every value accepted by from is of a type
+// accepted by to and carries the properties to requires.
+func nodesCompatible(to, from, toRoot, fromRoot *SchemaNode, depth int) bool {
+	if depth > 32 {
+		return true
+	}
+	to = resolveForCompare(to, toRoot)
+	from = resolveForCompare(from, fromRoot)
+	if to == nil || from == nil {
+		return false
+	}
+	if to.Never {
+		return false
+	}
+	if len(to.Type) > 0 {
+		if len(from.Type) == 0 {
+			return false
+		}
+		for _, typ := range from.Type {
+			if !to.Type.Has(typ) && !(typ == "integer" && to.Type.Has("number")) {
+				return false
+			}
+		}
+	}
+	for _, name := range to.Required {
+		if !contains(from.Required, name) {
+			return false
+		}
+	}
+	for name, prop := range to.Properties {
+		if fromProp, ok := from.Properties[name]; ok && !nodesCompatible(prop, fromProp, toRoot, fromRoot, depth+1) {
+			return false
+		}
+	}
+	if to.Items != nil && from.Items != nil && !nodesCompatible(to.Items, from.Items, toRoot, fromRoot, depth+1) {
+		return false
+	}
+	if to.AdditionalProperties != nil && from.AdditionalProperties != nil &&
+		!nodesCompatible(to.AdditionalProperties, from.AdditionalProperties, toRoot, fromRoot, depth+1) {
+		return false
+	}
+	return true
+}
+
+func resolveForCompare(node, root *SchemaNode) *SchemaNode {
+	if node == nil || node.Ref == "" {
+		return node
+	}
+	target, err := (&schemaValidator{root: root}).resolve(node.Ref)
+	if err != nil {
+		return nil
+	}
+	return target
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	schemaEnumType = reflect.TypeOf((*SchemaEnum)(nil)).Elem()
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// schemaDeriver builds schema nodes from Go types.
+type schemaDeriver struct {
+	defs     map[reflect.Type]*SchemaNode
+	visiting map[reflect.Type]bool
+	refs     map[reflect.Type]string
+}
+
+func (d *schemaDeriver) node(t reflect.Type) (*SchemaNode, error) {
+	if t.Kind() == reflect.Ptr {
+		node, err := d.node(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case node.Ref != "":
+			// A reference cannot be widened in place
+			return &SchemaNode{AnyOf: []*SchemaNode{node, {Type: SchemaTypes{"null"}}}}, nil
+		case len(node.Type) > 0 && !node.Type.Has("null"):
+			nullable := *node
+			nullable.Type = append(append(SchemaTypes(nil), node.Type...), "null")
+			return &nullable, nil
+		}
+		return node, nil
+	}
+
+	node, err := d.typeNode(t)
+	if err != nil {
+		return nil, err
+	}
+	if (t.Kind() == reflect.Slice || t.Kind() == reflect.Map) && len(node.Type) > 0 {
+		// encoding/json writes nil slices and maps as null
+		node.Type = append(node.Type, "null")
+	}
+	if t.Implements(schemaEnumType) {
+		node.Enum = reflect.Zero(t).Interface().(SchemaEnum).EnumValues()
+	}
+	return node, nil
+}
+
+func (d *schemaDeriver) typeNode(t reflect.Type) (*SchemaNode, error) {
+	if t == timeType {
+		return &SchemaNode{Type: SchemaTypes{"string"}, Format: "date-time"}, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &SchemaNode{Type: SchemaTypes{"string"}}, nil
+	case reflect.Bool:
+		return &SchemaNode{Type: SchemaTypes{"boolean"}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &SchemaNode{Type: SchemaTypes{"integer"}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &SchemaNode{Type: SchemaTypes{"number"}}, nil
+	case reflect.Interface:
+		return &SchemaNode{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// encoding/json writes byte slices as base64 strings
+			return &SchemaNode{Type: SchemaTypes{"string"}}, nil
+		}
+		items, err := d.node(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		node := &SchemaNode{Type: SchemaTypes{"array"}, Items: items}
+		if t.Kind() == reflect.Array {
+			n := t.Len()
+			node.MinItems, node.MaxItems = &n, &n
+		}
+		return node, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("cannot derive schema for map key type %s", t.Key())
+		}
+		values, err := d.node(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &SchemaNode{Type: SchemaTypes{"object"}, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+			// Custom JSON encodings cannot be described by reflection
+			return &SchemaNode{}, nil
+		}
+		return d.structNode(t)
+	default:
+		return nil, fmt.Errorf("cannot derive schema for type %s", t)
+	}
+}
+
+func (d *schemaDeriver) structNode(t reflect.Type) (*SchemaNode, error) {
+	if d.visiting[t] {
+		if d.refs == nil {
+			d.refs = make(map[reflect.Type]string)
+		}
+		name := t.Name()
+		if name == "" {
+			return nil, fmt.Errorf("cannot derive schema for recursive anonymous struct %s", t)
+		}
+		d.refs[t] = name
+		return &SchemaNode{Ref: "#/$defs/" + name}, nil
+	}
+	if def, ok := d.defs[t]; ok {
+		copied := *def
+		return &copied, nil
+	}
+
+	node := &SchemaNode{Type: SchemaTypes{"object"}, Properties: make(map[string]*SchemaNode)}
+	d.visiting[t] = true
+	d.defs[t] = node
+	defer delete(d.visiting, t)
+
+	if err := d.addFields(node, t); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// addFields adds the exported fields of t to node, flattening embedded
+// structs the way encoding/json does.
+func (d *schemaDeriver) addFields(node *SchemaNode, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := d.addFields(node, ft); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := d.node(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		// Copy so field-level keywords do not leak into shared definitions
+		copied := *prop
+		prop = &copied
+
+		required := !omitEmpty && field.Type.Kind() != reflect.Ptr
+		if desc, ok := field.Tag.Lookup("description"); ok {
+			prop.Description = desc
+		}
+		if pattern, ok := field.Tag.Lookup("pattern"); ok {
+			prop.Pattern = pattern
+		}
+		if tag, ok := field.Tag.Lookup("schema"); ok {
+			if required, err = applySchemaTag(prop, field.Type, tag, required); err != nil {
+				return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+			}
+		}
+
+		node.Properties[name] = prop
+		if required && !contains(node.Required, name) {
+			node.Required = append(node.Required, name)
+		}
+	}
+	return nil
+}
+
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
+
+// applySchemaTag applies the keywords of a schema struct tag to node and
+// returns whether the field is required.
+func applySchemaTag(node *SchemaNode, t reflect.Type, tag string, required bool) (bool, error) {
+	for _, entry := range strings.Split(tag, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(entry, "=")
+		var err error
+		switch key {
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "format":
+			node.Format = value
+		case "enum":
+			node.Enum, err = parseEnum(t, value)
+		case "minLength":
+			node.MinLength, err = parseIntKeyword(key, value)
+		case "maxLength":
+			node.MaxLength, err = parseIntKeyword(key, value)
+		case "minItems":
+			node.MinItems, err = parseIntKeyword(key, value)
+		case "maxItems":
+			node.MaxItems, err = parseIntKeyword(key, value)
+		case "uniqueItems":
+			node.UniqueItems = true
+		case "minimum":
+			node.Minimum, err = parseFloatKeyword(key, value)
+		case "maximum":
+			node.Maximum, err = parseFloatKeyword(key, value)
+		case "exclusiveMinimum":
+			node.ExclusiveMinimum, err = parseFloatKeyword(key, value)
+		case "exclusiveMaximum":
+			node.ExclusiveMaximum, err = parseFloatKeyword(key, value)
+		case "multipleOf":
+			node.MultipleOf, err = parseFloatKeyword(key, value)
+		default:
+			return required, fmt.Errorf("unknown schema tag key %q", key)
+		}
+		if err != nil {
+			return required, err
+		}
+	}
+	return required, nil
+}
+
+func parseIntKeyword(key, value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+	}
+	return &n, nil
+}
+
+func parseFloatKeyword(key, value string) (*float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number, got %q", key, value)
+	}
+	return &f, nil
+}
+
+// parseEnum parses "|"-separated enum values according to the field kind.
+func parseEnum(t reflect.Type, value string) ([]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var values []interface{}
+	for _, item := range strings.Split(value, "|") {
+		switch t.Kind() {
+		case reflect.String:
+			values = append(values, item)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean enum value %q", item)
+			}
+			values = append(values, b)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseInt(item, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer enum value %q", item)
+			}
+			values = append(values, n)
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number enum value %q", item)
+			}
+			values = append(values, f)
+		default:
+			return nil, fmt.Errorf("enum is not supported for type %s", t)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,242 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type schemaColor string
+
+func (schemaColor) EnumValues() []interface{} {
+	return []interface{}{"red", "green", "blue"}
+}
+
+type schemaAddress struct {
+	Street string `json:"street" schema:"minLength=1"`
+	Zip    string `json:"zip" pattern:"^[0-9]{5}$"`
+}
+
+type schemaCustomer struct {
+	Name     string            `json:"name" schema:"minLength=1,maxLength=20" description:"Full name"`
+	Age      int               `json:"age" schema:"minimum=0,maximum=150"`
+	Email    *string           `json:"email"`
+	Tier     string            `json:"tier" schema:"enum=free|pro"`
+	Color    schemaColor       `json:"color,omitempty"`
+	Tags     []string          `json:"tags,omitempty" schema:"maxItems=3"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Address  schemaAddress     `json:"address"`
+	Created  time.Time         `json:"created" schema:"optional"`
+	internal int
+}
+
+type schemaTree struct {
+	Value    int           `json:"value"`
+	Children []*schemaTree `json:"children,omitempty"`
+}
+
+func TestSchemaForStruct(t *testing.T) {
+	schema, err := SchemaFor[schemaCustomer]()
+	if err != nil {
+		t.Fatalf("SchemaFor failed: %v", err)
+	}
+	root := schema.Root()
+
+	if !reflect.DeepEqual(root.Required, []string{"name", "age", "tier", "address"}) {
+		t.Errorf("Unexpected required fields: %v", root.Required)
+	}
+	if _, ok := root.Properties["internal"]; ok {
+		t.Error("Unexported fields should not be described")
+	}
+	if got := root.Properties["email"].Type; !reflect.DeepEqual(got, SchemaTypes{"string", "null"}) {
+		t.Errorf("Expected nullable email, got %v", got)
+	}
+	if got := root.Properties["color"].Enum; len(got) != 3 {
+		t.Errorf("Expected enum from EnumValues, got %v", got)
+	}
+	if got := root.Properties["labels"].AdditionalProperties; got == nil || !got.Type.Has("string") {
+		t.Errorf("Expected map values to be described, got %+v", got)
+	}
+	if got := root.Properties["address"].Properties["zip"].Pattern; got != "^[0-9]{5}$" {
+		t.Errorf("Expected nested pattern, got %q", got)
+	}
+	if got := root.Properties["created"].Format; got != "date-time" {
+		t.Errorf("Expected date-time format, got %q", got)
+	}
+
+	valid := schemaCustomer{Name: "Ada", Age: 36, Tier: "pro", Address: schemaAddress{Street: "Main", Zip: "12345"}}
+	if err := schema.Validate(valid); err != nil {
+		t.Errorf("Valid struct rejected: %v", err)
+	}
+
+	invalid := valid
+	invalid.Address.Zip = "abc"
+	err = schema.Validate(&invalid)
+	if err == nil || !strings.Contains(err.Error(), "$.address.zip") {
+		t.Errorf("Expected error at $.address.zip, got %v", err)
+	}
+}
+
+func TestStructuredSchemaValidatesMaps(t *testing.T) {
+	schema := MustSchemaFor[schemaCustomer]()
+
+	data := map[string]interface{}{
+		"name":    "Ada",
+		"age":     36,
+		"tier":    "enterprise",
+		"address": map[string]interface{}{"street": "Main", "zip": "12345"},
+	}
+	err := schema.Validate(data)
+	if err == nil || !strings.Contains(err.Error(), "$.tier") {
+		t.Errorf("Expected enum error at $.tier, got %v", err)
+	}
+
+	data["tier"] = "free"
+	data["tags"] = []interface{}{"a", "b", "c", "d"}
+	if err := schema.Validate(data); err == nil || !strings.Contains(err.Error(), "$.tags") {
+		t.Errorf("Expected maxItems error at $.tags, got %v", err)
+	}
+
+	delete(data, "tags")
+	delete(data, "address")
+	if err := schema.Validate(data); err == nil || !strings.Contains(err.Error(), `"address"`) {
+		t.Errorf("Expected missing property error, got %v", err)
+	}
+
+	data["address"] = map[string]interface{}{"street": "Main", "zip": "12345"}
+	data["age"] = 36.5
+	if err := schema.Validate(data); err == nil {
+		t.Error("Expected a non-integral age to be rejected")
+	}
+}
+
+func TestSchemaForNilCollections(t *testing.T) {
+	type record struct {
+		Name   string            `json:"name"`
+		Tags   []string          `json:"tags"`
+		Labels map[string]string `json:"labels"`
+		Data   []byte            `json:"data"`
+	}
+	schema := MustSchemaFor[record]()
+
+	if err := schema.Validate(record{}); err != nil {
+		t.Errorf("Zero value rejected: %v", err)
+	}
+	if err := schema.Validate(record{Name: "a", Tags: []string{"x"}, Labels: map[string]string{"k": "v"}}); err != nil {
+		t.Errorf("Valid struct rejected: %v", err)
+	}
+	err := schema.Validate(map[string]interface{}{"name": "a", "tags": []interface{}{1}, "labels": nil, "data": nil})
+	if err == nil || !strings.Contains(err.Error(), "$.tags[0]") {
+		t.Errorf("Expected the items of a non-nil slice to be checked, got %v", err)
+	}
+}
+
+func TestJSONSchemaRoundTrip(t *testing.T) {
+	schema := MustSchemaFor[schemaCustomer]()
+	doc := schema.JSONSchema()
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &raw); err != nil {
+		t.Fatalf("Export is not valid JSON: %v", err)
+	}
+	if raw["$schema"] != JSONSchemaDialect {
+		t.Errorf("Expected $schema %s, got %v", JSONSchemaDialect, raw["$schema"])
+	}
+
+	loaded, err := LoadJSONSchema([]byte(doc))
+	if err != nil {
+		t.Fatalf("LoadJSONSchema failed: %v", err)
+	}
+	if loaded.JSONSchema() != doc {
+		t.Errorf("Round trip changed the document:\n%s\nvs\n%s", doc, loaded.JSONSchema())
+	}
+	valid := map[string]interface{}{
+		"name": "Ada", "age": 36, "tier": "pro",
+		"address": map[string]interface{}{"street": "Main", "zip": "12345"},
+	}
+	if err := loaded.Validate(valid); err != nil {
+		t.Errorf("Loaded schema rejected valid data: %v", err)
+	}
+	if !loaded.Compatible(schema) || !schema.Compatible(loaded) {
+		t.Error("Loaded and derived schemas should be compatible")
+	}
+}
+
+func TestLoadJSONSchema(t *testing.T) {
+	doc := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer", "exclusiveMinimum": 0},
+			"note": {"type": ["string", "null"]}
+		},
+		"additionalProperties": false
+	}`
+	schema, err := LoadJSONSchema([]byte(doc))
+	if err != nil {
+		t.Fatalf("LoadJSONSchema failed: %v", err)
+	}
+
+	if err := schema.Validate(map[string]interface{}{"id": 1.0, "note": nil}); err != nil {
+		t.Errorf("Valid data rejected: %v", err)
+	}
+	if err := schema.Validate(map[string]interface{}{"id": 0}); err == nil {
+		t.Error("Expected exclusiveMinimum violation")
+	}
+	if err := schema.Validate(map[string]interface{}{"id": 1, "extra": true}); err == nil || !strings.Contains(err.Error(), "$.extra") {
+		t.Errorf("Expected additional property to be rejected, got %v", err)
+	}
+
+	if _, err := LoadJSONSchema([]byte(`{"type": "object", "patternProperties": {}}`)); err == nil {
+		t.Error("Expected unsupported keyword to be rejected")
+	}
+	if _, err := LoadJSONSchema([]byte(`{"$schema": "http://json-schema.org/draft-07/schema#"}`)); err == nil {
+		t.Error("Expected other dialects to be rejected")
+	}
+}
+
+func TestRecursiveSchema(t *testing.T) {
+	schema := MustSchemaFor[schemaTree]()
+	if _, ok := schema.Root().Defs["schemaTree"]; !ok {
+		t.Fatalf("Expected recursive type in $defs:\n%s", schema.JSONSchema())
+	}
+
+	loaded, err := LoadJSONSchema([]byte(schema.JSONSchema()))
+	if err != nil {
+		t.Fatalf("Recursive schema should round-trip: %v", err)
+	}
+	if loaded.JSONSchema() != schema.JSONSchema() {
+		t.Errorf("Round trip changed the document:\n%s", loaded.JSONSchema())
+	}
+
+	tree := schemaTree{Value: 1, Children: []*schemaTree{{Value: 2}, nil}}
+	if err := schema.Validate(tree); err != nil {
+		t.Errorf("Valid tree rejected: %v", err)
+	}
+	bad := map[string]interface{}{"value": 1, "children": []interface{}{map[string]interface{}{"value": "x"}}}
+	if err := schema.Validate(bad); err == nil || !strings.Contains(err.Error(), "$.children[0].value") {
+		t.Errorf("Expected error at $.children[0].value, got %v", err)
+	}
+}
+
+func TestBaseSchemaJSONSchemaKeywords(t *testing.T) {
+	schema := NewBaseSchema(reflect.TypeOf(""), "Name")
+	schema.AddConstraint(&NotNilConstraint{})
+	schema.AddConstraint(&StringLengthConstraint{MinLength: 1, MaxLength: 10})
+	schema.AddConstraint(&RegexConstraint{Pattern: "^[A-Z]"})
+
+	loaded, err := LoadJSONSchema([]byte(schema.JSONSchema()))
+	if err != nil {
+		t.Fatalf("BaseSchema export should load: %v", err)
+	}
+	root := loaded.Root()
+	if *root.MinLength != 1 || *root.MaxLength != 10 || root.Pattern != "^[A-Z]" || root.Comment != "" {
+		t.Errorf("Constraints not mapped to keywords: %s", schema.JSONSchema())
+	}
+	if err := loaded.Validate("lower"); err == nil {
+		t.Error("Expected pattern violation")
+	}
+}
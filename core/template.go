@@ -0,0 +1,283 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Parameter declares a template parameter.
+type Parameter struct {
+	Name        string
+	Type        reflect.Type
+	Required    bool
+	Default     interface{}
+	Description string
+}
+
+// PipelineTemplate is a reusable pipeline shape that is instantiated with
+// parameters. Instances are pipelines and can therefore be used as components.
+type PipelineTemplate interface {
+	Name() string
+	Version() string
+	Description() string
+	Parameters() []Parameter
+	Instantiate(params map[string]interface{}) (*Pipeline, error)
+}
+
+// TemplateBuilder adds the components and connections of a template to p.
+// params holds a value of the declared type for every parameter that was
+// supplied or has a default.
+type TemplateBuilder func(p *Pipeline, params map[string]interface{}) error
+
+// Template is the standard PipelineTemplate implementation.
+type Template struct {
+	name        string
+	version     string
+	description string
+	parameters  []Parameter
+	build       TemplateBuilder
+}
+
+// NewTemplate creates a template whose instances are built by build.
+func NewTemplate(name string, build TemplateBuilder) *Template {
+	return &Template{name: name, version: "1.0.0", build: build}
+}
+
+// Param declares an optional parameter. The parameter type is taken from the
+// default value.
+func (t *Template) Param(name string, defaultValue interface{}, description string) *Template {
+	t.parameters = append(t.parameters, Parameter{
+		Name:        name,
+		Type:        reflect.TypeOf(defaultValue),
+		Default:     defaultValue,
+		Description: description,
+	})
+	return t
+}
+
+// RequiredParam declares a parameter that must be supplied.
+func (t *Template) RequiredParam(name string, typ reflect.Type, description string) *Template {
+	t.parameters = append(t.parameters, Parameter{
+		Name:        name,
+		Type:        typ,
+		Required:    true,
+		Description: description,
+	})
+	return t
+}
+
+// SetVersion sets the version given to the template and its instances.
+func (t *Template) SetVersion(version string) *Template {
+	t.version = version
+	return t
+}
+
+// SetDescription sets the description of the template.
+func (t *Template) SetDescription(description string) *Template {
+	t.description = description
+	return t
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Version returns the version of the template.
+func (t *Template) Version() string {
+	return t.version
+}
+
+// Description returns the description of the template.
+func (t *Template) Description() string {
+	return t.description
+}
+
+// Parameters returns the declared parameters.
+func (t *Template) Parameters() []Parameter {
+	return append([]Parameter(nil), t.parameters...)
+}
+
+// Instantiate validates params and builds a new pipeline from the template.
+func (t *Template) Instantiate(params map[string]interface{}) (*Pipeline, error) {
+	values, err := ResolveParameters(t.parameters, params)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", t.name, err)
+	}
+
+	p := NewPipeline(t.name)
+	p.SetVersion(t.version)
+	p.SetDescription(t.description)
+	p.SetMetadata("template", t.name)
+	p.SetMetadata("parameters", values)
+	if err := t.build(p, values); err != nil {
+		return nil, fmt.Errorf("template %s: %w", t.name, err)
+	}
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("template %s: %d construction errors: %w", t.name, len(errs), errs[0])
+	}
+	return p, nil
+}
+
+// ResolveParameters checks params against the declared parameters and returns
+// the value to use for each of them. Unknown parameters, required parameters
+// that are missing or null, and null values for types that cannot be nil are
+// errors. Values are converted to the declared type where that is lossless, so
+// numbers decoded from JSON can be used for integer parameters and strings for
+// durations.
+func ResolveParameters(declared []Parameter, params map[string]interface{}) (map[string]interface{}, error) {
+	known := make(map[string]bool, len(declared))
+	for _, param := range declared {
+		known[param.Name] = true
+	}
+	var unknown []string
+	for name := range params {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameters: %s", strings.Join(unknown, ", "))
+	}
+
+	values := make(map[string]interface{}, len(declared))
+	for _, param := range declared {
+		value, ok := params[param.Name]
+		if ok && value == nil {
+			if param.Required {
+				return nil, fmt.Errorf("required parameter '%s' is null", param.Name)
+			}
+			if param.Type != nil && !canBeNil(param.Type) {
+				return nil, fmt.Errorf("parameter '%s' is null; omit it to use the default", param.Name)
+			}
+		}
+		if !ok {
+			if param.Required {
+				return nil, fmt.Errorf("missing required parameter '%s'", param.Name)
+			}
+			if param.Default != nil {
+				values[param.Name] = param.Default
+			}
+			continue
+		}
+		converted, err := convertParameter(value, param.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parameter '%s': %w", param.Name, err)
+		}
+		values[param.Name] = converted
+	}
+	return values, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func convertParameter(value interface{}, typ reflect.Type) (interface{}, error) {
+	if typ == nil || value == nil {
+		return value, nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type() == typ || (typ.Kind() == reflect.Interface && v.Type().Implements(typ)) {
+		return value, nil
+	}
+
+	if typ == durationType {
+		if s, ok := value.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration %q: %w", s, err)
+			}
+			return d, nil
+		}
+	}
+
+	switch {
+	case isInteger(typ.Kind()) && isNumeric(v.Kind()):
+		return convertInteger(v, typ)
+	case isNumeric(typ.Kind()) && isNumeric(v.Kind()):
+		converted := reflect.New(typ).Elem()
+		if v.CanFloat() && converted.OverflowFloat(v.Float()) {
+			return nil, fmt.Errorf("value %v overflows %s", value, typ)
+		}
+		converted.Set(v.Convert(typ))
+		return converted.Interface(), nil
+	case v.Kind() == reflect.Slice && typ.Kind() == reflect.Slice:
+		converted := reflect.MakeSlice(typ, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := convertParameter(v.Index(i).Interface(), typ.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			if elem != nil {
+				converted.Index(i).Set(reflect.ValueOf(elem))
+			}
+		}
+		return converted.Interface(), nil
+	}
+	return nil, fmt.Errorf("expected %s, got %T", typ, value)
+}
+
+// convertInteger converts a number to the integer type typ, rejecting
+// fractions and values that do not fit.
+func convertInteger(v reflect.Value, typ reflect.Type) (interface{}, error) {
+	converted := reflect.New(typ).Elem()
+	unsigned := typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64
+	overflow := fmt.Errorf("value %v overflows %s", v.Interface(), typ)
+	switch {
+	case v.CanInt():
+		i := v.Int()
+		if unsigned {
+			if i < 0 || converted.OverflowUint(uint64(i)) {
+				return nil, overflow
+			}
+			converted.SetUint(uint64(i))
+		} else {
+			if converted.OverflowInt(i) {
+				return nil, overflow
+			}
+			converted.SetInt(i)
+		}
+	case v.CanUint():
+		u := v.Uint()
+		if unsigned {
+			if converted.OverflowUint(u) {
+				return nil, overflow
+			}
+			converted.SetUint(u)
+		} else {
+			if u > math.MaxInt64 || converted.OverflowInt(int64(u)) {
+				return nil, overflow
+			}
+			converted.SetInt(int64(u))
+		}
+	default:
+		f := v.Float()
+		if f != math.Trunc(f) {
+			return nil, fmt.Errorf("expected %s, got non-integral %v", typ, v.Interface())
+		}
+		if unsigned {
+			if f < 0 || f >= math.Exp2(64) || converted.OverflowUint(uint64(f)) {
+				return nil, overflow
+			}
+			converted.SetUint(uint64(f))
+		} else {
+			if f < -math.Exp2(63) || f >= math.Exp2(63) || converted.OverflowInt(int64(f)) {
+				return nil, overflow
+			}
+			converted.SetInt(int64(f))
+		}
+	}
+	return converted.Interface(), nil
+}
+
+func isInteger(kind reflect.Kind) bool {
+	return (kind >= reflect.Int && kind <= reflect.Int64) || (kind >= reflect.Uint && kind <= reflect.Uint64)
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	return isInteger(kind) || kind == reflect.Float32 || kind == reflect.Float64
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTemplate() *Template {
+	return NewTemplate("pair", func(p *Pipeline, params map[string]interface{}) error {
+		p.AddComponent(params["first"].(string), NewTestValidationComponent("a"))
+		p.AddComponent("second", NewTestValidationComponent("b"))
+		Connect[string](p, params["first"].(string), "output", "second", "input")
+		p.SetConnectionBufferSize(params["first"].(string), "output", "second", "input", params["buffer"].(int))
+		return nil
+	}).
+		RequiredParam("first", reflect.TypeOf(""), "Name of the first component").
+		Param("buffer", 10, "Connection buffer size").
+		Param("timeout", time.Second, "Unused timeout").
+		SetVersion("2.0.0")
+}
+
+func TestTemplateInstantiate(t *testing.T) {
+	template := newTestTemplate()
+
+	// Numbers and durations arrive untyped from spec files
+	p, err := template.Instantiate(map[string]interface{}{"first": "head", "buffer": 25.0, "timeout": "5s"})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+	if p.Version() != "2.0.0" || p.GetConnections()[0].BufferSize != 25 {
+		t.Errorf("Expected version 2.0.0 and buffer 25, got %s and %d", p.Version(), p.GetConnections()[0].BufferSize)
+	}
+	params := p.GetMetadata("parameters").(map[string]interface{})
+	if params["timeout"] != 5*time.Second {
+		t.Errorf("Expected timeout to be converted to a duration, got %v", params["timeout"])
+	}
+
+	// Each instance is independent
+	other, err := template.Instantiate(map[string]interface{}{"first": "start"})
+	if err != nil {
+		t.Fatalf("Instantiate failed: %v", err)
+	}
+	if _, ok := other.GetComponents()["start"]; !ok || other.GetConnections()[0].BufferSize != 10 {
+		t.Errorf("Expected second instance with defaults, got %v", other.GetConnections())
+	}
+}
+
+func TestTemplateParameterValidation(t *testing.T) {
+	template := newTestTemplate()
+
+	tests := []struct {
+		params map[string]interface{}
+		want   string
+	}{
+		{map[string]interface{}{}, "missing required parameter 'first'"},
+		{map[string]interface{}{"first": "a", "extra": 1}, "unknown parameters: extra"},
+		{map[string]interface{}{"first": 1}, "parameter 'first': expected string"},
+		{map[string]interface{}{"first": "a", "buffer": 2.5}, "non-integral"},
+		{map[string]interface{}{"first": "a", "timeout": "soon"}, "invalid duration"},
+		{map[string]interface{}{"first": nil}, "required parameter 'first' is null"},
+		{map[string]interface{}{"first": "a", "buffer": nil}, "parameter 'buffer' is null"},
+		{map[string]interface{}{"first": "a", "buffer": 1e20}, "overflows int"},
+	}
+	for _, tt := range tests {
+		_, err := template.Instantiate(tt.params)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Instantiate(%v): expected error containing %q, got %v", tt.params, tt.want, err)
+		}
+	}
+}
+
+func TestRegistryTemplates(t *testing.T) {
+	registry := NewComponentRegistry()
+	if err := registry.RegisterTemplate(newTestTemplate()); err != nil {
+		t.Fatalf("RegisterTemplate failed: %v", err)
+	}
+	if err := registry.RegisterTemplate(newTestTemplate()); err == nil {
+		t.Error("Expected duplicate registration to fail")
+	}
+
+	component, err := registry.Create("pair", map[string]interface{}{"first": "x"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, ok := component.(*Pipeline); !ok {
+		t.Errorf("Expected template instance to be a pipeline, got %T", component)
+	}
+
+	info, err := registry.GetInfo("pair")
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if info.Version != "2.0.0" || len(info.Parameters) != 3 {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+	if _, err := registry.Create("missing", nil); err == nil {
+		t.Error("Expected unknown type to fail")
+	}
+}
+
+func TestConvertParameterOverflow(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		typ   reflect.Type
+		want  interface{}
+	}{
+		{127, reflect.TypeOf(int8(0)), int8(127)},
+		{128, reflect.TypeOf(int8(0)), nil},
+		{-1, reflect.TypeOf(uint(0)), nil},
+		{uint64(1 << 63), reflect.TypeOf(int64(0)), nil},
+		{300.0, reflect.TypeOf(uint8(0)), nil},
+		{1e300, reflect.TypeOf(float32(0)), nil},
+		{2.5, reflect.TypeOf(float32(0)), float32(2.5)},
+	}
+	for _, tt := range tests {
+		got, err := convertParameter(tt.value, tt.typ)
+		if tt.want == nil {
+			if err == nil || !strings.Contains(err.Error(), "overflows") {
+				t.Errorf("convertParameter(%v, %s): expected overflow error, got %v, %v", tt.value, tt.typ, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("convertParameter(%v, %s): expected %v, got %v, %v", tt.value, tt.typ, tt.want, got, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"reflect"
 	"testing"
 )
 
@@ -26,7 +27,7 @@ func TestComponent(t *testing.T, c Component, inputs map[string]interface{}, exp
 			t.Errorf("Process() did not return an output named '%s'", name)
 			continue
 		}
-		if actual != expected {
+		if !reflect.DeepEqual(actual, expected) {
 			t.Errorf("Process() returned an incorrect value for output '%s': got %v, want %v", name, actual, expected)
 		}
 	}
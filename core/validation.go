@@ -158,6 +158,11 @@ func (pv *PipelineValidator) buildComponentGraph(p *Pipeline) (*ComponentGraph,
 		}
 		graph.Edges = append(graph.Edges, edge)
 
+		// Feedback connections close loops and do not order components
+		if conn.Feedback {
+			continue
+		}
+
 		// Update node dependencies
 		if fromNode, ok := graph.Nodes[conn.FromComponent]; ok {
 			fromNode.Dependents = append(fromNode.Dependents, conn.ToComponent)
@@ -308,8 +313,8 @@ func (pv *PipelineValidator) validateConnections(p *Pipeline, result *Validation
 			continue
 		}
 
-		// Validate type compatibility
-		if fromPort.Type() != toPort.Type() {
+		// Validate type compatibility; a schema migration converts between types
+		if fromPort.Type() != toPort.Type() && len(conn.Migration) == 0 {
 			result.Errors = append(result.Errors, PipelineValidationError{
 				Type:       ValidationErrorTypeTypeMismatch,
 				Component:  conn.FromComponent,
@@ -362,6 +367,9 @@ func (pv *PipelineValidator) validateTypes(p *Pipeline, result *ValidationResult
 		if fromPort == nil || toPort == nil {
 			continue // Already handled in validateConnections
 		}
+		if len(conn.Migration) > 0 {
+			continue // Packets are migrated to the input schema
+		}
 
 		// Validate schemas if available
 		if fromPort.Schema() != nil && toPort.Schema() != nil {
@@ -391,6 +399,13 @@ func (pv *PipelineValidator) validateGraph(p *Pipeline, result *ValidationResult
 			})
 		}
 	}
+	if err := p.validateLoops(); err != nil {
+		result.Errors = append(result.Errors, PipelineValidationError{
+			Type:     ValidationErrorTypeCycle,
+			Message:  err.Error(),
+			Severity: Error,
+		})
+	}
 
 	// Check for disconnected components
 	pv.validateConnectivity(p, result)
@@ -543,6 +558,9 @@ func (pv *PipelineValidator) calculateTopologyOrder(graph *ComponentGraph) error
 
 	// Calculate in-degrees
 	for _, edge := range graph.Edges {
+		if edge.Connection != nil && edge.Connection.Feedback {
+			continue
+		}
 		inDegree[edge.To]++
 	}
 
@@ -590,10 +608,14 @@ func (pv *PipelineValidator) calculateTopologyOrder(graph *ComponentGraph) error
 	return nil
 }
 
-// detectCycles checks for cycles in the pipeline graph using DFS
+// detectCycles checks for cycles in the pipeline graph using DFS, ignoring
+// feedback connections
 func (pv *PipelineValidator) detectCycles(p *Pipeline) error {
 	graph := make(map[string][]string)
 	for _, conn := range p.GetConnections() {
+		if conn.Feedback {
+			continue
+		}
 		graph[conn.FromComponent] = append(graph[conn.FromComponent], conn.ToComponent)
 	}
 
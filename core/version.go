@@ -0,0 +1,144 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version (major.minor.patch with optional pre-release).
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+// ParseVersion parses versions such as "1.2.3", "v1.2" and "2.0.0-rc.1".
+// Missing minor and patch numbers default to zero. Build metadata after "+" is
+// ignored.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	text := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(text, "+"); i >= 0 {
+		text = text[:i]
+	}
+	if i := strings.Index(text, "-"); i >= 0 {
+		v.PreRelease = text[i+1:]
+		text = text[:i]
+		if v.PreRelease == "" {
+			return Version{}, fmt.Errorf("invalid version %q: empty pre-release", s)
+		}
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 || text == "" {
+		return Version{}, fmt.Errorf("invalid version %q: expected major.minor.patch", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q: bad number %q", s, part)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// MustParseVersion is like ParseVersion but panics on invalid input.
+func MustParseVersion(s string) Version {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String formats the version as major.minor.patch[-prerelease].
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than
+// other. A pre-release sorts before the corresponding release, and
+// pre-releases are compared as in semver: identifier by identifier, numeric
+// identifiers numerically and below alphanumeric ones, so rc.2 < rc.10.
+func (v Version) Compare(other Version) int {
+	for _, d := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.PreRelease == other.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case other.PreRelease == "":
+		return -1
+	default:
+		return comparePreRelease(v.PreRelease, other.PreRelease)
+	}
+}
+
+func comparePreRelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumericIdentifier(a), isNumericIdentifier(b)
+	switch {
+	case aNumeric && bNumeric:
+		// Compare by length first so large numbers cannot overflow
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SemanticVersion parses the pipeline version.
+func (p *Pipeline) SemanticVersion() (Version, error) {
+	return ParseVersion(p.version)
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchdogConfig enables stall detection in engines that support it. A stall
+// is reported when no packet has moved for StallTimeout while at least one
+// component is blocked sending or receiving.
+type WatchdogConfig struct {
+	StallTimeout time.Duration
+	// Abort ends the run with a *DeadlockError when a stall is detected
+	Abort bool
+	// OnStall is called with the diagnostic for every stall detected
+	OnStall func(report *StallReport)
+}
+
+// WaitKind describes what a component is doing while the pipeline stalls.
+type WaitKind int
+
+const (
+	WaitKindReceive WaitKind = iota
+	WaitKindSend
+	WaitKindExternalInput
+	WaitKindExternalOutput
+	WaitKindProcessing
+)
+
+func (wk WaitKind) String() string {
+	switch wk {
+	case WaitKindReceive:
+		return "receive"
+	case WaitKindSend:
+		return "send"
+	case WaitKindExternalInput:
+		return "external_input"
+	case WaitKindExternalOutput:
+		return "external_output"
+	case WaitKindProcessing:
+		return "processing"
+	default:
+		return "unknown"
+	}
+}
+
+// ComponentWait is the state of one unfinished component in a StallReport.
+// For sends and receives, Connection names the connection, WaitsFor the
+// component on its other end and QueueDepth/QueueCapacity its buffer. For
+// external ports, Port names the pipeline port.
+type ComponentWait struct {
+	Component     string
+	Kind          WaitKind
+	Connection    string
+	Port          string
+	WaitsFor      string
+	QueueDepth    int
+	QueueCapacity int
+	Since         time.Duration
+}
+
+// StallReport describes a stalled run: which component waits on which
+// connection, and the resulting wait-for graph.
+type StallReport struct {
+	Pipeline string
+	// Idle is how long no packet has moved
+	Idle  time.Duration
+	Waits []ComponentWait
+}
+
+// Blocked returns the components waiting to send or receive.
+func (r *StallReport) Blocked() []ComponentWait {
+	var blocked []ComponentWait
+	for _, w := range r.Waits {
+		if w.Kind != WaitKindProcessing {
+			blocked = append(blocked, w)
+		}
+	}
+	return blocked
+}
+
+// String renders the report as text, one component per line.
+func (r *StallReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pipeline %s stalled: no packet moved for %s\n", r.Pipeline, r.Idle.Round(time.Millisecond))
+	for _, w := range r.sortedWaits() {
+		switch w.Kind {
+		case WaitKindReceive:
+			fmt.Fprintf(&b, "  %s waits to receive on %s from %s (queue %d/%d)\n", w.Component, w.Connection, w.WaitsFor, w.QueueDepth, w.QueueCapacity)
+		case WaitKindSend:
+			fmt.Fprintf(&b, "  %s waits to send on %s to %s (queue %d/%d)\n", w.Component, w.Connection, w.WaitsFor, w.QueueDepth, w.QueueCapacity)
+		case WaitKindExternalInput:
+			fmt.Fprintf(&b, "  %s waits for external input %s\n", w.Component, w.Port)
+		case WaitKindExternalOutput:
+			fmt.Fprintf(&b, "  %s waits to emit external output %s\n", w.Component, w.Port)
+		case WaitKindProcessing:
+			fmt.Fprintf(&b, "  %s is processing for %s\n", w.Component, w.Since.Round(time.Millisecond))
+		}
+	}
+	return b.String()
+}
+
+// DOT renders the wait-for graph in Graphviz format. An edge a -> b means a
+// waits for b; external ports appear as box nodes.
+func (r *StallReport) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph wait_for {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, w := range r.sortedWaits() {
+		switch w.Kind {
+		case WaitKindReceive, WaitKindSend:
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", w.Component, w.WaitsFor,
+				fmt.Sprintf("%s %s (%d/%d)", w.Kind, w.Connection, w.QueueDepth, w.QueueCapacity))
+		case WaitKindExternalInput, WaitKindExternalOutput:
+			node := "external:" + w.Port
+			fmt.Fprintf(&b, "  %q [shape=box];\n", node)
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", w.Component, node, w.Kind.String())
+		case WaitKindProcessing:
+			fmt.Fprintf(&b, "  %q [style=filled, fillcolor=lightyellow, label=%q];\n", w.Component, w.Component+"\nprocessing")
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (r *StallReport) sortedWaits() []ComponentWait {
+	waits := append([]ComponentWait(nil), r.Waits...)
+	sort.Slice(waits, func(i, j int) bool { return waits[i].Component < waits[j].Component })
+	return waits
+}
+
+// DeadlockError ends a run aborted by the watchdog.
+type DeadlockError struct {
+	Report *StallReport
+}
+
+func (e *DeadlockError) Error() string {
+	var names []string
+	for _, w := range e.Report.Blocked() {
+		names = append(names, w.Component)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("pipeline %s deadlocked: no packet moved for %s, blocked components: %s",
+		e.Report.Pipeline, e.Report.Idle.Round(time.Millisecond), strings.Join(names, ", "))
+}
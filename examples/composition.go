@@ -15,13 +15,14 @@ func compositionExample() {
 	subPipeline.AddComponent("reader", components.NewFileReader("examples/input.txt"))
 	subPipeline.AddComponent("upper", components.NewUpperCase())
 	core.Connect[string](subPipeline, "reader", "output", "upper", "input")
+	subPipeline.Expose("text", "upper", "output")
 	subPipeline.SetEngine(execution.NewConcurrentEngine())
 
 	// Create the main pipeline
 	mainPipeline := core.NewPipeline("main-pipeline")
 	mainPipeline.AddComponent("sub", subPipeline)
 	mainPipeline.AddComponent("writer", components.NewFileWriter("examples/composition-output.txt"))
-	core.Connect[string](mainPipeline, "sub", "output", "writer", "input")
+	core.Connect[string](mainPipeline, "sub", "text", "writer", "input")
 
 	// Set the execution engine
 	mainPipeline.SetEngine(execution.NewConcurrentEngine())
@@ -5,5 +5,6 @@ func main() {
 	// You can comment out the examples you don't want to run.
 	simpleExample()
 	compositionExample()
+	templateExample()
 	fileProcessingExample()
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/execution"
+	"github.com/forrest/go-flow/spec"
+)
+
+// filterFileTemplate reads a file, keeps the lines containing a pattern,
+// upper-cases them and writes the result to another file.
+var filterFileTemplate = core.NewTemplate("filter_file", func(p *core.Pipeline, params map[string]interface{}) error {
+	p.AddComponent("reader", components.NewFileReader(params["input"].(string)))
+	p.AddComponent("filter", components.NewGrep(params["pattern"].(string)))
+	p.AddComponent("upper", components.NewUpperCase())
+	p.AddComponent("writer", components.NewFileWriter(params["output"].(string)))
+	core.Connect[string](p, "reader", "output", "filter", "input")
+	core.Connect[string](p, "filter", "output", "upper", "input")
+	core.Connect[string](p, "upper", "output", "writer", "input")
+	return nil
+}).
+	RequiredParam("pattern", reflect.TypeOf(""), "Substring a line must contain").
+	Param("input", "examples/input.txt", "File to read").
+	Param("output", "examples/output.txt", "File to write").
+	SetDescription("Filter and upper-case the lines of a file")
+
+func templateExample() {
+	p, err := filterFileTemplate.Instantiate(map[string]interface{}{"pattern": "Go"})
+	if err != nil {
+		fmt.Println("Error instantiating template:", err)
+		return
+	}
+	p.SetEngine(execution.NewDefaultEngine())
+	if err := p.Run(context.Background()); err != nil {
+		fmt.Println("Error running pipeline:", err)
+	}
+
+	// The same template is available to spec files once registered.
+	core.DefaultRegistry.RegisterTemplate(filterFileTemplate)
+	p, err = spec.LoadPipeline("examples/specs/filter.json", map[string]interface{}{"pattern": "Flow"})
+	if err != nil {
+		fmt.Println("Error loading spec:", err)
+		return
+	}
+	p.SetEngine(execution.NewDefaultEngine())
+	if err := p.Run(context.Background()); err != nil {
+		fmt.Println("Error running pipeline:", err)
+	}
+}
@@ -0,0 +1,165 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+)
+
+func newCompositeTestPipeline(failReverse bool) *core.Pipeline {
+	sub := newRecordTestPipeline(failReverse)
+	sub.Expose("in", "upper", "input")
+	sub.Expose("out", "reverse", "result")
+	return sub
+}
+
+func TestSubPipelineAsComponent(t *testing.T) {
+	for _, engine := range []core.ExecutionEngine{nil, NewDefaultEngine()} {
+		sub := newCompositeTestPipeline(false)
+		if engine != nil {
+			sub.SetEngine(engine)
+		}
+		if got := sub.InputPorts(); len(got) != 1 || got[0].Name() != "in" {
+			t.Fatalf("Expected a single 'in' port, got %v", got)
+		}
+
+		p := core.NewPipeline("parent")
+		p.AddComponent("source", components.NewStringSource("abc"))
+		p.AddComponent("sub", sub)
+		core.Connect[string](p, "source", "output", "sub", "in")
+
+		outputs := map[string]chan interface{}{"out": make(chan interface{}, 1)}
+		if err := NewDefaultEngine().Run(context.Background(), p, nil, outputs); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if got := <-outputs["out"]; got != "CBA" {
+			t.Errorf("Expected 'CBA', got %v", got)
+		}
+	}
+}
+
+func TestSubPipelineErrorPropagation(t *testing.T) {
+	sub := newCompositeTestPipeline(true)
+	sub.SetEngine(NewDefaultEngine())
+
+	_, err := sub.Process(context.Background(), map[string]interface{}{"in": "abc"})
+	if err == nil {
+		t.Fatal("Expected the inner failure to be returned")
+	}
+	pipelineErr, ok := err.(core.PipelineError)
+	if !ok || pipelineErr.Component() != "record_test" {
+		t.Fatalf("Expected a PipelineError from record_test, got %T: %v", err, err)
+	}
+	if !strings.Contains(err.Error(), "reverse must not run") {
+		t.Errorf("Expected the inner error message, got %v", err)
+	}
+	if sub.GetErrorCollector().Count() != 1 {
+		t.Errorf("Expected the error to be collected by the sub-pipeline")
+	}
+}
+
+func TestSubPipelinePortCollisions(t *testing.T) {
+	sub := core.NewPipeline("collide")
+	sub.AddComponent("a", components.NewUpperCase())
+	sub.AddComponent("b", components.NewUpperCase())
+
+	var names []string
+	for _, port := range sub.InputPorts() {
+		names = append(names, port.Name())
+	}
+	if want := []string{"a.input", "b.input"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected qualified port names %v, got %v", want, names)
+	}
+
+	sub.Expose("in", "a", "input").Expose("in", "b", "input").Expose("x", "missing", "input")
+	if got := len(sub.Errors()); got != 2 {
+		t.Errorf("Expected duplicate and unknown exposures to be rejected, got %v", sub.Errors())
+	}
+}
+
+// lifecycleCounter forwards its input once initialized and counts the
+// lifecycle calls of all counters made by one factory.
+type lifecycleCounter struct {
+	core.BaseComponent
+	initialized bool
+	counts      *lifecycleCounts
+}
+
+type lifecycleCounts struct {
+	created, initialized, cleaned int32
+}
+
+func (c *lifecycleCounts) factory() core.Component {
+	atomic.AddInt32(&c.created, 1)
+	w := &lifecycleCounter{counts: c}
+	w.Inputs = []core.Port{&core.BasePort{PortName: "input", PortType: reflect.TypeOf(0), IsRequired: true}}
+	w.Outputs = []core.Port{&core.BasePort{PortName: "output", PortType: reflect.TypeOf(0)}}
+	return w
+}
+
+func (c *lifecycleCounter) Initialize(ctx context.Context) error {
+	c.initialized = true
+	atomic.AddInt32(&c.counts.initialized, 1)
+	return nil
+}
+
+func (c *lifecycleCounter) Cleanup(ctx context.Context) error {
+	atomic.AddInt32(&c.counts.cleaned, 1)
+	return nil
+}
+
+func (c *lifecycleCounter) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if !c.initialized {
+		return nil, errors.New("not initialized")
+	}
+	return map[string]interface{}{"output": inputs["input"]}, nil
+}
+
+func newLifecycleSubPipeline(counts *lifecycleCounts) *core.Pipeline {
+	sub := core.NewPipeline("sub")
+	sub.AddComponentFactory("w", counts.factory)
+	sub.Expose("in", "w", "input").Expose("out", "w", "output")
+	return sub
+}
+
+func TestSubPipelineInstanceLifecycle(t *testing.T) {
+	counts := &lifecycleCounts{}
+	sub := newLifecycleSubPipeline(counts)
+
+	const calls = 3
+	for i := 0; i < calls; i++ {
+		outputs, err := sub.Process(context.Background(), map[string]interface{}{"in": i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outputs["out"] != i {
+			t.Errorf("Expected %d, got %v", i, outputs["out"])
+		}
+	}
+	// One component for the pipeline itself and one per call
+	if counts.created != calls+1 || counts.initialized != calls || counts.cleaned != calls {
+		t.Errorf("Expected %d instances initialized and cleaned up once each, got %+v", calls, *counts)
+	}
+}
+
+func TestForEachInstanceLifecycle(t *testing.T) {
+	counts := &lifecycleCounts{}
+	forEach := core.NewForEach(newLifecycleSubPipeline(counts), core.ForEachConfig{Parallelism: 2})
+
+	outputs, err := forEach.Process(context.Background(), map[string]interface{}{"items": []int{1, 2, 3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(outputs["results"], want) {
+		t.Errorf("Expected %v, got %v", want, outputs["results"])
+	}
+	if counts.created != 5 || counts.initialized != 4 || counts.cleaned != 4 {
+		t.Errorf("Expected one initialized and cleaned up instance per element, got %+v", *counts)
+	}
+}
@@ -0,0 +1,346 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// StopReason describes why the DebugEngine paused.
+type StopReason int
+
+const (
+	StopBeforeComponent StopReason = iota
+	StopAfterComponent
+	StopOnConnection
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopBeforeComponent:
+		return "BEFORE"
+	case StopAfterComponent:
+		return "AFTER"
+	case StopOnConnection:
+		return "CONNECTION"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Condition decides whether a breakpoint fires. It receives the inputs map for
+// before-component breakpoints, the outputs map for after-component breakpoints
+// and the packet for connection breakpoints.
+type Condition func(data interface{}) bool
+
+// Breakpoint pauses execution before or after a component, or when a packet crosses a connection.
+type Breakpoint struct {
+	ID        int
+	Reason    StopReason
+	Component string
+	// Connection endpoints, only used for StopOnConnection breakpoints.
+	FromComponent string
+	FromPort      string
+	ToComponent   string
+	ToPort        string
+	Condition     Condition
+}
+
+// Stop is delivered on DebugEngine.Stops whenever execution pauses.
+// The inputs, outputs and packet may be modified before resuming.
+type Stop struct {
+	Reason     StopReason
+	Component  string
+	Breakpoint *Breakpoint
+	Inputs     map[string]interface{}
+	Outputs    map[string]interface{}
+	Connection *core.Connection
+	// Original holds the packet as emitted by the source port, before any schema migration or connection transform.
+	Original interface{}
+	Packet   interface{}
+
+	resume chan debugCommand
+}
+
+type debugCommand int
+
+const (
+	commandContinue debugCommand = iota
+	commandStep
+	commandAbort
+)
+
+// Continue resumes execution until the next breakpoint.
+func (s *Stop) Continue() {
+	s.resume <- commandContinue
+}
+
+// Step resumes execution and pauses again at the next pause point.
+func (s *Stop) Step() {
+	s.resume <- commandStep
+}
+
+// Abort stops the run; Run returns an error.
+func (s *Stop) Abort() {
+	s.resume <- commandAbort
+}
+
+// SetPacket replaces the packet that is about to be delivered across the connection.
+func (s *Stop) SetPacket(data interface{}) {
+	s.Packet = data
+}
+
+// String describes the pause point.
+func (s *Stop) String() string {
+	if s.Reason == StopOnConnection && s.Connection != nil {
+		return fmt.Sprintf("%s %s.%s -> %s.%s: %v", s.Reason, s.Connection.FromComponent, s.Connection.FromPort,
+			s.Connection.ToComponent, s.Connection.ToPort, s.Packet)
+	}
+	return fmt.Sprintf("%s %s", s.Reason, s.Component)
+}
+
+// DebugEngine executes a pipeline sequentially and pauses at breakpoints,
+// allowing the caller to inspect and modify data between components.
+type DebugEngine struct {
+	mu          sync.Mutex
+	breakpoints []*Breakpoint
+	nextID      int
+	stepping    bool
+	stops       chan *Stop
+}
+
+// NewDebugEngine creates a new DebugEngine. If stepping is true the engine
+// pauses before the first component.
+func NewDebugEngine(stepping bool) *DebugEngine {
+	return &DebugEngine{
+		stepping: stepping,
+		stops:    make(chan *Stop),
+	}
+}
+
+// Stops returns the channel on which pause points are delivered.
+// Every received Stop must be resumed with Continue, Step or Abort.
+func (e *DebugEngine) Stops() <-chan *Stop {
+	return e.stops
+}
+
+// BreakBefore pauses before the named component runs.
+func (e *DebugEngine) BreakBefore(component string, cond Condition) *Breakpoint {
+	return e.addBreakpoint(&Breakpoint{Reason: StopBeforeComponent, Component: component, Condition: cond})
+}
+
+// BreakAfter pauses after the named component runs.
+func (e *DebugEngine) BreakAfter(component string, cond Condition) *Breakpoint {
+	return e.addBreakpoint(&Breakpoint{Reason: StopAfterComponent, Component: component, Condition: cond})
+}
+
+// BreakOnConnection pauses when a packet crosses the given connection.
+// Empty endpoint fields act as wildcards.
+func (e *DebugEngine) BreakOnConnection(fromComponent, fromPort, toComponent, toPort string, cond Condition) *Breakpoint {
+	return e.addBreakpoint(&Breakpoint{
+		Reason:        StopOnConnection,
+		FromComponent: fromComponent,
+		FromPort:      fromPort,
+		ToComponent:   toComponent,
+		ToPort:        toPort,
+		Condition:     cond,
+	})
+}
+
+// RemoveBreakpoint removes the breakpoint with the given ID.
+func (e *DebugEngine) RemoveBreakpoint(id int) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, bp := range e.breakpoints {
+		if bp.ID == id {
+			e.breakpoints = append(e.breakpoints[:i], e.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Breakpoints returns the registered breakpoints.
+func (e *DebugEngine) Breakpoints() []*Breakpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	result := make([]*Breakpoint, len(e.breakpoints))
+	copy(result, e.breakpoints)
+	return result
+}
+
+func (e *DebugEngine) addBreakpoint(bp *Breakpoint) *Breakpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nextID++
+	bp.ID = e.nextID
+	e.breakpoints = append(e.breakpoints, bp)
+	return bp
+}
+
+// Run executes the pipeline sequentially, pausing at breakpoints. Feedback
+// loops are not supported.
+func (e *DebugEngine) Run(ctx context.Context, p *core.Pipeline, inputs, outputs map[string]chan interface{}) error {
+	p = withPacketValidation(p)
+	for _, conn := range p.GetConnections() {
+		if conn.Feedback {
+			return fmt.Errorf("the debug engine does not support feedback loops: %s", conn.Name)
+		}
+	}
+	graph := NewGraph(p)
+	sorted, err := graph.TopologicalSort()
+	if err != nil {
+		return fmt.Errorf("error sorting pipeline graph: %w", err)
+	}
+
+	components := p.GetComponents()
+	connections := p.GetConnections()
+	// Packets are tracked per connection so each edge can be inspected and modified independently
+	delivered := make(map[int]interface{})
+
+	for _, name := range sorted {
+		component := components[name]
+		compInputs := make(map[string]interface{})
+		deliveries := make(map[string]string)
+		connected := make(map[string]bool)
+
+		for _, port := range component.InputPorts() {
+			if ch, ok := inputs[port.Name()]; ok {
+				select {
+				case compInputs[port.Name()] = <-ch:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				continue
+			}
+			for i, conn := range connections {
+				if conn.ToComponent == name && conn.ToPort == port.Name() {
+					connected[port.Name()] = true
+					if data, ok := delivered[i]; ok {
+						compInputs[port.Name()] = data
+						deliveries[port.Name()] = conn.Name
+					}
+				}
+			}
+		}
+
+		if skipComponent(component, connected, compInputs) ||
+			(len(connected) == 0 && len(compInputs) == 0 && !admitSource(ctx, p, name)) {
+			markSkipped(p, name)
+			continue
+		}
+
+		stop := &Stop{Reason: StopBeforeComponent, Component: name, Inputs: compInputs}
+		if err := e.pause(ctx, stop, compInputs); err != nil {
+			return err
+		}
+
+		compOutputs, err := invoke(withDeliveries(ctx, deliveries), p, name, component, stop.Inputs)
+		if err != nil {
+			return fmt.Errorf("error executing component %s: %w", name, err)
+		}
+		if compOutputs == nil {
+			compOutputs = make(map[string]interface{})
+		}
+
+		stop = &Stop{Reason: StopAfterComponent, Component: name, Inputs: stop.Inputs, Outputs: compOutputs}
+		if err := e.pause(ctx, stop, compOutputs); err != nil {
+			return err
+		}
+		compOutputs = stop.Outputs
+
+		for portName, outData := range compOutputs {
+			if ch, ok := outputs[portName]; ok {
+				select {
+				case ch <- outData:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			for i := range connections {
+				conn := &connections[i]
+				if conn.FromComponent != name || conn.FromPort != portName {
+					continue
+				}
+				packet, err := deliver(ctx, conn, outData)
+				if err != nil {
+					return err
+				}
+				stop := &Stop{Reason: StopOnConnection, Component: name, Connection: conn, Original: outData, Packet: packet}
+				if err := e.pause(ctx, stop, packet); err != nil {
+					return err
+				}
+				delivered[i] = stop.Packet
+			}
+		}
+	}
+
+	return nil
+}
+
+// pause blocks at a pause point if stepping or a breakpoint matches.
+func (e *DebugEngine) pause(ctx context.Context, stop *Stop, data interface{}) error {
+	e.mu.Lock()
+	bp := e.match(stop, data)
+	shouldStop := e.stepping || bp != nil
+	e.mu.Unlock()
+	if !shouldStop {
+		return nil
+	}
+
+	stop.Breakpoint = bp
+	stop.resume = make(chan debugCommand, 1)
+	select {
+	case e.stops <- stop:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case cmd := <-stop.resume:
+		e.mu.Lock()
+		e.stepping = cmd == commandStep
+		e.mu.Unlock()
+		if cmd == commandAbort {
+			return fmt.Errorf("debug session aborted at %s", stop)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// match returns the first breakpoint matching the pause point. Must be called with e.mu held.
+func (e *DebugEngine) match(stop *Stop, data interface{}) *Breakpoint {
+	for _, bp := range e.breakpoints {
+		if bp.Reason != stop.Reason {
+			continue
+		}
+		if stop.Reason == StopOnConnection {
+			conn := stop.Connection
+			if !matchField(bp.FromComponent, conn.FromComponent) || !matchField(bp.FromPort, conn.FromPort) ||
+				!matchField(bp.ToComponent, conn.ToComponent) || !matchField(bp.ToPort, conn.ToPort) {
+				continue
+			}
+		} else if bp.Component != stop.Component {
+			continue
+		}
+		if bp.Condition != nil && !bp.Condition(data) {
+			continue
+		}
+		return bp
+	}
+	return nil
+}
+
+func matchField(pattern, value string) bool {
+	return pattern == "" || pattern == value
+}
+
+// Close gracefully shuts down the engine.
+func (e *DebugEngine) Close() error {
+	return nil
+}
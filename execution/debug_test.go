@@ -0,0 +1,86 @@
+package execution
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+)
+
+func newDebugTestPipeline() *core.Pipeline {
+	p := core.NewPipeline("debug_test")
+	p.AddComponent("source", components.NewStringSource("hello"))
+	p.AddComponent("upper", components.NewUpperCase())
+	p.AddComponent("grep", components.NewGrep("X"))
+	core.Connect[string](p, "source", "output", "upper", "input")
+	core.Connect[string](p, "upper", "output", "grep", "input")
+	return p
+}
+
+func TestDebugEngineBreakpoints(t *testing.T) {
+	p := newDebugTestPipeline()
+	engine := NewDebugEngine(false)
+	engine.BreakBefore("upper", nil)
+	engine.BreakOnConnection("upper", "output", "", "", func(data interface{}) bool {
+		return strings.HasPrefix(data.(string), "HI")
+	})
+
+	outputs := map[string]chan interface{}{"output": make(chan interface{}, 3)}
+	done := make(chan error, 1)
+	go func() { done <- engine.Run(context.Background(), p, nil, outputs) }()
+
+	stop := <-engine.Stops()
+	if stop.Reason != StopBeforeComponent || stop.Component != "upper" {
+		t.Fatalf("Expected to stop before upper, got %s", stop)
+	}
+	if stop.Inputs["input"] != "hello" {
+		t.Errorf("Expected input 'hello', got %v", stop.Inputs["input"])
+	}
+	stop.Inputs["input"] = "hi"
+	stop.Continue()
+
+	stop = <-engine.Stops()
+	if stop.Reason != StopOnConnection || stop.Packet != "HI" {
+		t.Fatalf("Expected conditional connection stop with packet 'HI', got %s", stop)
+	}
+	stop.SetPacket("HI\nXYZ")
+	stop.Continue()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// External outputs are keyed by port name, so every "output" lands here and grep's result is last
+	var last interface{}
+	for len(outputs["output"]) > 0 {
+		last = <-outputs["output"]
+	}
+	if last != "XYZ" {
+		t.Errorf("Expected modified packet to reach grep, got %v", last)
+	}
+}
+
+func TestDebugEngineStepAndAbort(t *testing.T) {
+	p := newDebugTestPipeline()
+	engine := NewDebugEngine(true)
+
+	done := make(chan error, 1)
+	go func() { done <- engine.Run(context.Background(), p, nil, nil) }()
+
+	stop := <-engine.Stops()
+	if stop.Reason != StopBeforeComponent || stop.Component != "source" {
+		t.Fatalf("Expected to stop before source, got %s", stop)
+	}
+	stop.Step()
+
+	stop = <-engine.Stops()
+	if stop.Reason != StopAfterComponent || stop.Outputs["output"] != "hello" {
+		t.Fatalf("Expected to stop after source with output, got %s", stop)
+	}
+	stop.Abort()
+
+	if err := <-done; err == nil {
+		t.Error("Expected aborted run to return an error")
+	}
+}
@@ -24,52 +24,38 @@ func NewDefaultEngine() *DefaultEngine {
 	return &DefaultEngine{}
 }
 
-// Run executes the pipeline sequentially.
+// Run executes the pipeline sequentially. Each feedback loop runs as a whole,
+// one pass at a time, until its entry stops feeding the loop body.
 func (e *DefaultEngine) Run(ctx context.Context, p *core.Pipeline, inputs, outputs map[string]chan interface{}) error {
-	graph := NewGraph(p)
-	sorted, err := graph.TopologicalSort()
+	p = withPacketValidation(p)
+	loops, err := findLoops(p)
+	if err != nil {
+		return err
+	}
+	sorted, err := schedule(p, loops)
 	if err != nil {
 		return fmt.Errorf("error sorting pipeline graph: %w", err)
 	}
 
 	fmt.Println("Running pipeline sequentially:")
-	components := p.GetComponents()
-	connections := p.GetConnections()
-	data := make(map[string]interface{})
+	s := newSequence(p, inputs, outputs)
+	s.observe = func(name string, ran bool) {
+		if ran {
+			fmt.Printf("Executing component: %s\n", name)
+		} else {
+			fmt.Printf("Skipping component: %s\n", name)
+		}
+	}
 
 	for _, name := range sorted {
-		component := components[name]
-		compInputs := make(map[string]interface{})
-
-		for _, port := range component.InputPorts() {
-			// Check for external inputs
-			if ch, ok := inputs[port.Name()]; ok {
-				compInputs[port.Name()] = <-ch
-				continue
+		if loop, ok := loops[name]; ok {
+			if err := s.runLoop(ctx, loop); err != nil {
+				return err
 			}
-			// Check for internal connections
-			for _, conn := range connections {
-				if conn.ToComponent == name && conn.ToPort == port.Name() {
-					dataKey := fmt.Sprintf("%s.%s", conn.FromComponent, conn.FromPort)
-					compInputs[port.Name()] = data[dataKey]
-				}
-			}
-		}
-
-		fmt.Printf("Executing component: %s\n", component.Name())
-		compOutputs, err := component.Process(ctx, compInputs)
-		if err != nil {
-			return fmt.Errorf("error executing component %s: %w", component.Name(), err)
+			continue
 		}
-
-		for portName, outData := range compOutputs {
-			dataKey := fmt.Sprintf("%s.%s", name, portName)
-			data[dataKey] = outData
-
-			// Check for external outputs
-			if ch, ok := outputs[portName]; ok {
-				ch <- outData
-			}
+		if _, _, err := s.fire(ctx, name, acceptAll); err != nil {
+			return err
 		}
 	}
 
@@ -82,6 +68,36 @@ func (e *DefaultEngine) Close() error {
 	return nil
 }
 
+// slots limits how many components of a run process at once. A nil slots
+// does not limit.
+type slots chan struct{}
+
+func newSlots(config *core.PipelineConfig) slots {
+	if config == nil || config.MaxConcurrency <= 0 {
+		return nil
+	}
+	return make(slots, config.MaxConcurrency)
+}
+
+// acquire waits for a free slot. It reports false if ctx is done first.
+func (s slots) acquire(ctx context.Context) bool {
+	if s == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case s <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s slots) release() {
+	if s != nil {
+		<-s
+	}
+}
+
 // ConcurrentEngine executes the pipeline with concurrency.
 type ConcurrentEngine struct{}
 
@@ -90,94 +106,255 @@ func NewConcurrentEngine() *ConcurrentEngine {
 	return &ConcurrentEngine{}
 }
 
-// Run executes the pipeline with concurrency.
+// Run executes the pipeline with concurrency. Every connection has its own
+// channel of Connection.Capacity packets, so an output port may feed several
+// inputs. At most MaxConcurrency components process at once; components
+// waiting for inputs or sending outputs do not count. A component whose
+// upstream produced nothing is skipped and in turn produces nothing. Each
+// feedback loop runs as one unit: it waits for its inputs from outside the
+// loop, iterates like the DefaultEngine and then sends its results. The first
+// error cancels the remaining components and is returned once all of them
+// have stopped. If the pipeline configures a watchdog, stalls are reported
+// with a wait-for diagnostic and optionally abort the run.
 func (e *ConcurrentEngine) Run(ctx context.Context, p *core.Pipeline, inputs, outputs map[string]chan interface{}) error {
 	fmt.Println("Running pipeline concurrently:")
+	p = withPacketValidation(p)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
 	components := p.GetComponents()
 	connections := p.GetConnections()
-	channels := make(map[string]chan interface{})
-	errCh := make(chan error, len(components))
+	loops, err := findLoops(p)
+	if err != nil {
+		return err
+	}
+	// Each loop runs in the goroutine of its entry
+	owner := make(map[string]string)
+	for entry, loop := range loops {
+		for name := range loop.members {
+			owner[name] = entry
+		}
+	}
+
+	// Create a channel for every internal connection
+	channels := make([]chan interface{}, len(connections))
+	for i, conn := range connections {
+		channels[i] = make(chan interface{}, conn.Capacity(p.GetConfig()))
+	}
+	slots := newSlots(p.GetConfig())
 
-	// Create channels for all internal connections
-	for _, conn := range connections {
-		channels[fmt.Sprintf("%s.%s", conn.FromComponent, conn.FromPort)] = make(chan interface{})
+	// Watch for stalls while the components run
+	tracker := newWaitTracker(p.GetConfig())
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watching := make(chan struct{})
+	if tracker != nil {
+		go func() {
+			defer close(watching)
+			tracker.watch(watchCtx, p, channels, fail)
+		}()
+	} else {
+		close(watching)
 	}
 
 	// Start each component in a goroutine
 	for name, component := range components {
+		if entry, ok := owner[name]; ok && entry != name {
+			continue
+		}
 		wg.Add(1)
 		go func(name string, component core.Component) {
 			defer wg.Done()
+			defer tracker.done(name)
+			// Closing the outgoing channels tells consumers that nothing more will arrive
+			defer func() {
+				for i, conn := range connections {
+					if conn.FromComponent == name || owner[conn.FromComponent] == name {
+						close(channels[i])
+					}
+				}
+			}()
+
+			if loop, ok := loops[name]; ok {
+				if err := runLoopRegion(ctx, p, loop, channels, slots, tracker, inputs, outputs); err != nil && ctx.Err() == nil {
+					core.ComponentErrors.WithLabelValues(name).Inc()
+					fail(err)
+				}
+				return
+			}
 
 			compInputs := make(map[string]interface{})
+			deliveries := make(map[string]string)
+			connected := make(map[string]bool)
 			for _, port := range component.InputPorts() {
-				var data interface{}
 				// Check if this is an external input
 				if ch, ok := inputs[port.Name()]; ok {
-					data = <-ch
-				} else {
-					// Check if this is an internal connection
-					for _, conn := range connections {
-						if conn.ToComponent == name && conn.ToPort == port.Name() {
-							chName := fmt.Sprintf("%s.%s", conn.FromComponent, conn.FromPort)
-							var ok bool
-							data, ok = <-channels[chName]
-							if !ok {
-								errCh <- fmt.Errorf("channel closed for %s.%s", name, port.Name())
-								return
-							}
+					tracker.set(name, core.WaitKindExternalInput, -1, port.Name())
+					select {
+					case data := <-ch:
+						compInputs[port.Name()] = data
+					case <-ctx.Done():
+						return
+					}
+					tracker.moved()
+					continue
+				}
+				// Check if this is an internal connection
+				for i, conn := range connections {
+					if conn.ToComponent != name || conn.ToPort != port.Name() {
+						continue
+					}
+					connected[port.Name()] = true
+					tracker.set(name, core.WaitKindReceive, i, "")
+					select {
+					case data, ok := <-channels[i]:
+						if ok {
+							compInputs[port.Name()] = data
+							deliveries[port.Name()] = conn.Name
 						}
+					case <-ctx.Done():
+						return
 					}
+					tracker.moved()
 				}
-				compInputs[port.Name()] = data
 			}
 
+			if skipComponent(component, connected, compInputs) {
+				markSkipped(p, name)
+				return
+			}
+			if len(connected) == 0 && len(compInputs) == 0 && !admitSource(ctx, p, name) {
+				markSkipped(p, name)
+				return
+			}
+
+			tracker.set(name, core.WaitKindProcessing, -1, "")
+			if !slots.acquire(ctx) {
+				return
+			}
 			timer := prometheus.NewTimer(core.ComponentLatency.WithLabelValues(name))
-			compOutputs, err := component.Process(ctx, compInputs)
+			compOutputs, err := invoke(withDeliveries(ctx, deliveries), p, name, component, compInputs)
 			timer.ObserveDuration()
+			slots.release()
+			tracker.moved()
 			if err != nil {
 				core.ComponentErrors.WithLabelValues(name).Inc()
-				errCh <- fmt.Errorf("error executing component %s: %w", name, err)
+				fail(fmt.Errorf("error executing component %s: %w", name, err))
 				return
 			}
 
 			for portName, data := range compOutputs {
 				// Check if this is an external output
 				if ch, ok := outputs[portName]; ok {
-					ch <- data
-					continue
+					tracker.set(name, core.WaitKindExternalOutput, -1, portName)
+					select {
+					case ch <- data:
+					case <-ctx.Done():
+						return
+					}
+					tracker.moved()
 				}
-				// Check if this is an internal connection
-				chName := fmt.Sprintf("%s.%s", name, portName)
-				if ch, ok := channels[chName]; ok {
-					ch <- data
+				// Deliver to every internal connection from this port
+				for i, conn := range connections {
+					if conn.FromComponent != name || conn.FromPort != portName {
+						continue
+					}
+					packet, err := deliver(ctx, &conn, data)
+					if err != nil {
+						fail(err)
+						return
+					}
+					tracker.set(name, core.WaitKindSend, i, "")
+					select {
+					case channels[i] <- packet:
+					case <-ctx.Done():
+						return
+					}
+					tracker.moved()
 				}
 			}
-
 		}(name, component)
 	}
 
-	go func() {
-		wg.Wait()
-		close(errCh)
-		for _, ch := range channels {
-			close(ch)
-		}
-	}()
+	wg.Wait()
+	stopWatching()
+	<-watching
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fmt.Println("Pipeline execution complete.")
+	return nil
+}
 
-	// Collect errors
-	for err := range errCh {
+// deliver prepares a packet for a connection: it migrates the packet to the
+// schema of the input port and then applies the connection's transform.
+func deliver(ctx context.Context, conn *core.Connection, data interface{}) (interface{}, error) {
+	var err error
+	if len(conn.Migration) > 0 {
+		data, err = conn.Migration.Apply(data)
 		if err != nil {
-			// For now, we just return the first error.
-			// A more advanced implementation could handle multiple errors.
-			return err
+			return nil, fmt.Errorf("error migrating packet on %s: %w", conn.Name, err)
 		}
 	}
+	if conn.Transform != nil {
+		data, err = conn.Transform.Transform(ctx, data)
+		if err != nil {
+			return nil, fmt.Errorf("error applying transform %s on %s: %w", conn.Transform.Name(), conn.Name, err)
+		}
+	}
+	return data, nil
+}
 
-	fmt.Println("Pipeline execution complete.")
-	return nil
+// skipComponent reports whether a component must be skipped because its
+// upstream produced no data: a connected required input is missing, or none
+// of its connected inputs received anything. Components run with partial
+// inputs as long as the missing ports are optional.
+func skipComponent(component core.Component, connected map[string]bool, inputs map[string]interface{}) bool {
+	if len(connected) == 0 {
+		return false
+	}
+	received := false
+	for _, port := range component.InputPorts() {
+		if !connected[port.Name()] {
+			continue
+		}
+		if _, ok := inputs[port.Name()]; ok {
+			received = true
+		} else if port.Required() {
+			return true
+		}
+	}
+	return !received
+}
+
+// admitSource waits while the run is paused before a source emits. It
+// reports false if the run was stopped, in which case the source must not
+// emit.
+func admitSource(ctx context.Context, p *core.Pipeline, name string) bool {
+	control := core.RunControlFromContext(ctx)
+	if control.Paused() {
+		p.GetContext().SetComponentState(name, core.ComponentStatePaused)
+	}
+	return control.Admit(ctx)
+}
+
+// markSkipped records that a component was skipped.
+func markSkipped(p *core.Pipeline, name string) {
+	p.GetContext().SetComponentState(name, core.ComponentStateSkipped)
 }
 
 // Close gracefully shuts down the engine.
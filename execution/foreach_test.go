@@ -0,0 +1,147 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// scaleTemplate multiplies its "n" input by its "factor" input and fails for
+// negative numbers. running counts the instances processing at once and peak
+// the highest count seen.
+func scaleTemplate(running, peak *int32) *core.Template {
+	return core.NewTemplate("scale", func(p *core.Pipeline, params map[string]interface{}) error {
+		p.AddComponent("scale", core.Func2("scale", func(ctx context.Context, n, factor int) (int, error) {
+			now := atomic.AddInt32(running, 1)
+			defer atomic.AddInt32(running, -1)
+			for {
+				old := atomic.LoadInt32(peak)
+				if now <= old || atomic.CompareAndSwapInt32(peak, old, now) {
+					break
+				}
+			}
+			// Later elements finish first, so the order of results is not the order of completion
+			time.Sleep(time.Duration(10-n%10) * time.Millisecond)
+			if n < 0 {
+				return 0, fmt.Errorf("negative input %d", n)
+			}
+			return n * factor, nil
+		}))
+		p.Expose("n", "scale", "input1").Expose("factor", "scale", "input2").Expose("out", "scale", "output")
+		p.SetEngine(NewDefaultEngine())
+		return nil
+	})
+}
+
+func newForEach(config core.ForEachConfig) (*core.ForEach, *int32) {
+	var running, peak int32
+	config.Element = "n"
+	return core.NewForEachTemplate(scaleTemplate(&running, &peak), nil, config), &peak
+}
+
+func TestForEach(t *testing.T) {
+	forEach, peak := newForEach(core.ForEachConfig{Parallelism: 2})
+	if err := forEach.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := newValueComponent(reflect.TypeOf([]int(nil)), nil)
+	p := core.NewPipeline("batch")
+	p.AddComponent("items", newValueComponent(nil, []int{1, 2, 3, 4, 5, 6}))
+	p.AddComponent("factor", newValueComponent(nil, 10))
+	p.AddComponent("each", forEach)
+	p.AddComponent("sink", sink)
+	core.Connect[[]int](p, "items", "output", "each", "items")
+	core.Connect[int](p, "factor", "output", "each", "factor")
+	core.Connect[[]int](p, "each", "results", "sink", "input")
+	if errs := p.Errors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	if err := runWithTimeout(NewConcurrentEngine(), p); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{[]int{10, 20, 30, 40, 50, 60}}; !reflect.DeepEqual(sink.received, want) {
+		t.Errorf("Expected ordered results %v, got %v", want, sink.received)
+	}
+	if *peak != 2 {
+		t.Errorf("Expected at most 2 instances at once, saw %d", *peak)
+	}
+}
+
+func TestForEachFailurePolicies(t *testing.T) {
+	inputs := map[string]interface{}{"items": []int{1, -2, 3, -4}, "factor": 2}
+
+	t.Run("fail fast", func(t *testing.T) {
+		forEach, _ := newForEach(core.ForEachConfig{Parallelism: 1})
+		_, err := forEach.Process(context.Background(), inputs)
+		var pipelineErr core.PipelineError
+		if !errors.As(err, &pipelineErr) || pipelineErr.Context()["index"] != 1 {
+			t.Fatalf("Expected element 1 to fail the ForEach, got %v", err)
+		}
+		if failed := pipelineErr.Context()["failed"]; failed != 1 {
+			t.Errorf("Expected the remaining elements to be cancelled, got %v failures", failed)
+		}
+	})
+
+	t.Run("fail at end", func(t *testing.T) {
+		forEach, _ := newForEach(core.ForEachConfig{Parallelism: 4, Policy: core.ForEachPolicyFailAtEnd})
+		_, err := forEach.Process(context.Background(), inputs)
+		if err == nil || !strings.Contains(err.Error(), "2 elements failed") || !strings.Contains(err.Error(), "negative input") {
+			t.Fatalf("Expected both failures to be reported, got %v", err)
+		}
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		forEach, _ := newForEach(core.ForEachConfig{Policy: core.ForEachPolicyContinue})
+		outputs, err := forEach.Process(context.Background(), inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{2, 0, 6, 0}; !reflect.DeepEqual(outputs["results"], want) {
+			t.Errorf("Expected %v, got %v", want, outputs["results"])
+		}
+		elementErrors, _ := outputs["errors"].([]core.ElementError)
+		if len(elementErrors) != 2 || elementErrors[0].Index != 1 || elementErrors[1].Element != -4 {
+			t.Errorf("Expected errors for elements 1 and 3, got %v", elementErrors)
+		}
+	})
+}
+
+func TestForEachNesting(t *testing.T) {
+	sub := core.NewPipeline("double")
+	sub.AddComponent("double", core.Func1("double", func(ctx context.Context, n int) (int, error) { return n * 2, nil }))
+
+	inner := core.NewPipeline("inner")
+	inner.AddComponent("each", core.NewForEach(sub, core.ForEachConfig{}))
+	outputs, err := inner.Process(context.Background(), map[string]interface{}{"items": []int{1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 4, 6}; !reflect.DeepEqual(outputs["results"], want) {
+		t.Errorf("Expected %v from the nested ForEach, got %v", want, outputs)
+	}
+
+	outer := core.NewForEach(inner, core.ForEachConfig{Result: "results"})
+	if err := outer.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err = outer.Process(context.Background(), map[string]interface{}{"items": [][]int{{1}, {2, 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]int{{2}, {4, 6}}; !reflect.DeepEqual(outputs["results"], want) {
+		t.Errorf("Expected %v from nested ForEach components, got %v", want, outputs)
+	}
+
+	if err := core.NewForEach(inner, core.ForEachConfig{}).Validate(); err == nil {
+		t.Error("Expected a sub-pipeline with several outputs to need a result port")
+	}
+}
@@ -28,7 +28,11 @@ func NewGraph(p *core.Pipeline) *Graph {
 		g.inDegree[name] = 0
 	}
 
+	// Feedback connections close loops and do not order components
 	for _, conn := range p.GetConnections() {
+		if conn.Feedback {
+			continue
+		}
 		g.edges[conn.FromComponent] = append(g.edges[conn.FromComponent], conn.ToComponent)
 		g.inDegree[conn.ToComponent]++
 	}
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// tally adds up the numbers it receives and emits the running total.
+type tally struct {
+	core.BaseComponent
+	total int
+}
+
+func newTally() *tally {
+	c := &tally{}
+	c.Inputs = []core.Port{&core.BasePort{PortName: "input", PortType: reflect.TypeOf(0), IsRequired: true}}
+	c.Outputs = []core.Port{&core.BasePort{PortName: "output", PortType: reflect.TypeOf(0)}}
+	return c
+}
+
+func (c *tally) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.total += inputs["input"].(int)
+	return map[string]interface{}{"output": c.total}, nil
+}
+
+func (c *tally) Clone() core.Component {
+	return newTally()
+}
+
+func TestConcurrentInstances(t *testing.T) {
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := core.NewPipeline("instances").SetEngine(engine)
+			p.AddComponent("source", newValueComponent(nil, 5))
+			p.AddComponent("tally", newTally())
+			p.AddComponentFactory("sink", func() core.Component {
+				return newValueComponent(reflect.TypeOf(0), nil)
+			})
+			core.Connect[int](p, "source", "output", "tally", "input")
+			core.Connect[int](p, "tally", "output", "sink", "input")
+
+			const runs = 20
+			instances := make([]*core.Pipeline, runs)
+			errs := make([]error, runs)
+			var wg sync.WaitGroup
+			for i := 0; i < runs; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					instances[i], errs[i] = p.RunInstance(context.Background())
+				}(i)
+			}
+			wg.Wait()
+
+			ids := make(map[string]bool)
+			for i, run := range instances {
+				if errs[i] != nil {
+					t.Fatalf("run %d: %v", i, errs[i])
+				}
+				sink := run.GetComponents()["sink"].(*valueComponent)
+				if !reflect.DeepEqual(sink.received, []interface{}{5}) {
+					t.Errorf("run %d: expected its own tally to emit 5, got %v", i, sink.received)
+				}
+				if state := run.GetContext().GetComponentState("sink"); state != core.ComponentStateCompleted {
+					t.Errorf("run %d: expected sink completed, got %v", i, state)
+				}
+				ids[run.GetContext().ExecutionID] = true
+			}
+			if len(ids) != runs {
+				t.Errorf("Expected %d execution IDs, got %d", runs, len(ids))
+			}
+			if p.GetComponents()["tally"].(*tally).total != 0 {
+				t.Error("Expected the pipeline's own components to be untouched")
+			}
+			if len(p.GetContext().ComponentStates) != 0 {
+				t.Errorf("Expected the pipeline's own context to be untouched, got %v", p.GetContext().ComponentStates)
+			}
+		})
+	}
+}
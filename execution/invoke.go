@@ -0,0 +1,111 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// invoke calls a component's Process. A panic is recovered and converted
+// into a Critical PipelineError, so one faulty component cannot crash the
+// host process. Failures are passed to the pipeline's error handler: Retry
+// calls Process again, Continue and Skip drop the component's outputs so its
+// downstream is skipped, and Abort (the default without a handler) returns
+// the error. Retries follow the pipeline's retry policy, or the default one:
+// after MaxRetries retries, or for error types not in RetryableErrors, the
+// error is returned. The component's state records the outcome.
+func invoke(ctx context.Context, p *core.Pipeline, name string, component core.Component, inputs map[string]interface{}) (map[string]interface{}, error) {
+	states := p.GetContext()
+	states.SetComponentState(name, core.ComponentStateRunning)
+
+	var policy *core.RetryPolicy
+	if config := p.GetConfig(); config != nil {
+		policy = config.RetryPolicy
+	}
+	if policy == nil {
+		policy = core.NewDefaultRetryPolicy()
+	}
+	delay := policy.InitialDelay
+	for retries := 0; ; retries++ {
+		outputs, err := safeProcess(ctx, name, component, inputs)
+		if err == nil {
+			states.SetComponentState(name, core.ComponentStateCompleted)
+			return outputs, nil
+		}
+		states.SetComponentState(name, core.ComponentStateError)
+
+		handler := p.ErrorHandler()
+		if handler == nil {
+			return nil, err
+		}
+		pipelineErr := asPipelineError(name, err)
+		switch handler.HandleError(ctx, pipelineErr) {
+		case core.Retry:
+			if retries >= policy.MaxRetries || !retryable(policy, pipelineErr) || !waitRetry(ctx, delay) {
+				return nil, err
+			}
+			delay = nextRetryDelay(policy, delay)
+			states.SetComponentState(name, core.ComponentStateRunning)
+		case core.Continue, core.Skip:
+			p.GetErrorCollector().Collect(pipelineErr)
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+// safeProcess calls Process, converting a panic into an error.
+func safeProcess(ctx context.Context, name string, component core.Component, inputs map[string]interface{}) (outputs map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			outputs, err = nil, core.NewPanicError(name, r)
+		}
+	}()
+	return component.Process(ctx, inputs)
+}
+
+func asPipelineError(name string, err error) core.PipelineError {
+	var pipelineErr core.PipelineError
+	if errors.As(err, &pipelineErr) {
+		return pipelineErr
+	}
+	return core.NewPipelineError(err.Error(), name, core.RuntimeError, core.Error, false).WithOriginalError(err)
+}
+
+// retryable reports whether the policy retries errors of err's type.
+func retryable(policy *core.RetryPolicy, err core.PipelineError) bool {
+	for _, errorType := range policy.RetryableErrors {
+		if err.ErrorType() == errorType {
+			return true
+		}
+	}
+	return false
+}
+
+func waitRetry(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func nextRetryDelay(policy *core.RetryPolicy, delay time.Duration) time.Duration {
+	if policy.BackoffFactor <= 0 {
+		return delay
+	}
+	next := time.Duration(float64(delay) * policy.BackoffFactor)
+	if policy.MaxDelay > 0 && next > policy.MaxDelay {
+		next = policy.MaxDelay
+	}
+	return next
+}
@@ -0,0 +1,165 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// panicComponent panics on its first panics calls and then forwards its input.
+type panicComponent struct {
+	core.BaseComponent
+	panics int32
+	calls  int32
+}
+
+func newPanicComponent(panics int32) *panicComponent {
+	c := &panicComponent{panics: panics}
+	c.Inputs = []core.Port{&core.BasePort{PortName: "input", PortType: reflect.TypeOf(0), IsRequired: true}}
+	c.Outputs = []core.Port{&core.BasePort{PortName: "output", PortType: reflect.TypeOf(0)}}
+	return c
+}
+
+func (c *panicComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if atomic.AddInt32(&c.calls, 1) <= c.panics {
+		var m map[string]int
+		m["boom"]++ // nil map write
+	}
+	return map[string]interface{}{"output": inputs["input"]}, nil
+}
+
+// handlerFunc adapts a function to core.ErrorHandler.
+type handlerFunc func(err core.PipelineError) core.ErrorAction
+
+func (h handlerFunc) HandleError(ctx context.Context, err core.PipelineError) core.ErrorAction {
+	return h(err)
+}
+
+func (h handlerFunc) CanRecover(err core.PipelineError) bool { return true }
+
+// newPanicPipeline builds source -> faulty -> sink next to an independent
+// source -> other branch.
+func newPanicPipeline(faulty core.Component) (*core.Pipeline, *valueComponent, *valueComponent) {
+	sink := newValueComponent(reflect.TypeOf(0), nil)
+	other := newValueComponent(reflect.TypeOf(0), nil)
+	p := core.NewPipeline("panics")
+	p.AddComponent("source", newValueComponent(nil, 1))
+	p.AddComponent("faulty", faulty)
+	p.AddComponent("sink", sink)
+	p.AddComponent("other", other)
+	core.Connect[int](p, "source", "output", "faulty", "input")
+	core.Connect[int](p, "faulty", "output", "sink", "input")
+	core.Connect[int](p, "source", "output", "other", "input")
+	return p, sink, other
+}
+
+func TestPanicIsolation(t *testing.T) {
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			baseline := runtime.NumGoroutine()
+			p, sink, _ := newPanicPipeline(newPanicComponent(1))
+
+			err := engine.Run(context.Background(), p, nil, nil)
+			var pipelineErr core.PipelineError
+			if !errors.As(err, &pipelineErr) {
+				t.Fatalf("Expected a PipelineError, got %v", err)
+			}
+			if pipelineErr.Severity() != core.Critical || pipelineErr.Component() != "faulty" {
+				t.Errorf("Expected a Critical error from faulty, got %s from %s", pipelineErr.Severity(), pipelineErr.Component())
+			}
+			stack, _ := pipelineErr.Context()["stack"].(string)
+			if !strings.Contains(stack, "panicComponent") {
+				t.Errorf("Expected the stack trace in the error context, got %q", stack)
+			}
+			if state := p.GetContext().GetComponentState("faulty"); state != core.ComponentStateError {
+				t.Errorf("Expected faulty to be in state ERROR, got %s", state)
+			}
+			if len(sink.received) != 0 {
+				t.Errorf("The sink should not receive anything, got %v", sink.received)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if n := runtime.NumGoroutine(); n > baseline {
+				t.Errorf("Expected no leaked goroutines, %d running before and %d after", baseline, n)
+			}
+		})
+	}
+}
+
+func TestPanicErrorHandling(t *testing.T) {
+	t.Run("continue", func(t *testing.T) {
+		p, sink, other := newPanicPipeline(newPanicComponent(1))
+		p.SetErrorHandler(handlerFunc(func(err core.PipelineError) core.ErrorAction { return core.Continue }))
+
+		if err := NewConcurrentEngine().Run(context.Background(), p, nil, nil); err != nil {
+			t.Fatalf("Expected the run to continue, got %v", err)
+		}
+		if len(sink.received) != 0 || len(other.received) != 1 {
+			t.Errorf("Expected only the independent branch to run, got sink=%v other=%v", sink.received, other.received)
+		}
+		if errs := p.GetErrorCollector().GetErrorsByComponent("faulty"); len(errs) != 1 || errs[0].Severity() != core.Critical {
+			t.Errorf("Expected the panic to be collected, got %v", errs)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		faulty := newPanicComponent(2)
+		p, sink, _ := newPanicPipeline(faulty)
+		p.SetErrorHandler(handlerFunc(func(err core.PipelineError) core.ErrorAction { return core.Retry }))
+
+		if err := NewDefaultEngine().Run(context.Background(), p, nil, nil); err != nil {
+			t.Fatalf("Expected the retry to succeed, got %v", err)
+		}
+		if faulty.calls != 3 || len(sink.received) != 1 {
+			t.Errorf("Expected 3 calls and one delivered packet, got %d calls and %v", faulty.calls, sink.received)
+		}
+		if state := p.GetContext().GetComponentState("faulty"); state != core.ComponentStateCompleted {
+			t.Errorf("Expected faulty to complete after retrying, got %s", state)
+		}
+	})
+
+	t.Run("retry gives up", func(t *testing.T) {
+		for name, tt := range map[string]struct {
+			retryable []core.ErrorType
+			calls     int32
+		}{
+			"after MaxRetries":      {[]core.ErrorType{core.RuntimeError}, 3},
+			"for other error types": {[]core.ErrorType{core.NetworkError}, 1},
+		} {
+			t.Run(name, func(t *testing.T) {
+				faulty := newPanicComponent(1000)
+				p, sink, _ := newPanicPipeline(faulty)
+				p.GetConfig().RetryPolicy = &core.RetryPolicy{MaxRetries: 2, InitialDelay: time.Millisecond, RetryableErrors: tt.retryable}
+				p.SetErrorHandler(handlerFunc(func(err core.PipelineError) core.ErrorAction { return core.Retry }))
+
+				if err := runWithTimeout(NewDefaultEngine(), p); err == nil || errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("Expected the run to fail with the panic, got %v", err)
+				}
+				if faulty.calls != tt.calls || len(sink.received) != 0 {
+					t.Errorf("Expected %d calls and no packets, got %d calls and %v", tt.calls, faulty.calls, sink.received)
+				}
+			})
+		}
+	})
+
+	t.Run("default handler aborts", func(t *testing.T) {
+		p, _, _ := newPanicPipeline(newPanicComponent(1))
+		p.SetErrorHandler(core.NewDefaultErrorHandler(3))
+		if err := NewConcurrentEngine().Run(context.Background(), p, nil, nil); err == nil {
+			t.Error("Expected Critical panics to abort the run")
+		}
+	})
+}
@@ -0,0 +1,332 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// loopRegion is a feedback loop: the loop boundary at its entry and the body
+// components on the paths from the entry back to its feedback connections.
+type loopRegion struct {
+	entry string
+	// body lists the body components in execution order
+	body    []string
+	members map[string]bool
+	// bodyPorts are the entry's output ports that feed the body
+	bodyPorts map[string]bool
+}
+
+// feedsBody reports whether the entry's outputs start another pass.
+func (l *loopRegion) feedsBody(outputs map[string]interface{}) bool {
+	for port := range outputs {
+		if l.bodyPorts[port] {
+			return true
+		}
+	}
+	return false
+}
+
+// findLoops returns the loop regions of a pipeline by entry component. Loops
+// may not share components.
+func findLoops(p *core.Pipeline) (map[string]*loopRegion, error) {
+	connections := p.GetConnections()
+	forward := make(map[string][]string)
+	reverse := make(map[string][]string)
+	sources := make(map[string][]string)
+	for _, conn := range connections {
+		if conn.Feedback {
+			sources[conn.ToComponent] = append(sources[conn.ToComponent], conn.FromComponent)
+			continue
+		}
+		forward[conn.FromComponent] = append(forward[conn.FromComponent], conn.ToComponent)
+		reverse[conn.ToComponent] = append(reverse[conn.ToComponent], conn.FromComponent)
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	order, err := NewGraph(p).TopologicalSort()
+	if err != nil {
+		return nil, fmt.Errorf("error sorting pipeline graph: %w", err)
+	}
+	entries := make([]string, 0, len(sources))
+	for entry := range sources {
+		entries = append(entries, entry)
+	}
+	sort.Strings(entries)
+
+	loops := make(map[string]*loopRegion, len(entries))
+	owner := make(map[string]string)
+	for _, entry := range entries {
+		downstream := reach(forward, []string{entry})
+		upstream := reach(reverse, sources[entry])
+		loop := &loopRegion{entry: entry, members: map[string]bool{entry: true}, bodyPorts: make(map[string]bool)}
+		for _, name := range order {
+			if name != entry && downstream[name] && upstream[name] {
+				loop.body = append(loop.body, name)
+				loop.members[name] = true
+			}
+		}
+		for name := range loop.members {
+			if other, ok := owner[name]; ok {
+				return nil, fmt.Errorf("loops entered at %s and %s share component %s; nested loops are not supported", other, entry, name)
+			}
+			owner[name] = entry
+		}
+		for _, conn := range connections {
+			if conn.FromComponent == entry && loop.members[conn.ToComponent] {
+				loop.bodyPorts[conn.FromPort] = true
+			}
+		}
+		loops[entry] = loop
+	}
+	return loops, nil
+}
+
+// schedule returns the execution order of a pipeline in which every loop is
+// represented by its entry, so that the loop runs as a whole after all its
+// inputs and before all its consumers.
+func schedule(p *core.Pipeline, loops map[string]*loopRegion) ([]string, error) {
+	unit := make(map[string]string)
+	for entry, loop := range loops {
+		for name := range loop.members {
+			unit[name] = entry
+		}
+	}
+	unitOf := func(name string) string {
+		if entry, ok := unit[name]; ok {
+			return entry
+		}
+		return name
+	}
+
+	g := &Graph{
+		nodes:    make(map[string]core.Component),
+		edges:    make(map[string][]string),
+		inDegree: make(map[string]int),
+	}
+	for name, component := range p.GetComponents() {
+		if unitOf(name) == name {
+			g.nodes[name] = component
+			g.inDegree[name] = 0
+		}
+	}
+	for _, conn := range p.GetConnections() {
+		from, to := unitOf(conn.FromComponent), unitOf(conn.ToComponent)
+		if conn.Feedback || from == to {
+			continue
+		}
+		g.edges[from] = append(g.edges[from], to)
+		g.inDegree[to]++
+	}
+	return g.TopologicalSort()
+}
+
+// reach returns the components reachable from starts in graph, including
+// starts.
+func reach(graph map[string][]string, starts []string) map[string]bool {
+	visited := make(map[string]bool)
+	queue := append([]string(nil), starts...)
+	for _, start := range starts {
+		visited[start] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range graph[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return visited
+}
+
+// sequence runs components one at a time. Packets are kept by connection
+// index until the receiving component runs.
+type sequence struct {
+	p           *core.Pipeline
+	components  map[string]core.Component
+	connections []core.Connection
+	packets     map[int]interface{}
+	external    map[string]interface{}
+	inputs      map[string]chan interface{}
+	outputs     map[string]chan interface{}
+	// observe, if set, is told whether each component runs or is skipped
+	observe func(name string, ran bool)
+}
+
+func newSequence(p *core.Pipeline, inputs, outputs map[string]chan interface{}) *sequence {
+	return &sequence{
+		p:           p,
+		components:  p.GetComponents(),
+		connections: p.GetConnections(),
+		packets:     make(map[int]interface{}),
+		external:    make(map[string]interface{}),
+		inputs:      inputs,
+		outputs:     outputs,
+	}
+}
+
+func acceptAll(core.Connection) bool { return true }
+
+// fire runs a component on the packets waiting on the incoming connections
+// selected by accept and delivers its outputs. It reports whether the
+// component ran: a component whose upstream produced nothing is skipped.
+func (s *sequence) fire(ctx context.Context, name string, accept func(core.Connection) bool) (map[string]interface{}, bool, error) {
+	component := s.components[name]
+	compInputs := make(map[string]interface{})
+	deliveries := make(map[string]string)
+	connected := make(map[string]bool)
+	for _, port := range component.InputPorts() {
+		if ch, ok := s.inputs[port.Name()]; ok {
+			// External inputs are read once, so loop bodies reuse them on every pass
+			key := name + "." + port.Name()
+			if _, ok := s.external[key]; !ok {
+				select {
+				case s.external[key] = <-ch:
+				case <-ctx.Done():
+					return nil, false, ctx.Err()
+				}
+			}
+			compInputs[port.Name()] = s.external[key]
+			continue
+		}
+		for i, conn := range s.connections {
+			if conn.ToComponent != name || conn.ToPort != port.Name() || !accept(conn) {
+				continue
+			}
+			connected[port.Name()] = true
+			if packet, ok := s.packets[i]; ok {
+				compInputs[port.Name()] = packet
+				deliveries[port.Name()] = conn.Name
+			}
+		}
+	}
+
+	if skipComponent(component, connected, compInputs) ||
+		(len(connected) == 0 && len(compInputs) == 0 && !admitSource(ctx, s.p, name)) {
+		markSkipped(s.p, name)
+		if s.observe != nil {
+			s.observe(name, false)
+		}
+		return nil, false, nil
+	}
+	if s.observe != nil {
+		s.observe(name, true)
+	}
+	compOutputs, err := invoke(withDeliveries(ctx, deliveries), s.p, name, component, compInputs)
+	if err != nil {
+		return nil, false, fmt.Errorf("error executing component %s: %w", name, err)
+	}
+
+	for portName, data := range compOutputs {
+		if ch, ok := s.outputs[portName]; ok {
+			select {
+			case ch <- data:
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+		}
+		for i, conn := range s.connections {
+			if conn.FromComponent != name || conn.FromPort != portName {
+				continue
+			}
+			packet, err := deliver(ctx, &conn, data)
+			if err != nil {
+				return nil, false, err
+			}
+			s.packets[i] = packet
+		}
+	}
+	return compOutputs, true, nil
+}
+
+// runLoop runs a loop region. The entry first runs on the packets from
+// outside the loop; then every pass runs the body and hands the feedback
+// packets back to the entry, until the entry stops feeding the body. Packets
+// inside the loop only live for one pass, so a pass that produces no
+// feedback ends the loop without a result. Packets leaving the loop keep the
+// value of the last pass.
+func (s *sequence) runLoop(ctx context.Context, loop *loopRegion) error {
+	outputs, ran, err := s.fire(ctx, loop.entry, func(conn core.Connection) bool { return !conn.Feedback })
+	for err == nil && ran && loop.feedsBody(outputs) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, name := range loop.body {
+			if _, _, err := s.fire(ctx, name, acceptAll); err != nil {
+				return err
+			}
+		}
+		s.discard(loop, func(from string) bool { return from == loop.entry })
+		outputs, ran, err = s.fire(ctx, loop.entry, func(conn core.Connection) bool { return conn.Feedback })
+		s.discard(loop, func(from string) bool { return from != loop.entry })
+	}
+	return err
+}
+
+// discard drops the packets on connections inside the loop whose source
+// matches from.
+func (s *sequence) discard(loop *loopRegion, from func(name string) bool) {
+	for i, conn := range s.connections {
+		if loop.members[conn.ToComponent] && loop.members[conn.FromComponent] && from(conn.FromComponent) {
+			delete(s.packets, i)
+		}
+	}
+}
+
+// runLoopRegion runs a loop region inside a ConcurrentEngine run: it receives
+// the packets entering the loop, iterates the loop sequentially in one slot
+// and then sends the packets leaving it.
+func runLoopRegion(ctx context.Context, p *core.Pipeline, loop *loopRegion, channels []chan interface{}, slots slots, tracker *waitTracker, inputs, outputs map[string]chan interface{}) error {
+	s := newSequence(p, inputs, outputs)
+	for i, conn := range s.connections {
+		if !loop.members[conn.ToComponent] || loop.members[conn.FromComponent] {
+			continue
+		}
+		tracker.set(loop.entry, core.WaitKindReceive, i, "")
+		select {
+		case data, ok := <-channels[i]:
+			if ok {
+				s.packets[i] = data
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		tracker.moved()
+	}
+
+	tracker.set(loop.entry, core.WaitKindProcessing, -1, "")
+	if !slots.acquire(ctx) {
+		return ctx.Err()
+	}
+	err := s.runLoop(ctx, loop)
+	slots.release()
+	if err != nil {
+		return err
+	}
+	tracker.moved()
+
+	for i, conn := range s.connections {
+		if !loop.members[conn.FromComponent] || loop.members[conn.ToComponent] {
+			continue
+		}
+		packet, ok := s.packets[i]
+		if !ok {
+			continue
+		}
+		tracker.set(loop.entry, core.WaitKindSend, i, "")
+		select {
+		case channels[i] <- packet:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		tracker.moved()
+	}
+	return nil
+}
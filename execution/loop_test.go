@@ -0,0 +1,103 @@
+package execution
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// newCountingLoop builds source -> loop -> add -> loop, where add adds a step
+// from outside the loop and reports every pass to trace.
+func newCountingLoop(maxIterations int, keep func(n int) bool) (*core.Pipeline, *core.LoopEntry, *valueComponent, *valueComponent) {
+	loop := core.NewLoopEntry(maxIterations, func(previous, current int) bool { return current >= 10 })
+	sink := newValueComponent(reflect.TypeOf(0), nil)
+	trace := newValueComponent(reflect.TypeOf(0), nil)
+	p := core.NewPipeline("counting")
+	p.GetConfig().AllowCycles = true
+	p.AddComponent("start", newValueComponent(nil, 1))
+	p.AddComponent("step", newValueComponent(nil, 3))
+	p.AddComponent("loop", loop)
+	p.AddComponent("add", core.FuncPorts("add", func(ctx context.Context, in struct {
+		N    int `port:"n"`
+		Step int `port:"step"`
+	}) (struct {
+		Sum *int `port:"sum"`
+	}, error) {
+		var out struct {
+			Sum *int `port:"sum"`
+		}
+		if sum := in.N + in.Step; keep(sum) {
+			out.Sum = &sum
+		}
+		return out, nil
+	}))
+	p.AddComponent("deref", core.Func1("deref", func(ctx context.Context, n *int) (int, error) { return *n, nil }))
+	p.AddComponent("sink", sink)
+	p.AddComponent("trace", trace)
+	core.Connect[int](p, "start", "output", "loop", "init")
+	core.Connect[int](p, "loop", "body", "add", "n")
+	core.Connect[int](p, "step", "output", "add", "step")
+	core.Connect[*int](p, "add", "sum", "deref", "input")
+	core.Connect[int](p, "deref", "output", "loop", "feedback")
+	core.Connect[int](p, "deref", "output", "trace", "input")
+	core.Connect[int](p, "loop", "done", "sink", "input")
+	return p, loop, sink, trace
+}
+
+func TestFeedbackLoops(t *testing.T) {
+	keepAll := func(int) bool { return true }
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, loop, sink, trace := newCountingLoop(10, keepAll)
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			if err := runWithTimeout(engine, p); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sink.received, []interface{}{10}) || loop.Iterations() != 3 || !loop.Converged() {
+				t.Errorf("Expected 10 after 3 converging iterations, got %v after %d", sink.received, loop.Iterations())
+			}
+			if !reflect.DeepEqual(trace.received, []interface{}{10}) {
+				t.Errorf("Expected the packet leaving the body to keep the last pass, got %v", trace.received)
+			}
+
+			p, loop, sink, _ = newCountingLoop(2, keepAll)
+			if err := runWithTimeout(engine, p); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sink.received, []interface{}{7}) || loop.Converged() {
+				t.Errorf("Expected the iteration limit to stop at 7, got %v", sink.received)
+			}
+
+			p, _, sink, _ = newCountingLoop(10, func(n int) bool { return n < 5 })
+			if err := runWithTimeout(engine, p); err != nil {
+				t.Fatal(err)
+			}
+			if len(sink.received) != 0 {
+				t.Errorf("Expected a pass without feedback to end the loop without a result, got %v", sink.received)
+			}
+		})
+	}
+}
+
+func TestDebugEngineRejectsFeedbackLoops(t *testing.T) {
+	p, _, _, _ := newCountingLoop(10, func(int) bool { return true })
+	err := NewDebugEngine(false).Run(context.Background(), p, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "feedback loops") {
+		t.Errorf("Expected the debug engine to reject the loop, got %v", err)
+	}
+}
+
+func runWithTimeout(engine core.ExecutionEngine, p *core.Pipeline) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return engine.Run(ctx, p, nil, nil)
+}
@@ -0,0 +1,77 @@
+package execution
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/forrest/go-flow/core"
+)
+
+type celsius float64
+type kelvin float64
+
+// valueComponent emits a fixed value and records what it receives.
+type valueComponent struct {
+	core.BaseComponent
+	emit interface{}
+
+	mu       sync.Mutex
+	received []interface{}
+}
+
+func newValueComponent(input reflect.Type, emit interface{}) *valueComponent {
+	c := &valueComponent{emit: emit}
+	if input != nil {
+		c.Inputs = []core.Port{&core.BasePort{PortName: "input", PortType: input, IsRequired: true}}
+	}
+	if emit != nil {
+		c.Outputs = []core.Port{&core.BasePort{PortName: "output", PortType: reflect.TypeOf(emit)}}
+	}
+	return c
+}
+
+func (c *valueComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := inputs["input"]; ok {
+		c.received = append(c.received, v)
+	}
+	if c.emit == nil {
+		return nil, nil
+	}
+	return map[string]interface{}{"output": c.emit}, nil
+}
+
+func TestSchemaMigrationAtRuntime(t *testing.T) {
+	migrations := core.NewSchemaMigrationRegistry()
+	if err := core.RegisterTypeMigration(migrations, func(c celsius) (kelvin, error) {
+		return kelvin(c + 273.15), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			sink := newValueComponent(reflect.TypeOf(kelvin(0)), nil)
+			p := core.NewPipeline("migration_runtime").SetSchemaMigrations(migrations)
+			p.AddComponent("sensor", newValueComponent(nil, celsius(20)))
+			p.AddComponent("sink", sink)
+			core.Connect[celsius](p, "sensor", "output", "sink", "input")
+			if errs := p.Errors(); len(errs) > 0 {
+				t.Fatalf("Unexpected construction errors: %v", errs)
+			}
+
+			if err := engine.Run(context.Background(), p, nil, nil); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if len(sink.received) != 1 || sink.received[0] != kelvin(293.15) {
+				t.Errorf("Expected the sink to receive kelvin(293.15), got %v", sink.received)
+			}
+		})
+	}
+}
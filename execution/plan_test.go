@@ -0,0 +1,49 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// TestConcurrentEngineFollowsPlan checks that the ConcurrentEngine runs no
+// more components at once than the stage parallelism of the plan.
+func TestConcurrentEngineFollowsPlan(t *testing.T) {
+	config := core.NewDefaultPipelineConfig()
+	config.MaxConcurrency = 2
+	p := core.NewPipelineWithConfig("planned", config).SetEngine(NewConcurrentEngine())
+
+	var running, peak int32
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("source%d", i)
+		core.AddSource(p, name, func(ctx context.Context) (int, error) {
+			now := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return i, nil
+		})
+	}
+	plan, err := p.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Stages[0].Parallelism != 2 {
+		t.Fatalf("Expected the plan to cap parallelism at 2, got %d", plan.Stages[0].Parallelism)
+	}
+	if err := p.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&peak); got > 2 {
+		t.Errorf("Expected at most 2 components processing at once, got %d", got)
+	}
+}
@@ -0,0 +1,479 @@
+package execution
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// EventKind identifies what a recorded event captured.
+type EventKind int
+
+const (
+	// EventExternalInput is a value read from one of the inputs channels passed to Run.
+	EventExternalInput EventKind = iota
+	// EventPacket is a value delivered to a component input port over a connection.
+	EventPacket
+	// EventComponentOutput is a value returned by a component on one of its output ports.
+	EventComponentOutput
+	// EventComponentError is an error returned by a component.
+	EventComponentError
+	// EventExternalOutput is a value written to one of the outputs channels passed to Run.
+	EventExternalOutput
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventExternalInput:
+		return "EXTERNAL_INPUT"
+	case EventPacket:
+		return "PACKET"
+	case EventComponentOutput:
+		return "COMPONENT_OUTPUT"
+	case EventComponentError:
+		return "COMPONENT_ERROR"
+	case EventExternalOutput:
+		return "EXTERNAL_OUTPUT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ValueEncoder converts packet values to and from bytes for recordings.
+// Custom types that the default encoder cannot handle need their own encoder.
+type ValueEncoder interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// GobValueEncoder encodes values with encoding/gob. Basic Go types work out of
+// the box; custom types must be registered with gob.Register.
+type GobValueEncoder struct{}
+
+// Encode encodes a value with gob.
+func (GobValueEncoder) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a value encoded by Encode.
+func (GobValueEncoder) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// RecordedEvent is a single entry of a Recording.
+type RecordedEvent struct {
+	Kind       EventKind
+	Component  string
+	Port       string
+	Connection string
+	// Invocation counts Process calls of the component, starting at zero.
+	Invocation int
+	// Offset is the time elapsed since the start of the run.
+	Offset time.Duration
+	Data   []byte
+	Nil    bool
+	Error  string
+}
+
+// Recording holds every event captured during one pipeline run.
+type Recording struct {
+	Pipeline  string
+	StartTime time.Time
+	Events    []RecordedEvent
+}
+
+// WriteTo writes the recording in its compact gzip-compressed gob form.
+func (r *Recording) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	zw := gzip.NewWriter(counter)
+	if err := gob.NewEncoder(zw).Encode(r); err != nil {
+		return counter.n, err
+	}
+	err := zw.Close()
+	return counter.n, err
+}
+
+// Save writes the recording to a file.
+func (r *Recording) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadRecording reads a recording written by WriteTo.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recording: %w", err)
+	}
+	defer zr.Close()
+	var rec Recording
+	if err := gob.NewDecoder(zr).Decode(&rec); err != nil {
+		return nil, fmt.Errorf("error decoding recording: %w", err)
+	}
+	return &rec, nil
+}
+
+// LoadRecording reads a recording from a file.
+func LoadRecording(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
+
+// Filter returns the events of the given kind, optionally restricted to a component.
+func (r *Recording) Filter(kind EventKind, component string) []RecordedEvent {
+	var result []RecordedEvent
+	for _, ev := range r.Events {
+		if ev.Kind == kind && (component == "" || ev.Component == component) {
+			result = append(result, ev)
+		}
+	}
+	return result
+}
+
+// DecodeValue decodes the value of an event.
+func DecodeValue(ev RecordedEvent, encoder ValueEncoder) (interface{}, error) {
+	if ev.Nil {
+		return nil, nil
+	}
+	return encoder.Decode(ev.Data)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// RecordingEngine wraps another engine and records every external input,
+// packet, component output and external output of a run.
+type RecordingEngine struct {
+	inner   core.ExecutionEngine
+	encoder ValueEncoder
+
+	mu        sync.Mutex
+	recording *Recording
+	encodeErr error
+}
+
+// NewRecordingEngine creates a RecordingEngine around inner. A nil encoder
+// selects GobValueEncoder.
+func NewRecordingEngine(inner core.ExecutionEngine, encoder ValueEncoder) *RecordingEngine {
+	if encoder == nil {
+		encoder = GobValueEncoder{}
+	}
+	return &RecordingEngine{inner: inner, encoder: encoder}
+}
+
+// Recording returns the recording of the most recent run.
+func (e *RecordingEngine) Recording() *Recording {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.recording
+}
+
+// Run executes the pipeline on the inner engine while recording its data flow.
+func (e *RecordingEngine) Run(ctx context.Context, p *core.Pipeline, inputs, outputs map[string]chan interface{}) error {
+	start := time.Now()
+	e.mu.Lock()
+	e.recording = &Recording{Pipeline: p.Name(), StartTime: start}
+	e.encodeErr = nil
+	e.mu.Unlock()
+
+	recorded := p.Decorate(func(name string, component core.Component) core.Component {
+		return &recordingComponent{Component: component, engine: e, name: name}
+	})
+
+	finished := make(chan struct{})
+	var forwarders sync.WaitGroup
+
+	proxyInputs := make(map[string]chan interface{}, len(inputs))
+	for port, ch := range inputs {
+		// Unbuffered so that only values the engine actually consumes are recorded
+		proxy := make(chan interface{})
+		proxyInputs[port] = proxy
+		forwarders.Add(1)
+		go func(port string, ch, proxy chan interface{}) {
+			defer forwarders.Done()
+			for {
+				select {
+				case v, ok := <-ch:
+					if !ok {
+						close(proxy)
+						return
+					}
+					select {
+					case proxy <- v:
+						e.record(RecordedEvent{Kind: EventExternalInput, Port: port}, v, nil)
+					case <-finished:
+						return
+					}
+				case <-finished:
+					return
+				}
+			}
+		}(port, ch, proxy)
+	}
+
+	proxyOutputs := make(map[string]chan interface{}, len(outputs))
+	for port, ch := range outputs {
+		proxy := make(chan interface{}, cap(ch))
+		proxyOutputs[port] = proxy
+		forwarders.Add(1)
+		go func(port string, ch, proxy chan interface{}) {
+			defer forwarders.Done()
+			forward := func(v interface{}) {
+				e.record(RecordedEvent{Kind: EventExternalOutput, Port: port}, v, nil)
+				ch <- v
+			}
+			for {
+				select {
+				case v := <-proxy:
+					forward(v)
+				case <-finished:
+					// The engine has returned; deliver whatever it already sent
+					for {
+						select {
+						case v := <-proxy:
+							forward(v)
+						default:
+							return
+						}
+					}
+				}
+			}
+		}(port, ch, proxy)
+	}
+
+	err := e.inner.Run(ctx, recorded, proxyInputs, proxyOutputs)
+	close(finished)
+	forwarders.Wait()
+
+	e.mu.Lock()
+	encodeErr := e.encodeErr
+	e.mu.Unlock()
+	if err == nil && encodeErr != nil {
+		return fmt.Errorf("error recording pipeline run: %w", encodeErr)
+	}
+	return err
+}
+
+// Close closes the inner engine.
+func (e *RecordingEngine) Close() error {
+	return e.inner.Close()
+}
+
+func (e *RecordingEngine) record(ev RecordedEvent, value interface{}, procErr error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.recording == nil {
+		return
+	}
+	ev.Offset = time.Since(e.recording.StartTime)
+	switch {
+	case procErr != nil:
+		ev.Error = procErr.Error()
+	case value == nil:
+		ev.Nil = true
+	default:
+		data, err := e.encoder.Encode(value)
+		if err != nil {
+			if e.encodeErr == nil {
+				e.encodeErr = fmt.Errorf("cannot encode %T for %s %s.%s: %w", value, ev.Kind, ev.Component, ev.Port, err)
+			}
+			return
+		}
+		ev.Data = data
+	}
+	e.recording.Events = append(e.recording.Events, ev)
+}
+
+type deliveriesKey struct{}
+
+// withDeliveries returns a context that tells the component which connection
+// delivered the packet on each input port. Engines set it before invoke, so
+// recordings name the connection a packet actually came through.
+func withDeliveries(ctx context.Context, deliveries map[string]string) context.Context {
+	return context.WithValue(ctx, deliveriesKey{}, deliveries)
+}
+
+// deliveriesFrom returns the connections by input port set by withDeliveries.
+func deliveriesFrom(ctx context.Context) map[string]string {
+	deliveries, _ := ctx.Value(deliveriesKey{}).(map[string]string)
+	return deliveries
+}
+
+// recordingComponent records the inputs and outputs of every Process call.
+type recordingComponent struct {
+	core.Component
+	engine *RecordingEngine
+	name   string
+
+	mu          sync.Mutex
+	invocations int
+}
+
+func (c *recordingComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	invocation := c.invocations
+	c.invocations++
+	c.mu.Unlock()
+
+	deliveries := deliveriesFrom(ctx)
+	for port, value := range inputs {
+		c.engine.record(RecordedEvent{Kind: EventPacket, Component: c.name, Port: port, Connection: deliveries[port], Invocation: invocation}, value, nil)
+	}
+
+	outputs, err := c.Component.Process(ctx, inputs)
+	if err != nil {
+		c.engine.record(RecordedEvent{Kind: EventComponentError, Component: c.name, Invocation: invocation}, nil, err)
+		return outputs, err
+	}
+	for port, value := range outputs {
+		c.engine.record(RecordedEvent{Kind: EventComponentOutput, Component: c.name, Port: port, Invocation: invocation}, value, nil)
+	}
+	return outputs, nil
+}
+
+// ReplayEngine re-runs a pipeline from a Recording. Recorded external inputs
+// are fed to the inner engine, and stubbed components return their recorded
+// outputs instead of running.
+type ReplayEngine struct {
+	inner     core.ExecutionEngine
+	recording *Recording
+	encoder   ValueEncoder
+	stubs     map[string]bool
+}
+
+// NewReplayEngine creates a ReplayEngine around inner. A nil encoder selects GobValueEncoder.
+func NewReplayEngine(inner core.ExecutionEngine, recording *Recording, encoder ValueEncoder) *ReplayEngine {
+	if encoder == nil {
+		encoder = GobValueEncoder{}
+	}
+	return &ReplayEngine{inner: inner, recording: recording, encoder: encoder, stubs: make(map[string]bool)}
+}
+
+// Stub replaces the named components with their recorded outputs during replay.
+func (e *ReplayEngine) Stub(components ...string) *ReplayEngine {
+	for _, name := range components {
+		e.stubs[name] = true
+	}
+	return e
+}
+
+// Run replays the recording. Recorded external inputs take precedence over
+// the supplied inputs channels for the same port.
+func (e *ReplayEngine) Run(ctx context.Context, p *core.Pipeline, inputs, outputs map[string]chan interface{}) error {
+	for name := range e.stubs {
+		if _, ok := p.GetComponents()[name]; !ok {
+			return fmt.Errorf("cannot stub component %s: not found in pipeline %s", name, p.Name())
+		}
+	}
+
+	replayInputs := make(map[string]chan interface{}, len(inputs))
+	for port, ch := range inputs {
+		replayInputs[port] = ch
+	}
+	recordedInputs := make(map[string][]interface{})
+	for _, ev := range e.recording.Filter(EventExternalInput, "") {
+		value, err := DecodeValue(ev, e.encoder)
+		if err != nil {
+			return fmt.Errorf("error decoding recorded input %s: %w", ev.Port, err)
+		}
+		recordedInputs[ev.Port] = append(recordedInputs[ev.Port], value)
+	}
+	for port, values := range recordedInputs {
+		ch := make(chan interface{}, len(values))
+		for _, v := range values {
+			ch <- v
+		}
+		replayInputs[port] = ch
+	}
+
+	replayed := p.Decorate(func(name string, component core.Component) core.Component {
+		if !e.stubs[name] {
+			return component
+		}
+		return &stubComponent{Component: component, name: name, engine: e}
+	})
+	return e.inner.Run(ctx, replayed, replayInputs, outputs)
+}
+
+// Close closes the inner engine.
+func (e *ReplayEngine) Close() error {
+	return e.inner.Close()
+}
+
+// stubComponent returns the recorded outputs of a component instead of running it.
+type stubComponent struct {
+	core.Component
+	name   string
+	engine *ReplayEngine
+
+	mu          sync.Mutex
+	invocations int
+}
+
+func (c *stubComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	invocation := c.invocations
+	c.invocations++
+	c.mu.Unlock()
+
+	found := false
+	outputs := make(map[string]interface{})
+	for _, ev := range c.engine.recording.Events {
+		if ev.Component != c.name || ev.Invocation != invocation {
+			continue
+		}
+		switch ev.Kind {
+		case EventComponentError:
+			return nil, errors.New(ev.Error)
+		case EventComponentOutput:
+			value, err := DecodeValue(ev, c.engine.encoder)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding recorded output %s.%s: %w", c.name, ev.Port, err)
+			}
+			outputs[ev.Port] = value
+			found = true
+		case EventPacket:
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no recorded invocation %d for component %s", invocation, c.name)
+	}
+	return outputs, nil
+}
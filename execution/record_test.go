@@ -0,0 +1,153 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+)
+
+// reverseComponent reverses the string received on its "text" port.
+type reverseComponent struct {
+	core.BaseComponent
+	fail bool
+}
+
+func newReverseComponent(fail bool) *reverseComponent {
+	c := &reverseComponent{fail: fail}
+	c.Inputs = []core.Port{&core.BasePort{PortName: "text", PortType: reflect.TypeOf("")}}
+	c.Outputs = []core.Port{&core.BasePort{PortName: "result", PortType: reflect.TypeOf("")}}
+	return c
+}
+
+func (c *reverseComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if c.fail {
+		return nil, errors.New("reverse must not run")
+	}
+	runes := []rune(inputs["text"].(string))
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return map[string]interface{}{"result": string(runes)}, nil
+}
+
+func newRecordTestPipeline(failReverse bool) *core.Pipeline {
+	p := core.NewPipeline("record_test")
+	p.AddComponent("upper", components.NewUpperCase())
+	p.AddComponent("reverse", newReverseComponent(failReverse))
+	core.Connect[string](p, "upper", "output", "reverse", "text")
+	return p
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	engine := NewRecordingEngine(NewDefaultEngine(), nil)
+	inputs := map[string]chan interface{}{"input": make(chan interface{}, 1)}
+	outputs := map[string]chan interface{}{"result": make(chan interface{}, 1)}
+	inputs["input"] <- "abc"
+
+	if err := engine.Run(context.Background(), newRecordTestPipeline(false), inputs, outputs); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := <-outputs["result"]; got != "CBA" {
+		t.Fatalf("Expected 'CBA', got %v", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := engine.Recording().WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	rec, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecording failed: %v", err)
+	}
+
+	if n := len(rec.Filter(EventExternalInput, "")); n != 1 {
+		t.Errorf("Expected 1 external input event, got %d", n)
+	}
+	packets := rec.Filter(EventPacket, "reverse")
+	if len(packets) != 1 || !strings.Contains(packets[0].Connection, "upper.output") {
+		t.Fatalf("Expected packet on upper -> reverse connection, got %+v", packets)
+	}
+	if v, _ := DecodeValue(packets[0], GobValueEncoder{}); v != "ABC" {
+		t.Errorf("Expected recorded packet 'ABC', got %v", v)
+	}
+	if n := len(rec.Filter(EventExternalOutput, "")); n != 1 {
+		t.Errorf("Expected 1 external output event, got %d", n)
+	}
+
+	// Replay with a broken reverse component stubbed out by its recorded outputs
+	replay := NewReplayEngine(NewDefaultEngine(), rec, nil).Stub("reverse")
+	replayOutputs := map[string]chan interface{}{"result": make(chan interface{}, 1)}
+	if err := replay.Run(context.Background(), newRecordTestPipeline(true), nil, replayOutputs); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if got := <-replayOutputs["result"]; got != "CBA" {
+		t.Errorf("Expected replayed 'CBA', got %v", got)
+	}
+}
+
+type upperEncoder struct{}
+
+func (upperEncoder) Encode(value interface{}) ([]byte, error) {
+	return []byte(value.(string)), nil
+}
+
+func (upperEncoder) Decode(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+func TestRecordingCustomEncoder(t *testing.T) {
+	engine := NewRecordingEngine(NewDefaultEngine(), upperEncoder{})
+	inputs := map[string]chan interface{}{"input": make(chan interface{}, 1)}
+	inputs["input"] <- "xy"
+
+	if err := engine.Run(context.Background(), newRecordTestPipeline(false), inputs, nil); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	outputs := engine.Recording().Filter(EventComponentOutput, "reverse")
+	if len(outputs) != 1 || string(outputs[0].Data) != "YX" {
+		t.Errorf("Expected custom-encoded output 'YX', got %+v", outputs)
+	}
+}
+
+// silentComponent has an output port but never emits on it.
+type silentComponent struct {
+	core.BaseComponent
+}
+
+func (c *silentComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestRecordingNamesDeliveringConnection(t *testing.T) {
+	for name, inner := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+		"debug":      NewDebugEngine(false),
+	} {
+		t.Run(name, func(t *testing.T) {
+			silent := &silentComponent{}
+			silent.Outputs = []core.Port{&core.BasePort{PortName: "output", PortType: reflect.TypeOf(0)}}
+			p := core.NewPipeline("fan_in")
+			p.AddComponent("silent", silent)
+			p.AddComponent("loud", newValueComponent(nil, 1))
+			p.AddComponent("sink", newValueComponent(reflect.TypeOf(0), nil))
+			core.Connect[int](p, "silent", "output", "sink", "input")
+			core.Connect[int](p, "loud", "output", "sink", "input")
+
+			engine := NewRecordingEngine(inner, nil)
+			if err := engine.Run(context.Background(), p, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			packets := engine.Recording().Filter(EventPacket, "sink")
+			if len(packets) != 1 || packets[0].Connection != "loud.output -> sink.input" {
+				t.Errorf("Expected the packet from loud, got %+v", packets)
+			}
+		})
+	}
+}
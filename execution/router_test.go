@@ -0,0 +1,102 @@
+package execution
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/forrest/go-flow/core"
+)
+
+type joined struct {
+	Small string `port:"small,optional"`
+	Large string `port:"large,optional"`
+}
+
+// newRoutingPipeline routes a number to one of two branches and joins them
+// again through optional ports.
+func newRoutingPipeline(n int) (*core.Pipeline, *valueComponent) {
+	sink := newValueComponent(reflect.TypeOf(""), nil)
+	p := core.NewPipeline("routing")
+	p.AddComponent("source", newValueComponent(nil, n))
+	p.AddComponent("switch", core.NewSwitch("large", core.Route[int]{Port: "small", When: func(n int) bool { return n < 10 }}))
+	p.AddComponent("small", core.Func1("small", func(ctx context.Context, n int) (string, error) { return "small", nil }))
+	p.AddComponent("large", core.Func1("large", func(ctx context.Context, n int) (string, error) { return "large", nil }))
+	p.AddComponent("join", core.FuncPorts("join", func(ctx context.Context, in joined) (struct {
+		Output string `port:"output"`
+	}, error) {
+		var out struct {
+			Output string `port:"output"`
+		}
+		out.Output = in.Small + in.Large
+		return out, nil
+	}))
+	p.AddComponent("sink", sink)
+	core.Connect[int](p, "source", "output", "switch", "input")
+	core.Connect[int](p, "switch", "small", "small", "input")
+	core.Connect[int](p, "switch", "large", "large", "input")
+	core.Connect[string](p, "small", "output", "join", "small")
+	core.Connect[string](p, "large", "output", "join", "large")
+	core.Connect[string](p, "join", "output", "sink", "input")
+	return p, sink
+}
+
+func TestConditionalRouting(t *testing.T) {
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+		"debug":      NewDebugEngine(false),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for n, taken := range map[int]string{3: "small", 30: "large"} {
+				p, sink := newRoutingPipeline(n)
+				if errs := p.Errors(); len(errs) > 0 {
+					t.Fatal(errs)
+				}
+				if err := runWithTimeout(engine, p); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(sink.received, []interface{}{taken}) {
+					t.Errorf("Expected the join to run with the %s branch only, got %v", taken, sink.received)
+				}
+
+				skipped := "large"
+				if taken == "large" {
+					skipped = "small"
+				}
+				states := p.GetContext()
+				if state := states.GetComponentState(skipped); state != core.ComponentStateSkipped {
+					t.Errorf("Expected %s to be skipped, got %s", skipped, state)
+				}
+				if state := states.GetComponentState(taken); state != core.ComponentStateCompleted {
+					t.Errorf("Expected %s to complete, got %s", taken, state)
+				}
+			}
+		})
+	}
+}
+
+func TestRequiredJoinInputIsSkipped(t *testing.T) {
+	sink := newValueComponent(reflect.TypeOf(0), nil)
+	p := core.NewPipeline("required")
+	p.AddComponent("source", newValueComponent(nil, 1))
+	p.AddComponent("router", core.NewRouter("", core.Route[int]{Port: "never", When: func(int) bool { return false }}))
+	p.AddComponent("join", core.Func2("join", func(ctx context.Context, a, b int) (int, error) { return a + b, nil }))
+	p.AddComponent("sink", sink)
+	core.Connect[int](p, "source", "output", "router", "input")
+	core.Connect[int](p, "router", "never", "join", "input1")
+	core.Connect[int](p, "source", "output", "join", "input2")
+	core.Connect[int](p, "join", "output", "sink", "input")
+
+	if err := runWithTimeout(NewConcurrentEngine(), p); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.received) != 0 {
+		t.Errorf("Expected the join to be skipped without its required input, got %v", sink.received)
+	}
+	for _, name := range []string{"join", "sink"} {
+		if state := p.GetContext().GetComponentState(name); state != core.ComponentStateSkipped {
+			t.Errorf("Expected %s to be skipped, got %s", name, state)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// startPaused starts p and pauses it before the execute phase begins.
+func startPaused(t *testing.T, p *core.Pipeline) *core.RunHandle {
+	t.Helper()
+	paused := make(chan struct{})
+	p.SetLifecycleHooks(&core.LifecycleHooks{BeforePhase: func(ctx context.Context, phase core.LifecyclePhase) error {
+		if phase == core.LifecyclePhaseExecute {
+			<-paused
+		}
+		return nil
+	}})
+	h, err := p.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Pause()
+	close(paused)
+	return h
+}
+
+func waitForState(t *testing.T, p *core.Pipeline, component string, state core.ComponentState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for p.GetContext().GetComponentState(component) != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s never reached state %s, it is %s", component, state, p.GetContext().GetComponentState(component))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newControlledPipeline(engine core.ExecutionEngine) (*core.Pipeline, *valueComponent) {
+	sink := newValueComponent(reflect.TypeOf(0), nil)
+	p := core.NewPipeline("controlled")
+	p.SetEngine(engine)
+	p.AddComponent("source", newValueComponent(nil, 1))
+	p.AddComponent("sink", sink)
+	core.Connect[int](p, "source", "output", "sink", "input")
+	return p, sink
+}
+
+func TestPauseAndResume(t *testing.T) {
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, sink := newControlledPipeline(engine)
+			h := startPaused(t, p)
+
+			waitForState(t, p, "source", core.ComponentStatePaused)
+			if h.Status() != core.PipelineStatusPaused || p.GetContext().GetStatus() != core.PipelineStatusPaused {
+				t.Errorf("Expected the run to be paused, got %s", h.Status())
+			}
+			if len(sink.received) != 0 {
+				t.Fatalf("Expected the paused source not to emit, got %v", sink.received)
+			}
+
+			h.Resume()
+			if err := h.Wait(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sink.received, []interface{}{1}) {
+				t.Errorf("Expected the resumed source to emit, got %v", sink.received)
+			}
+			if h.Status() != core.PipelineStatusIdle {
+				t.Errorf("Expected the finished run to be idle, got %s", h.Status())
+			}
+		})
+	}
+}
+
+func TestWatchdogIgnoresPause(t *testing.T) {
+	p, sink := newControlledPipeline(NewConcurrentEngine())
+	p.GetConfig().Watchdog = &core.WatchdogConfig{StallTimeout: 20 * time.Millisecond, Abort: true}
+	h := startPaused(t, p)
+	waitForState(t, p, "source", core.ComponentStatePaused)
+
+	// Stay paused for several stall timeouts
+	time.Sleep(150 * time.Millisecond)
+	h.Resume()
+	if err := h.Wait(); err != nil {
+		t.Fatalf("Expected the paused run not to be reported as stalled, got %v", err)
+	}
+	if !reflect.DeepEqual(sink.received, []interface{}{1}) {
+		t.Errorf("Expected the resumed source to emit, got %v", sink.received)
+	}
+}
+
+func TestGracefulStop(t *testing.T) {
+	p, sink := newControlledPipeline(NewConcurrentEngine())
+	h := startPaused(t, p)
+	waitForState(t, p, "source", core.ComponentStatePaused)
+
+	h.Stop(true)
+	if err := h.Wait(); err != nil {
+		t.Fatalf("Expected a graceful stop to end the run cleanly, got %v", err)
+	}
+	if len(sink.received) != 0 {
+		t.Errorf("Expected the stopped source not to emit, got %v", sink.received)
+	}
+	if h.Status() != core.PipelineStatusStopped {
+		t.Errorf("Expected the run to be stopped, got %s", h.Status())
+	}
+	for _, name := range []string{"source", "sink"} {
+		if state := p.GetContext().GetComponentState(name); state != core.ComponentStateSkipped {
+			t.Errorf("Expected %s to be skipped, got %s", name, state)
+		}
+	}
+}
+
+func TestHardStop(t *testing.T) {
+	stuck := newStuckComponent()
+	p, _ := newStallPipeline(stuck, nil)
+	p.SetEngine(NewConcurrentEngine())
+
+	h, err := p.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, p, "stuck", core.ComponentStateRunning)
+	h.Stop(false)
+
+	select {
+	case <-h.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("The stopped run did not end")
+	}
+	if err := h.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the run to be cancelled, got %v", err)
+	}
+	if h.Status() != core.PipelineStatusStopped {
+		t.Errorf("Expected the run to be stopped, got %s", h.Status())
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	if _, err := core.NewPipeline("no engine").Start(context.Background()); err == nil {
+		t.Error("Expected Start without an engine to fail")
+	}
+}
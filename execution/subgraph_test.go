@@ -0,0 +1,86 @@
+package execution
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// newTargetPipeline builds left -> middle -> first, left -> second and
+// right -> third.
+func newTargetPipeline(engine core.ExecutionEngine) (*core.Pipeline, map[string]*valueComponent) {
+	components := map[string]*valueComponent{
+		"left":   newValueComponent(nil, 1),
+		"right":  newValueComponent(nil, 2),
+		"middle": newValueComponent(reflect.TypeOf(0), 3),
+		"first":  newValueComponent(reflect.TypeOf(0), nil),
+		"second": newValueComponent(reflect.TypeOf(0), nil),
+		"third":  newValueComponent(reflect.TypeOf(0), nil),
+	}
+	p := core.NewPipeline("targets").SetEngine(engine)
+	for name, component := range components {
+		p.AddComponent(name, component)
+	}
+	core.Connect[int](p, "left", "output", "middle", "input")
+	core.Connect[int](p, "middle", "output", "first", "input")
+	core.Connect[int](p, "left", "output", "second", "input")
+	core.Connect[int](p, "right", "output", "third", "input")
+	return p, components
+}
+
+func TestPartialRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(p *core.Pipeline) error
+		ran  []string
+	}{
+		{
+			name: "target",
+			run:  func(p *core.Pipeline) error { return p.RunTarget(context.Background(), "first") },
+			ran:  []string{"first", "left", "middle"},
+		},
+		{
+			name: "downstream",
+			run:  func(p *core.Pipeline) error { return p.RunDownstream(context.Background(), "middle", "right") },
+			ran:  []string{"first", "left", "middle", "right", "third"},
+		},
+	}
+	for _, tt := range tests {
+		for engineName, engine := range map[string]core.ExecutionEngine{
+			"default":    NewDefaultEngine(),
+			"concurrent": NewConcurrentEngine(),
+		} {
+			t.Run(tt.name+"/"+engineName, func(t *testing.T) {
+				p, components := newTargetPipeline(engine)
+				if err := tt.run(p); err != nil {
+					t.Fatal(err)
+				}
+				ran := make(map[string]bool)
+				for _, name := range tt.ran {
+					ran[name] = true
+				}
+				for name, component := range components {
+					state := p.GetContext().GetComponentState(name)
+					if ran[name] != (state == core.ComponentStateCompleted) {
+						t.Errorf("%s: expected ran=%v, got state %v", name, ran[name], state)
+					}
+					if component.Inputs != nil && ran[name] != (len(component.received) == 1) {
+						t.Errorf("%s: expected ran=%v, received %v", name, ran[name], component.received)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPartialRunErrors(t *testing.T) {
+	p, _ := newTargetPipeline(NewDefaultEngine())
+	if err := p.RunTarget(context.Background(), "missing"); err == nil {
+		t.Error("Expected an error for a missing target")
+	}
+	if err := p.RunDownstream(context.Background()); err == nil {
+		t.Error("Expected an error without changed components")
+	}
+}
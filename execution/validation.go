@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"context"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// withPacketValidation returns p with every component wrapped so that its
+// input and output packets are checked against the port schemas and
+// constraints. The pipeline is returned unchanged when packet validation is
+// not configured.
+func withPacketValidation(p *core.Pipeline) *core.Pipeline {
+	config := p.GetConfig()
+	if config == nil || config.PacketValidation == nil {
+		return p
+	}
+	return p.Decorate(func(name string, component core.Component) core.Component {
+		return &validatingComponent{
+			Component: component,
+			name:      name,
+			config:    config.PacketValidation,
+			errors:    p.GetErrorCollector(),
+		}
+	})
+}
+
+type validatingComponent struct {
+	core.Component
+	name   string
+	config *core.PacketValidationConfig
+	errors *core.ErrorCollector
+}
+
+// Process validates the inputs, runs the component and validates its
+// outputs. Invalid packets are dropped under the skip and dead-letter
+// policies; if that leaves a required input empty the component is skipped.
+func (c *validatingComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	if !c.config.SkipInputs {
+		valid, err := c.filter(ctx, c.Component.InputPorts(), core.DirectionInput, inputs)
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range c.Component.InputPorts() {
+			if _, ok := inputs[port.Name()]; ok && port.Required() {
+				if _, ok := valid[port.Name()]; !ok {
+					return nil, nil
+				}
+			}
+		}
+		inputs = valid
+	}
+
+	outputs, err := c.Component.Process(ctx, inputs)
+	if err != nil || c.config.SkipOutputs {
+		return outputs, err
+	}
+	return c.filter(ctx, c.Component.OutputPorts(), core.DirectionOutput, outputs)
+}
+
+// filter returns the valid packets of values. Under the fail policy the first
+// invalid packet is returned as an error instead.
+func (c *validatingComponent) filter(ctx context.Context, ports []core.Port, direction string, values map[string]interface{}) (map[string]interface{}, error) {
+	if values == nil {
+		return nil, nil
+	}
+	byName := make(map[string]core.Port, len(ports))
+	for _, port := range ports {
+		byName[port.Name()] = port
+	}
+
+	valid := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		port, ok := byName[name]
+		if !ok {
+			valid[name] = value
+			continue
+		}
+		verr := core.ValidatePacket(c.name, port, direction, value)
+		if verr == nil {
+			valid[name] = value
+			continue
+		}
+		if c.config.Policy == core.InvalidPacketFail {
+			return nil, verr
+		}
+		if c.errors != nil {
+			c.errors.Collect(verr)
+		}
+		if c.config.Policy == core.InvalidPacketDeadLetter && c.config.DeadLetter != nil {
+			letter := core.DeadLetter{
+				Component: c.name,
+				Port:      name,
+				Direction: direction,
+				Value:     value,
+				Error:     verr,
+				Time:      time.Now(),
+			}
+			if err := c.config.DeadLetter.Send(ctx, letter); err != nil {
+				return nil, core.NewPipelineError("failed to send packet to dead-letter sink", c.name, core.RuntimeError, core.Error, false).
+					WithOriginalError(err).
+					WithContext("port", name)
+			}
+		}
+	}
+	return valid, nil
+}
@@ -0,0 +1,101 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+)
+
+// newValidationTestPipeline feeds an empty string into UpperCase, whose input
+// schema requires at least one character.
+func newValidationTestPipeline(policy core.InvalidPacketPolicy, sink core.DeadLetterSink) *core.Pipeline {
+	p := core.NewPipeline("validation_test")
+	p.GetConfig().PacketValidation = &core.PacketValidationConfig{Policy: policy, DeadLetter: sink}
+	p.AddComponent("source", components.NewStringSource(""))
+	p.AddComponent("upper", components.NewUpperCase())
+	p.AddComponent("sink", components.NewStringSink())
+	core.Connect[string](p, "source", "output", "upper", "input")
+	core.Connect[string](p, "upper", "output", "sink", "input")
+	return p
+}
+
+func TestPacketValidationFail(t *testing.T) {
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newValidationTestPipeline(core.InvalidPacketFail, nil)
+			err := engine.Run(context.Background(), p, nil, nil)
+
+			var perr core.PipelineError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Expected a PipelineError, got %v", err)
+			}
+			if perr.ErrorType() != core.ValidationError || perr.Component() != "upper" {
+				t.Errorf("Expected ValidationError from upper, got %s from %s", perr.ErrorType(), perr.Component())
+			}
+			ctx := perr.Context()
+			if ctx["port"] != "input" || ctx["direction"] != core.DirectionInput {
+				t.Errorf("Unexpected error context: %v", ctx)
+			}
+			if ctx["constraint"] != (&core.StringLengthConstraint{MinLength: 1, MaxLength: 10000}).Description() {
+				t.Errorf("Expected the length constraint in the context, got %v", ctx["constraint"])
+			}
+		})
+	}
+}
+
+func TestPacketValidationSkip(t *testing.T) {
+	for name, engine := range map[string]core.ExecutionEngine{
+		"default":    NewDefaultEngine(),
+		"concurrent": NewConcurrentEngine(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newValidationTestPipeline(core.InvalidPacketSkip, nil)
+			outputs := map[string]chan interface{}{"output": make(chan interface{}, 2)}
+			if err := engine.Run(context.Background(), p, nil, outputs); err != nil {
+				t.Fatalf("Expected the invalid packet to be skipped, got %v", err)
+			}
+
+			// Only the source emits; upper and sink are skipped
+			if got := len(outputs["output"]); got != 1 {
+				t.Errorf("Expected 1 output packet, got %d", got)
+			}
+			if got := p.GetErrorCollector().GetErrorsByComponent("upper"); len(got) != 1 {
+				t.Errorf("Expected 1 collected validation error, got %d", len(got))
+			}
+		})
+	}
+}
+
+func TestPacketValidationDeadLetter(t *testing.T) {
+	queue := core.NewDeadLetterQueue()
+	p := newValidationTestPipeline(core.InvalidPacketDeadLetter, queue)
+	if err := NewConcurrentEngine().Run(context.Background(), p, nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	letters := queue.Letters()
+	if len(letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(letters))
+	}
+	letter := letters[0]
+	if letter.Component != "upper" || letter.Port != "input" || letter.Direction != core.DirectionInput || letter.Value != "" {
+		t.Errorf("Unexpected dead letter: %+v", letter)
+	}
+	if letter.Error.ErrorType() != core.ValidationError {
+		t.Errorf("Expected a ValidationError, got %s", letter.Error.ErrorType())
+	}
+}
+
+func TestPacketValidationDisabled(t *testing.T) {
+	p := newValidationTestPipeline(core.InvalidPacketFail, nil)
+	p.GetConfig().PacketValidation = nil
+	if err := NewDefaultEngine().Run(context.Background(), p, nil, nil); err != nil {
+		t.Fatalf("Expected no validation without configuration, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package execution
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// waitTracker records what every running component of a ConcurrentEngine run
+// is waiting on and when a packet last moved. A nil tracker ignores calls, so
+// engines can use it unconditionally.
+type waitTracker struct {
+	mu           sync.Mutex
+	lastProgress time.Time
+	waits        map[string]trackedWait
+}
+
+type trackedWait struct {
+	kind  core.WaitKind
+	conn  int
+	port  string
+	since time.Time
+}
+
+func newWaitTracker(config *core.PipelineConfig) *waitTracker {
+	if config == nil || config.Watchdog == nil || config.Watchdog.StallTimeout <= 0 {
+		return nil
+	}
+	return &waitTracker{lastProgress: time.Now(), waits: make(map[string]trackedWait)}
+}
+
+// set records that a component started waiting on a connection (by index)
+// or an external port.
+func (t *waitTracker) set(component string, kind core.WaitKind, conn int, port string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.waits[component] = trackedWait{kind: kind, conn: conn, port: port, since: time.Now()}
+}
+
+// moved records progress.
+func (t *waitTracker) moved() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastProgress = time.Now()
+}
+
+// done records that a component has finished.
+func (t *waitTracker) done(component string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.waits, component)
+	t.lastProgress = time.Now()
+}
+
+// watch checks for stalls until ctx is done, reporting each stall once. The
+// watchdog is suspended while the run is paused: sources held by the
+// RunControl leave everything downstream waiting, which is not a stall.
+func (t *waitTracker) watch(ctx context.Context, p *core.Pipeline, channels []chan interface{}, fail func(error)) {
+	config := p.GetConfig().Watchdog
+	control := core.RunControlFromContext(ctx)
+	interval := config.StallTimeout / 4
+	if interval < 5*time.Millisecond {
+		interval = 5 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	reported := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if control.Paused() {
+			t.moved()
+			continue
+		}
+		report := t.check(p, channels, config.StallTimeout)
+		if report == nil {
+			reported = false
+			continue
+		}
+		if reported {
+			continue
+		}
+		reported = true
+		if config.OnStall != nil {
+			config.OnStall(report)
+		}
+		if config.Abort {
+			fail(&core.DeadlockError{Report: report})
+			return
+		}
+	}
+}
+
+// check returns a report if nothing has moved for timeout while a component
+// is blocked sending or receiving.
+func (t *waitTracker) check(p *core.Pipeline, channels []chan interface{}, timeout time.Duration) *core.StallReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	idle := now.Sub(t.lastProgress)
+	if idle < timeout {
+		return nil
+	}
+	blocked := false
+	for _, w := range t.waits {
+		if w.kind != core.WaitKindProcessing {
+			blocked = true
+		}
+	}
+	if !blocked {
+		return nil
+	}
+
+	connections := p.GetConnections()
+	report := &core.StallReport{Pipeline: p.Name(), Idle: idle}
+	for component, w := range t.waits {
+		wait := core.ComponentWait{Component: component, Kind: w.kind, Port: w.port, Since: now.Sub(w.since)}
+		if w.kind == core.WaitKindReceive || w.kind == core.WaitKindSend {
+			conn := connections[w.conn]
+			wait.Connection = conn.Name
+			if wait.Connection == "" {
+				wait.Connection = conn.FromComponent + "." + conn.FromPort + " -> " + conn.ToComponent + "." + conn.ToPort
+			}
+			wait.WaitsFor = conn.FromComponent
+			if w.kind == core.WaitKindSend {
+				wait.WaitsFor = conn.ToComponent
+			}
+			wait.QueueDepth = len(channels[w.conn])
+			wait.QueueCapacity = cap(channels[w.conn])
+		}
+		report.Waits = append(report.Waits, wait)
+	}
+	return report
+}
@@ -0,0 +1,126 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// stuckComponent blocks in Process until released or cancelled.
+type stuckComponent struct {
+	core.BaseComponent
+	release chan struct{}
+}
+
+func newStuckComponent() *stuckComponent {
+	c := &stuckComponent{release: make(chan struct{})}
+	c.Inputs = []core.Port{&core.BasePort{PortName: "input", PortType: reflect.TypeOf(0), IsRequired: true}}
+	c.Outputs = []core.Port{&core.BasePort{PortName: "output", PortType: reflect.TypeOf(0)}}
+	return c
+}
+
+func (c *stuckComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	select {
+	case <-c.release:
+		return map[string]interface{}{"output": inputs["input"]}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newStallPipeline(stuck *stuckComponent, watchdog *core.WatchdogConfig) (*core.Pipeline, *valueComponent) {
+	sink := newValueComponent(reflect.TypeOf(0), nil)
+	p := core.NewPipeline("stall")
+	p.GetConfig().Watchdog = watchdog
+	p.AddComponent("source", newValueComponent(nil, 1))
+	p.AddComponent("stuck", stuck)
+	p.AddComponent("sink", sink)
+	core.Connect[int](p, "source", "output", "stuck", "input")
+	core.Connect[int](p, "stuck", "output", "sink", "input")
+	return p, sink
+}
+
+func TestWatchdogAbortsStalledRun(t *testing.T) {
+	p, _ := newStallPipeline(newStuckComponent(), &core.WatchdogConfig{StallTimeout: 50 * time.Millisecond, Abort: true})
+
+	done := make(chan error, 1)
+	go func() { done <- NewConcurrentEngine().Run(context.Background(), p, nil, nil) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The watchdog did not abort the stalled run")
+	}
+
+	var deadlock *core.DeadlockError
+	if !errors.As(err, &deadlock) {
+		t.Fatalf("Expected a DeadlockError, got %v", err)
+	}
+	report := deadlock.Report
+	blocked := report.Blocked()
+	if len(blocked) != 1 || blocked[0].Component != "sink" || blocked[0].Kind != core.WaitKindReceive || blocked[0].WaitsFor != "stuck" {
+		t.Fatalf("Expected sink to wait on stuck, got %+v", blocked)
+	}
+	if blocked[0].QueueDepth != 0 || blocked[0].QueueCapacity != 100 {
+		t.Errorf("Unexpected queue depth %d/%d", blocked[0].QueueDepth, blocked[0].QueueCapacity)
+	}
+	if text := report.String(); !strings.Contains(text, "sink waits to receive on stuck.output -> sink.input from stuck") ||
+		!strings.Contains(text, "stuck is processing") {
+		t.Errorf("Unexpected text report:\n%s", text)
+	}
+	if dot := report.DOT(); !strings.Contains(dot, `"sink" -> "stuck"`) || !strings.HasPrefix(dot, "digraph wait_for {") {
+		t.Errorf("Unexpected DOT report:\n%s", dot)
+	}
+	if !strings.Contains(err.Error(), "blocked components: sink") {
+		t.Errorf("Unexpected error message %q", err)
+	}
+}
+
+func TestWatchdogReportsExternalInputs(t *testing.T) {
+	sink := newValueComponent(reflect.TypeOf(0), nil)
+	p := core.NewPipeline("external")
+	p.GetConfig().Watchdog = &core.WatchdogConfig{StallTimeout: 30 * time.Millisecond, Abort: true}
+	p.AddComponent("sink", sink)
+
+	inputs := map[string]chan interface{}{"input": make(chan interface{})}
+	err := NewConcurrentEngine().Run(context.Background(), p, inputs, nil)
+	var deadlock *core.DeadlockError
+	if !errors.As(err, &deadlock) {
+		t.Fatalf("Expected a DeadlockError, got %v", err)
+	}
+	if w := deadlock.Report.Waits; len(w) != 1 || w[0].Kind != core.WaitKindExternalInput || w[0].Port != "input" {
+		t.Errorf("Expected sink to wait for external input, got %+v", w)
+	}
+	if dot := deadlock.Report.DOT(); !strings.Contains(dot, `"external:input" [shape=box]`) {
+		t.Errorf("Expected an external node in the DOT report:\n%s", dot)
+	}
+}
+
+func TestWatchdogReportsWithoutAborting(t *testing.T) {
+	stuck := newStuckComponent()
+	var once sync.Once
+	var reports []*core.StallReport
+	p, sink := newStallPipeline(stuck, &core.WatchdogConfig{
+		StallTimeout: 30 * time.Millisecond,
+		OnStall: func(report *core.StallReport) {
+			reports = append(reports, report)
+			once.Do(func() { close(stuck.release) })
+		},
+	})
+
+	if err := NewConcurrentEngine().Run(context.Background(), p, nil, nil); err != nil {
+		t.Fatalf("Expected the run to finish once released, got %v", err)
+	}
+	if len(reports) != 1 {
+		t.Errorf("Expected one stall report, got %d", len(reports))
+	}
+	if len(sink.received) != 1 {
+		t.Errorf("Expected the sink to receive the packet, got %v", sink.received)
+	}
+}
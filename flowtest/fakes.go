@@ -0,0 +1,147 @@
+package flowtest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// Matcher decides whether an observed value is acceptable.
+type Matcher interface {
+	Match(value interface{}) bool
+	Description() string
+}
+
+type equalMatcher struct {
+	want interface{}
+}
+
+// Equal matches values that are deeply equal to want.
+func Equal(want interface{}) Matcher {
+	return &equalMatcher{want: want}
+}
+
+func (m *equalMatcher) Match(value interface{}) bool {
+	return reflect.DeepEqual(value, m.want)
+}
+
+func (m *equalMatcher) Description() string {
+	return fmt.Sprintf("equal to %#v", m.want)
+}
+
+type funcMatcher struct {
+	description string
+	fn          func(interface{}) bool
+}
+
+// MatchFunc matches values for which fn returns true.
+func MatchFunc(description string, fn func(value interface{}) bool) Matcher {
+	return &funcMatcher{description: description, fn: fn}
+}
+
+func (m *funcMatcher) Match(value interface{}) bool {
+	return m.fn(value)
+}
+
+func (m *funcMatcher) Description() string {
+	return m.description
+}
+
+// Source is a fake component that emits fixed values on its output ports.
+type Source struct {
+	core.BaseComponent
+	values map[string]interface{}
+}
+
+// NewSource creates a fake source emitting the given values, one output port per
+// entry. Port types are taken from the values.
+func NewSource(values map[string]interface{}) *Source {
+	c := &Source{values: values}
+	c.ComponentDescription = "Fake source for tests"
+	for _, name := range sortedKeys(values) {
+		c.Outputs = append(c.Outputs, &core.BasePort{
+			PortName: name,
+			PortType: reflect.TypeOf(values[name]),
+		})
+	}
+	return c
+}
+
+// Process emits the configured values.
+func (c *Source) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(c.values))
+	for name, value := range c.values {
+		outputs[name] = value
+	}
+	return outputs, nil
+}
+
+// Sink is a fake component that records everything it receives.
+type Sink struct {
+	core.BaseComponent
+	mu       sync.Mutex
+	received map[string][]interface{}
+}
+
+// NewSink creates a fake sink with one optional input port per entry of ports,
+// each accepting the given type.
+func NewSink(ports map[string]reflect.Type) *Sink {
+	c := &Sink{received: make(map[string][]interface{})}
+	c.ComponentDescription = "Fake sink for tests"
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.Inputs = append(c.Inputs, &core.BasePort{
+			PortName: name,
+			PortType: ports[name],
+		})
+	}
+	return c
+}
+
+// Process records the inputs.
+func (c *Sink) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, value := range inputs {
+		c.received[name] = append(c.received[name], value)
+	}
+	return nil, nil
+}
+
+// Received returns the values received on a port.
+func (c *Sink) Received(port string) []interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]interface{}(nil), c.received[port]...)
+}
+
+// ProcessFunc is the signature of a mocked Process implementation.
+type ProcessFunc func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error)
+
+// mockComponent keeps the ports and metadata of the original component but
+// replaces its Process implementation.
+type mockComponent struct {
+	core.Component
+	fn ProcessFunc
+}
+
+func (c *mockComponent) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	return c.fn(ctx, inputs)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,531 @@
+// Package flowtest provides a harness for testing whole pipelines: mocked
+// components, fake sources and sinks, per-connection packet assertions,
+// fault injection, lifecycle verification and goroutine leak detection.
+package flowtest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/execution"
+)
+
+// Lifecycle phases recorded by the harness.
+const (
+	PhaseInitialize = "initialize"
+	PhaseProcess    = "process"
+	PhaseCleanup    = "cleanup"
+)
+
+// LifecycleEvent is a single lifecycle call observed on a component.
+type LifecycleEvent struct {
+	Component string
+	Phase     string
+}
+
+// Result holds everything observed during a harness run.
+type Result struct {
+	Err error
+	// Packets holds the values delivered on each connection, keyed by the
+	// connection name "from.port -> to.port".
+	Packets map[string][]interface{}
+	// Outputs holds the values written to external output channels, keyed by port.
+	Outputs map[string][]interface{}
+	// Sinks holds the values received by components without outgoing
+	// connections, keyed "component.port".
+	Sinks     map[string][]interface{}
+	Lifecycle []LifecycleEvent
+	Duration  time.Duration
+}
+
+type packetExpectation struct {
+	from, fromPort, to, toPort string
+	want                       []interface{}
+}
+
+type fault struct {
+	err     error
+	latency time.Duration
+}
+
+// Harness runs a pipeline under test.
+type Harness struct {
+	t        testing.TB
+	pipeline *core.Pipeline
+	engine   core.ExecutionEngine
+
+	mocks   map[string]core.Component
+	faults  map[string]*fault
+	inputs  map[string][]interface{}
+	capture map[string]bool
+
+	packetExpectations []packetExpectation
+	outputExpectations map[string][]interface{}
+	errorExpectation   func(error) bool
+	initOrder          []string
+	cleanupOrder       []string
+
+	leakTimeout time.Duration
+	timeout     time.Duration
+}
+
+// New creates a harness for the pipeline. The pipeline's own engine is used
+// unless WithEngine is called; without either a DefaultEngine is used.
+func New(t testing.TB, p *core.Pipeline) *Harness {
+	return &Harness{
+		t:                  t,
+		pipeline:           p,
+		engine:             p.GetEngine(),
+		mocks:              make(map[string]core.Component),
+		faults:             make(map[string]*fault),
+		inputs:             make(map[string][]interface{}),
+		capture:            make(map[string]bool),
+		outputExpectations: make(map[string][]interface{}),
+		timeout:            10 * time.Second,
+	}
+}
+
+// WithEngine selects the engine used to run the pipeline.
+func (h *Harness) WithEngine(engine core.ExecutionEngine) *Harness {
+	h.engine = engine
+	return h
+}
+
+// WithTimeout bounds the run; the default is ten seconds.
+func (h *Harness) WithTimeout(timeout time.Duration) *Harness {
+	h.timeout = timeout
+	return h
+}
+
+// Mock replaces a component for the run. The replacement keeps the original name and wiring.
+func (h *Harness) Mock(name string, component core.Component) *Harness {
+	component.SetName(name)
+	h.mocks[name] = component
+	return h
+}
+
+// MockFunc replaces the Process implementation of a component while keeping its ports.
+func (h *Harness) MockFunc(name string, fn ProcessFunc) *Harness {
+	h.mocks[name] = &mockComponent{Component: h.pipeline.GetComponents()[name], fn: fn}
+	return h
+}
+
+// InjectError makes the component's Process return err instead of running.
+func (h *Harness) InjectError(name string, err error) *Harness {
+	h.fault(name).err = err
+	return h
+}
+
+// InjectLatency delays every Process call of the component.
+func (h *Harness) InjectLatency(name string, latency time.Duration) *Harness {
+	h.fault(name).latency = latency
+	return h
+}
+
+func (h *Harness) fault(name string) *fault {
+	f, ok := h.faults[name]
+	if !ok {
+		f = &fault{}
+		h.faults[name] = f
+	}
+	return f
+}
+
+// Input queues values on an external input port.
+func (h *Harness) Input(port string, values ...interface{}) *Harness {
+	h.inputs[port] = append(h.inputs[port], values...)
+	return h
+}
+
+// CaptureOutput collects the values written to an external output port.
+func (h *Harness) CaptureOutput(port string) *Harness {
+	h.capture[port] = true
+	return h
+}
+
+// ExpectPackets asserts the values that cross a connection, in order.
+// Each expected value is either a Matcher or a value compared with deep equality.
+func (h *Harness) ExpectPackets(from, fromPort, to, toPort string, want ...interface{}) *Harness {
+	h.packetExpectations = append(h.packetExpectations, packetExpectation{from, fromPort, to, toPort, want})
+	return h
+}
+
+// ExpectOutput asserts the values written to an external output port, in order.
+func (h *Harness) ExpectOutput(port string, want ...interface{}) *Harness {
+	h.capture[port] = true
+	h.outputExpectations[port] = want
+	return h
+}
+
+// ExpectError asserts that the run fails with an error accepted by match.
+// A nil match accepts any error.
+func (h *Harness) ExpectError(match func(error) bool) *Harness {
+	if match == nil {
+		match = func(error) bool { return true }
+	}
+	h.errorExpectation = match
+	return h
+}
+
+// ExpectInitializeOrder asserts that the named components are initialized in the given relative order.
+func (h *Harness) ExpectInitializeOrder(names ...string) *Harness {
+	h.initOrder = names
+	return h
+}
+
+// ExpectCleanupOrder asserts that the named components are cleaned up in the given relative order.
+func (h *Harness) ExpectCleanupOrder(names ...string) *Harness {
+	h.cleanupOrder = names
+	return h
+}
+
+// CheckGoroutineLeaks fails the test if goroutines started during the run are
+// still alive after the engine returns and the timeout elapses.
+func (h *Harness) CheckGoroutineLeaks(timeout time.Duration) *Harness {
+	h.leakTimeout = timeout
+	return h
+}
+
+// Run executes the pipeline through the full lifecycle of
+// core.Pipeline.Run, including its lifecycle hooks, and then checks all
+// expectations.
+func (h *Harness) Run() *Result {
+	h.t.Helper()
+
+	components := h.pipeline.GetComponents()
+	for name := range h.mocks {
+		if _, ok := components[name]; !ok {
+			h.t.Fatalf("cannot mock component '%s': not found in pipeline %s", name, h.pipeline.Name())
+		}
+	}
+	for name := range h.faults {
+		if _, ok := components[name]; !ok {
+			h.t.Fatalf("cannot inject fault into component '%s': not found in pipeline %s", name, h.pipeline.Name())
+		}
+	}
+
+	engine := h.engine
+	if engine == nil {
+		engine = execution.NewDefaultEngine()
+	}
+
+	baseline := runtime.NumGoroutine()
+	result := &Result{
+		Packets: make(map[string][]interface{}),
+		Outputs: make(map[string][]interface{}),
+	}
+	rec := &recorder{result: result, received: make(map[string][]interface{})}
+
+	decorated := h.pipeline.Decorate(func(name string, component core.Component) core.Component {
+		if mock, ok := h.mocks[name]; ok {
+			component = mock
+		}
+		return &probe{Component: component, name: name, fault: h.faults[name], recorder: rec}
+	}).DecorateConnections(func(conn core.Connection) core.DataTransform {
+		return &connectionProbe{name: conn.Name, transform: conn.Transform, recorder: rec}
+	})
+	decorated.SetEngine(engine)
+
+	inputs := make(map[string]chan interface{}, len(h.inputs))
+	for port, values := range h.inputs {
+		ch := make(chan interface{}, len(values))
+		for _, v := range values {
+			ch <- v
+		}
+		inputs[port] = ch
+	}
+
+	finished := make(chan struct{})
+	var collectors sync.WaitGroup
+	outputs := make(map[string]chan interface{}, len(h.capture))
+	for port := range h.capture {
+		ch := make(chan interface{})
+		outputs[port] = ch
+		collectors.Add(1)
+		go func(port string, ch chan interface{}) {
+			defer collectors.Done()
+			for {
+				select {
+				case v := <-ch:
+					rec.output(port, v)
+				case <-finished:
+					return
+				}
+			}
+		}(port, ch)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result.Err = decorated.RunWithChannels(ctx, inputs, outputs)
+	if err := engine.Close(); err != nil && result.Err == nil {
+		result.Err = fmt.Errorf("engine cleanup failed: %w", err)
+	}
+	result.Duration = time.Since(start)
+	close(finished)
+	collectors.Wait()
+	result.Sinks = sinkPackets(h.pipeline, rec.received)
+
+	h.check(result)
+	if h.leakTimeout > 0 {
+		h.checkLeaks(baseline)
+	}
+	return result
+}
+
+// sinkPackets selects the packets received by components that have no
+// outgoing connections.
+func sinkPackets(p *core.Pipeline, packets map[string][]interface{}) map[string][]interface{} {
+	hasOutgoing := make(map[string]bool)
+	for _, conn := range p.GetConnections() {
+		hasOutgoing[conn.FromComponent] = true
+	}
+	sinks := make(map[string][]interface{})
+	for key, values := range packets {
+		component := key[:strings.Index(key, ".")]
+		if !hasOutgoing[component] {
+			sinks[key] = values
+		}
+	}
+	return sinks
+}
+
+func (h *Harness) check(result *Result) {
+	h.t.Helper()
+
+	if h.errorExpectation != nil {
+		if result.Err == nil {
+			h.t.Errorf("Expected pipeline %s to fail, but it succeeded", h.pipeline.Name())
+		} else if !h.errorExpectation(result.Err) {
+			h.t.Errorf("Pipeline %s failed with unexpected error: %v", h.pipeline.Name(), result.Err)
+		}
+	} else if result.Err != nil {
+		h.t.Errorf("Pipeline %s failed: %v", h.pipeline.Name(), result.Err)
+	}
+
+	for _, exp := range h.packetExpectations {
+		if !h.hasConnection(exp) {
+			h.t.Errorf("No connection %s.%s -> %s.%s in pipeline %s", exp.from, exp.fromPort, exp.to, exp.toPort, h.pipeline.Name())
+			continue
+		}
+		name := fmt.Sprintf("%s.%s -> %s.%s", exp.from, exp.fromPort, exp.to, exp.toPort)
+		h.compare("connection "+name, result.Packets[name], exp.want)
+	}
+
+	for port, want := range h.outputExpectations {
+		h.compare(fmt.Sprintf("output %s", port), result.Outputs[port], want)
+	}
+
+	h.checkLifecycle(result.Lifecycle)
+}
+
+func (h *Harness) hasConnection(exp packetExpectation) bool {
+	for _, conn := range h.pipeline.GetConnections() {
+		if conn.FromComponent == exp.from && conn.FromPort == exp.fromPort &&
+			conn.ToComponent == exp.to && conn.ToPort == exp.toPort {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Harness) compare(label string, got, want []interface{}) {
+	h.t.Helper()
+	if len(got) != len(want) {
+		h.t.Errorf("%s: got %d values %v, want %d", label, len(got), got, len(want))
+		return
+	}
+	for i, w := range want {
+		matcher, ok := w.(Matcher)
+		if !ok {
+			matcher = Equal(w)
+		}
+		if !matcher.Match(got[i]) {
+			h.t.Errorf("%s: value %d is %#v, want %s", label, i, got[i], matcher.Description())
+		}
+	}
+}
+
+func (h *Harness) checkLifecycle(events []LifecycleEvent) {
+	h.t.Helper()
+
+	initialized := make(map[string]int)
+	cleaned := make(map[string]int)
+	var initOrder, cleanupOrder []string
+	for _, ev := range events {
+		switch ev.Phase {
+		case PhaseInitialize:
+			initialized[ev.Component]++
+			initOrder = append(initOrder, ev.Component)
+		case PhaseProcess:
+			if initialized[ev.Component] == 0 {
+				h.t.Errorf("Component %s processed before it was initialized", ev.Component)
+			}
+			if cleaned[ev.Component] > 0 {
+				h.t.Errorf("Component %s processed after it was cleaned up", ev.Component)
+			}
+		case PhaseCleanup:
+			cleaned[ev.Component]++
+			cleanupOrder = append(cleanupOrder, ev.Component)
+		}
+	}
+
+	for name := range h.pipeline.GetComponents() {
+		if initialized[name] > 1 {
+			h.t.Errorf("Component %s initialized %d times", name, initialized[name])
+		}
+		if cleaned[name] > 1 {
+			h.t.Errorf("Component %s cleaned up %d times", name, cleaned[name])
+		}
+		if initialized[name] == 1 && cleaned[name] == 0 {
+			h.t.Errorf("Component %s was initialized but never cleaned up", name)
+		}
+	}
+
+	checkOrder := func(phase string, observed, want []string) {
+		position := make(map[string]int, len(observed))
+		for i, name := range observed {
+			position[name] = i
+		}
+		for i := 1; i < len(want); i++ {
+			prev, okPrev := position[want[i-1]]
+			cur, okCur := position[want[i]]
+			if !okPrev || !okCur || prev > cur {
+				h.t.Errorf("Expected %s order %v, got %v", phase, want, observed)
+				return
+			}
+		}
+	}
+	checkOrder(PhaseInitialize, initOrder, h.initOrder)
+	checkOrder(PhaseCleanup, cleanupOrder, h.cleanupOrder)
+}
+
+func (h *Harness) checkLeaks(baseline int) {
+	h.t.Helper()
+	deadline := time.Now().Add(h.leakTimeout)
+	for {
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	h.t.Errorf("Goroutine leak: %d goroutines running after pipeline %s returned, %d before\n%s",
+		runtime.NumGoroutine(), h.pipeline.Name(), baseline, strings.TrimSpace(string(buf[:n])))
+}
+
+// recorder collects observations from concurrently running probes.
+type recorder struct {
+	mu     sync.Mutex
+	result *Result
+	// Values received per input port, keyed "component.port"
+	received map[string][]interface{}
+}
+
+func (r *recorder) lifecycle(component, phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Lifecycle = append(r.result.Lifecycle, LifecycleEvent{Component: component, Phase: phase})
+}
+
+func (r *recorder) packet(connection string, value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Packets[connection] = append(r.result.Packets[connection], value)
+}
+
+func (r *recorder) inputs(component string, inputs map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for port, value := range inputs {
+		key := component + "." + port
+		r.received[key] = append(r.received[key], value)
+	}
+}
+
+func (r *recorder) output(port string, value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Outputs[port] = append(r.result.Outputs[port], value)
+}
+
+// probe observes a component and applies injected faults.
+type probe struct {
+	core.Component
+	name     string
+	fault    *fault
+	recorder *recorder
+}
+
+func (p *probe) Initialize(ctx context.Context) error {
+	p.recorder.lifecycle(p.name, PhaseInitialize)
+	return p.Component.Initialize(ctx)
+}
+
+func (p *probe) Cleanup(ctx context.Context) error {
+	p.recorder.lifecycle(p.name, PhaseCleanup)
+	return p.Component.Cleanup(ctx)
+}
+
+func (p *probe) Process(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+	p.recorder.lifecycle(p.name, PhaseProcess)
+	p.recorder.inputs(p.name, inputs)
+	if p.fault != nil {
+		if p.fault.latency > 0 {
+			select {
+			case <-time.After(p.fault.latency):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if p.fault.err != nil {
+			return nil, p.fault.err
+		}
+	}
+	return p.Component.Process(ctx, inputs)
+}
+
+// connectionProbe records the packets delivered on a connection after its
+// own transform, if any, has been applied.
+type connectionProbe struct {
+	name      string
+	transform core.DataTransform
+	recorder  *recorder
+}
+
+func (c *connectionProbe) Transform(ctx context.Context, data interface{}) (interface{}, error) {
+	if c.transform != nil {
+		var err error
+		if data, err = c.transform.Transform(ctx, data); err != nil {
+			return nil, err
+		}
+	}
+	c.recorder.packet(c.name, data)
+	return data, nil
+}
+
+func (c *connectionProbe) Name() string {
+	if c.transform != nil {
+		return c.transform.Name()
+	}
+	return "flowtest-probe"
+}
+
+func (c *connectionProbe) Description() string {
+	if c.transform != nil {
+		return c.transform.Description()
+	}
+	return "Records the packets delivered on " + c.name
+}
@@ -0,0 +1,114 @@
+package flowtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/execution"
+)
+
+func newHarnessTestPipeline() (*core.Pipeline, *Sink) {
+	sink := NewSink(map[string]reflect.Type{"input": reflect.TypeOf("")})
+	p := core.NewPipeline("flowtest")
+	p.AddComponent("source", NewSource(map[string]interface{}{"output": "hello"}))
+	p.AddComponent("upper", components.NewUpperCase())
+	p.AddComponent("sink", sink)
+	core.Connect[string](p, "source", "output", "upper", "input")
+	core.Connect[string](p, "upper", "output", "sink", "input")
+	return p, sink
+}
+
+func TestHarnessPackets(t *testing.T) {
+	p, sink := newHarnessTestPipeline()
+
+	New(t, p).
+		ExpectPackets("source", "output", "upper", "input", "hello").
+		ExpectPackets("upper", "output", "sink", "input", MatchFunc("upper-case", func(v interface{}) bool {
+			return v == strings.ToUpper(v.(string))
+		})).
+		ExpectInitializeOrder("source", "upper", "sink").
+		CheckGoroutineLeaks(time.Second).
+		Run()
+
+	if got := sink.Received("input"); !reflect.DeepEqual(got, []interface{}{"HELLO"}) {
+		t.Errorf("Expected sink to receive [HELLO], got %v", got)
+	}
+}
+
+func TestHarnessPacketsPerConnection(t *testing.T) {
+	sink := NewSink(map[string]reflect.Type{"input": reflect.TypeOf("")})
+	p := core.NewPipeline("fan-in")
+	p.AddComponent("left", NewSource(map[string]interface{}{"output": "l"}))
+	p.AddComponent("right", NewSource(map[string]interface{}{"output": "r"}))
+	p.AddComponent("sink", sink)
+	core.Connect[string](p, "left", "output", "sink", "input")
+	core.Connect[string](p, "right", "output", "sink", "input")
+
+	result := New(t, p).
+		ExpectPackets("left", "output", "sink", "input", "l").
+		ExpectPackets("right", "output", "sink", "input", "r").
+		Run()
+
+	if got := result.Packets["left.output -> sink.input"]; !reflect.DeepEqual(got, []interface{}{"l"}) {
+		t.Errorf("Expected [l] on left.output -> sink.input, got %v", got)
+	}
+}
+
+func TestHarnessMockAndFaults(t *testing.T) {
+	p, sink := newHarnessTestPipeline()
+
+	New(t, p).
+		WithEngine(execution.NewConcurrentEngine()).
+		MockFunc("upper", func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"output": []string{"mocked"}}, nil
+		}).
+		InjectLatency("source", 10*time.Millisecond).
+		ExpectPackets("upper", "output", "sink", "input", []string{"mocked"}).
+		Run()
+
+	if got := sink.Received("input"); len(got) != 1 {
+		t.Errorf("Expected one value at the sink, got %v", got)
+	}
+
+	boom := errors.New("boom")
+	p, _ = newHarnessTestPipeline()
+	result := New(t, p).
+		InjectError("upper", boom).
+		ExpectError(func(err error) bool { return errors.Is(err, boom) }).
+		Run()
+	if result.Err == nil {
+		t.Error("Expected injected error to fail the run")
+	}
+}
+
+// recordingT captures failures reported by the harness.
+type recordingT struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingT) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestHarnessReportsFailures(t *testing.T) {
+	p, _ := newHarnessTestPipeline()
+	fake := &recordingT{}
+	New(fake, p).ExpectPackets("upper", "output", "sink", "input", "wrong").Run()
+	if len(fake.failures) != 1 {
+		t.Errorf("Expected mismatched packet to report one failure, got %v", fake.failures)
+	}
+}
@@ -0,0 +1,159 @@
+package flowtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/visualization"
+)
+
+// GoldenDir is the directory, relative to the package under test, that holds golden files.
+var GoldenDir = "testdata"
+
+// Update makes the golden assertions rewrite golden files instead of
+// comparing them. It is set when the GOFLOW_UPDATE_GOLDEN environment
+// variable is not empty; a test package can also set it from a flag of its
+// own in TestMain.
+var Update = os.Getenv("GOFLOW_UPDATE_GOLDEN") != ""
+
+// AssertGolden compares got with the golden file testdata/<name>.golden.
+// With Update set the golden file is rewritten instead.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join(GoldenDir, name+".golden")
+
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("cannot update golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read golden file %s (set GOFLOW_UPDATE_GOLDEN=1 to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match (set GOFLOW_UPDATE_GOLDEN=1 to accept):\n%s", path, Diff(string(want), string(got)))
+	}
+}
+
+// AssertTopologyGolden snapshots the pipeline's DOT and JSON topology to
+// testdata/<name>.dot.golden and testdata/<name>.json.golden.
+func AssertTopologyGolden(t testing.TB, name string, p *core.Pipeline) {
+	t.Helper()
+	AssertGolden(t, name+".dot", []byte(visualization.ToDOT(p)))
+
+	topology, err := visualization.ToJSON(p)
+	if err != nil {
+		t.Fatalf("cannot render topology of %s: %v", p.Name(), err)
+	}
+	AssertGolden(t, name+".json", []byte(topology))
+}
+
+// AssertOutputsGolden snapshots the values received by each sink and written
+// to each external output to testdata/<name>.outputs.golden. Values must be
+// encodable as JSON.
+func AssertOutputsGolden(t testing.TB, name string, result *Result) {
+	t.Helper()
+	snapshot := map[string]map[string][]interface{}{}
+	if len(result.Sinks) > 0 {
+		snapshot["sinks"] = result.Sinks
+	}
+	if len(result.Outputs) > 0 {
+		snapshot["outputs"] = result.Outputs
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		t.Fatalf("cannot encode outputs for golden file %s: %v", name, err)
+	}
+	AssertGolden(t, name+".outputs", append(data, '\n'))
+}
+
+// Diff returns a line-based diff of want and got with two lines of context.
+// Removed lines are prefixed with "-", added lines with "+".
+func Diff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	const context = 2
+	var out strings.Builder
+	lastPrinted := -1
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		start := k - context
+		if start < 0 {
+			start = 0
+		}
+		if start <= lastPrinted {
+			start = lastPrinted + 1
+		} else if lastPrinted >= 0 {
+			out.WriteString("  ...\n")
+		}
+		end := k + context
+		for n := k + 1; n < len(lines) && n <= end; n++ {
+			if lines[n].op != ' ' {
+				end = n + context
+			}
+		}
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		for n := start; n <= end; n++ {
+			if n > lastPrinted {
+				fmt.Fprintf(&out, "%c %s\n", lines[n].op, lines[n].text)
+				lastPrinted = n
+			}
+		}
+	}
+	return out.String()
+}
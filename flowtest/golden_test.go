@@ -0,0 +1,36 @@
+package flowtest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoldenSnapshots(t *testing.T) {
+	p, _ := newHarnessTestPipeline()
+	result := New(t, p).Run()
+
+	AssertTopologyGolden(t, "flowtest", p)
+	AssertOutputsGolden(t, "flowtest", result)
+}
+
+func TestGoldenMismatchReportsDiff(t *testing.T) {
+	if Update {
+		t.Skip("golden files are being updated")
+	}
+	fake := &recordingT{}
+	AssertGolden(fake, "flowtest.outputs", []byte("{\n  \"sinks\": {\n    \"sink.input\": [\n      \"hello\"\n    ]\n  }\n}\n"))
+	if len(fake.failures) != 1 {
+		t.Fatalf("Expected one failure, got %v", fake.failures)
+	}
+	if !strings.Contains(fake.failures[0], "-       \"HELLO\"") || !strings.Contains(fake.failures[0], "+       \"hello\"") {
+		t.Errorf("Expected diff of changed line, got:\n%s", fake.failures[0])
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := Diff("a\nb\nc\nd\ne\nf\ng\n", "a\nb\nc\nX\ne\nf\ng\n")
+	want := "  b\n  c\n- d\n+ X\n  e\n  f\n"
+	if got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,307 @@
+// Package spec loads pipelines from JSON spec files. A spec lists components
+// by registered type name, the connections between them and, optionally,
+// parameters that turn the spec into a reusable template.
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	// Register the built-in component types.
+	_ "github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+)
+
+// Spec is the decoded form of a spec file.
+type Spec struct {
+	Name        string           `json:"name"`
+	Version     string           `json:"version,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Parameters  []ParameterSpec  `json:"parameters,omitempty"`
+	Components  []ComponentSpec  `json:"components"`
+	Connections []ConnectionSpec `json:"connections,omitempty"`
+	Expose      []ExposeSpec     `json:"expose,omitempty"`
+}
+
+// ParameterSpec declares a template parameter. Type is one of string, int,
+// float, bool, duration or a slice of those written as []string etc.
+type ParameterSpec struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// ComponentSpec declares a component instance. Type names a component type or
+// template in the registry; Config is passed to its factory. String values of
+// the form "${name}" are replaced by the value of parameter name.
+type ComponentSpec struct {
+	Name   string                 `json:"name"`
+	Type   string                 `json:"type"`
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// ConnectionSpec connects two ports, each written as "component.port".
+type ConnectionSpec struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	BufferSize int    `json:"buffer_size,omitempty"`
+}
+
+// ExposeSpec exposes an inner port when the pipeline is used as a component.
+type ExposeSpec struct {
+	Name      string `json:"name"`
+	Component string `json:"component"`
+	Port      string `json:"port"`
+}
+
+// Parse decodes a spec. Unknown fields are rejected.
+func Parse(data []byte) (*Spec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var s Spec
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+	if s.Name == "" {
+		return nil, fmt.Errorf("invalid spec: name is required")
+	}
+	return &s, nil
+}
+
+// Load reads and decodes a spec file.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// LoadPipeline reads a spec file and builds it with the default registry and
+// the given parameters.
+func LoadPipeline(path string, params map[string]interface{}) (*core.Pipeline, error) {
+	s, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Build(nil, params)
+}
+
+// Build instantiates the spec. Component types are looked up in r, or in
+// core.DefaultRegistry when r is nil.
+func (s *Spec) Build(r *core.ComponentRegistry, params map[string]interface{}) (*core.Pipeline, error) {
+	template, err := s.Template(r)
+	if err != nil {
+		return nil, err
+	}
+	return template.Instantiate(params)
+}
+
+// Template turns the spec into a template that can be instantiated repeatedly
+// or registered for use by other specs.
+func (s *Spec) Template(r *core.ComponentRegistry) (*core.Template, error) {
+	if r == nil {
+		r = core.DefaultRegistry
+	}
+
+	template := core.NewTemplate(s.Name, func(p *core.Pipeline, params map[string]interface{}) error {
+		return s.populate(p, r, params)
+	}).SetDescription(s.Description)
+	if s.Version != "" {
+		template.SetVersion(s.Version)
+	}
+
+	for _, param := range s.Parameters {
+		typ, err := parseType(param.Type)
+		if err != nil {
+			return nil, fmt.Errorf("spec %s: parameter '%s': %w", s.Name, param.Name, err)
+		}
+		if param.Required {
+			template.RequiredParam(param.Name, typ, param.Description)
+			continue
+		}
+		if param.Default == nil {
+			return nil, fmt.Errorf("spec %s: optional parameter '%s' needs a default", s.Name, param.Name)
+		}
+		value, err := core.ResolveParameters([]core.Parameter{{Name: param.Name, Type: typ}},
+			map[string]interface{}{param.Name: param.Default})
+		if err != nil {
+			return nil, fmt.Errorf("spec %s: default of %w", s.Name, err)
+		}
+		template.Param(param.Name, value[param.Name], param.Description)
+	}
+	return template, nil
+}
+
+func (s *Spec) populate(p *core.Pipeline, r *core.ComponentRegistry, params map[string]interface{}) error {
+	for _, cs := range s.Components {
+		config, err := substitute(cs.Config, params)
+		if err != nil {
+			return fmt.Errorf("component '%s': %w", cs.Name, err)
+		}
+		component, err := r.Create(cs.Type, config)
+		if err != nil {
+			return fmt.Errorf("component '%s': %w", cs.Name, err)
+		}
+		p.AddComponent(cs.Name, component)
+	}
+
+	for _, conn := range s.Connections {
+		fromComponent, fromPort, err := splitPort(conn.From)
+		if err != nil {
+			return err
+		}
+		toComponent, toPort, err := splitPort(conn.To)
+		if err != nil {
+			return err
+		}
+		p.ConnectPorts(fromComponent, fromPort, toComponent, toPort)
+		if conn.BufferSize > 0 {
+			p.SetConnectionBufferSize(fromComponent, fromPort, toComponent, toPort, conn.BufferSize)
+		}
+	}
+
+	for _, e := range s.Expose {
+		p.Expose(e.Name, e.Component, e.Port)
+	}
+	return nil
+}
+
+// splitPort splits "component.port" at the last dot.
+func splitPort(ref string) (string, string, error) {
+	i := strings.LastIndex(ref, ".")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid port reference '%s': expected component.port", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
+
+// substitute replaces parameter references in a component configuration. A
+// string that is exactly "${name}" becomes the parameter value with its type;
+// other references are formatted into the surrounding string. Entries whose
+// value refers to an unset optional parameter are dropped.
+func substitute(config map[string]interface{}, params map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(config))
+	for key, item := range config {
+		if name, ok := reference(item); ok {
+			if param, set := params[name]; set {
+				result[key] = param
+			}
+			continue
+		}
+		substituted, err := substituteValue(item, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		result[key] = substituted
+	}
+	return result, nil
+}
+
+func substituteValue(value interface{}, params map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if name, ok := reference(v); ok {
+			param, set := params[name]
+			if !set {
+				return nil, fmt.Errorf("parameter '%s' is not set", name)
+			}
+			return param, nil
+		}
+		return expand(v, params)
+	case map[string]interface{}:
+		return substitute(v, params)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			substituted, err := substituteValue(item, params)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			result[i] = substituted
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+// reference reports whether value is exactly "${name}".
+func reference(value interface{}) (string, bool) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") || strings.Count(s, "${") != 1 {
+		return "", false
+	}
+	return s[2 : len(s)-1], true
+}
+
+// expand formats every "${name}" in s.
+func expand(s string, params map[string]interface{}) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		end := strings.Index(s[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated parameter reference in %q", s)
+		}
+		name := s[start+2 : start+end]
+		param, set := params[name]
+		if !set {
+			return "", fmt.Errorf("parameter '%s' is not set", name)
+		}
+		out.WriteString(s[:start])
+		fmt.Fprint(&out, param)
+		s = s[start+end+1:]
+	}
+}
+
+var parameterTypes = map[string]reflect.Type{
+	"string":   reflect.TypeOf(""),
+	"int":      reflect.TypeOf(0),
+	"float":    reflect.TypeOf(0.0),
+	"bool":     reflect.TypeOf(false),
+	"duration": reflect.TypeOf(time.Duration(0)),
+}
+
+func parseType(name string) (reflect.Type, error) {
+	if elem := strings.TrimPrefix(name, "[]"); elem != name {
+		typ, err := parseType(elem)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(typ), nil
+	}
+	typ, ok := parameterTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type '%s'", name)
+	}
+	return typ, nil
+}
+
+// Diff loads two spec files with the same parameters and compares them.
+func Diff(oldPath, newPath string, params map[string]interface{}) (*core.PipelineDiff, error) {
+	from, err := LoadPipeline(oldPath, params)
+	if err != nil {
+		return nil, err
+	}
+	to, err := LoadPipeline(newPath, params)
+	if err != nil {
+		return nil, err
+	}
+	return core.DiffPipelines(from, to), nil
+}
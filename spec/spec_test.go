@@ -0,0 +1,91 @@
+package spec
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+	"github.com/forrest/go-flow/execution"
+)
+
+// newTestRegistry registers the built-ins and a "loud_lines" template that
+// upper-cases its input and keeps the lines containing a pattern.
+func newTestRegistry(t *testing.T) *core.ComponentRegistry {
+	registry := core.NewComponentRegistry()
+	components.RegisterBuiltins(registry)
+	template := core.NewTemplate("loud_lines", func(p *core.Pipeline, params map[string]interface{}) error {
+		p.AddComponent("upper", components.NewUpperCase())
+		p.AddComponent("grep", components.NewGrep(params["pattern"].(string)))
+		core.Connect[string](p, "upper", "output", "grep", "input")
+		p.Expose("in", "upper", "input")
+		p.Expose("out", "grep", "output")
+		return nil
+	}).RequiredParam("pattern", reflect.TypeOf(""), "Substring to keep")
+	if err := registry.RegisterTemplate(template); err != nil {
+		t.Fatalf("RegisterTemplate failed: %v", err)
+	}
+	return registry
+}
+
+func TestBuildSpecWithTemplate(t *testing.T) {
+	s, err := Load("testdata/shout.json")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	p, err := s.Build(newTestRegistry(t), map[string]interface{}{"text": "hello world"})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if p.Version() != "1.2.0" || p.GetConnections()[0].BufferSize != 5 {
+		t.Errorf("Expected version 1.2.0 and buffer size 5, got %s and %d", p.Version(), p.GetConnections()[0].BufferSize)
+	}
+
+	outputs := map[string]chan interface{}{"out": make(chan interface{}, 1)}
+	if err := execution.NewDefaultEngine().Run(context.Background(), p, nil, outputs); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := <-outputs["out"]; got != "HELLO WORLD" {
+		t.Errorf("Expected 'HELLO WORLD', got %v", got)
+	}
+}
+
+func TestBuildSpecErrors(t *testing.T) {
+	registry := newTestRegistry(t)
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{`{"name": "x", "components": [], "extra": 1}`, "unknown field"},
+		{`{"components": []}`, "name is required"},
+		{`{"name": "x", "components": [{"name": "a", "type": "nope"}]}`, "unknown component type 'nope'"},
+		{`{"name": "x", "components": [{"name": "a", "type": "grep", "config": {"pattern": 3}}]}`, "parameter 'pattern'"},
+		{`{"name": "x", "components": [{"name": "a", "type": "loud_lines", "config": {"pattern": "${missing}"}}]}`, "missing required parameter 'pattern'"},
+		{`{"name": "x", "components": [{"name": "a", "type": "string_source"}, {"name": "b", "type": "string_source"}],
+		  "connections": [{"from": "a.output", "to": "b.input"}]}`, "port 'input' not found"},
+		{`{"name": "x", "parameters": [{"name": "n", "type": "complex"}], "components": []}`, "unknown type 'complex'"},
+	}
+	for _, tt := range tests {
+		s, err := Parse([]byte(tt.spec))
+		if err == nil {
+			_, err = s.Build(registry, nil)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Spec %s: expected error containing %q, got %v", tt.spec, tt.want, err)
+		}
+	}
+}
+
+func TestBuildSpecNullParameter(t *testing.T) {
+	s, err := Parse([]byte(`{"name": "x", "parameters": [{"name": "text", "type": "string", "default": "hi"}],
+	  "components": [{"name": "a", "type": "string_source", "config": {"data": "${text}"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Build(newTestRegistry(t), map[string]interface{}{"text": nil})
+	if err == nil || !strings.Contains(err.Error(), "parameter 'text' is null") {
+		t.Errorf("Expected a null parameter to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package visualization
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// Colors used by DiffToDOT.
+const (
+	addedColor    = "green"
+	removedColor  = "red"
+	modifiedColor = "orange"
+)
+
+// DiffToDOT renders both versions of a pipeline as one Graphviz graph. Added
+// components and connections are green, removed ones red and dashed, and
+// modified ones orange.
+func DiffToDOT(from, to *core.Pipeline) string {
+	d := core.DiffPipelines(from, to)
+	componentKinds := make(map[string]core.ChangeKind)
+	for _, c := range d.Components {
+		componentKinds[c.Name] = c.Kind
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("digraph \"%s\" {\n", to.Name()))
+	b.WriteString(fmt.Sprintf("  label=\"%s %s -> %s (%s)\";\n", to.Name(), d.FromVersion, d.ToVersion, d.Compatibility))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=record];\n")
+
+	oldComponents := from.GetComponents()
+	newComponents := to.GetComponents()
+	all := make(map[string]core.Component, len(oldComponents)+len(newComponents))
+	for name, component := range oldComponents {
+		all[name] = component
+	}
+	for name, component := range newComponents {
+		all[name] = component
+	}
+	for _, name := range sortedNames(all) {
+		component := all[name]
+		label := fmt.Sprintf("{%s|{%s|%s}}", name, getPorts(component.InputPorts()), getPorts(component.OutputPorts()))
+		attrs := fmt.Sprintf("label=\"%s\"", label)
+		if kind, changed := componentKinds[name]; changed {
+			attrs += changeAttributes(kind)
+		}
+		b.WriteString(fmt.Sprintf("  \"%s\" [%s];\n", name, attrs))
+	}
+
+	modified := make(map[string]bool)
+	for _, c := range d.Connections {
+		if c.Kind == core.ChangeModified {
+			modified[c.From+" -> "+c.To] = true
+		}
+	}
+	newKeys := make(map[string]bool)
+	for _, conn := range to.GetConnections() {
+		newKeys[connectionKey(conn)] = true
+	}
+	oldKeys := make(map[string]bool)
+	for _, conn := range from.GetConnections() {
+		oldKeys[connectionKey(conn)] = true
+		if !newKeys[connectionKey(conn)] {
+			b.WriteString(edge(conn, changeAttributes(core.ChangeRemoved)))
+		}
+	}
+	for _, conn := range to.GetConnections() {
+		key := connectionKey(conn)
+		switch {
+		case !oldKeys[key]:
+			b.WriteString(edge(conn, changeAttributes(core.ChangeAdded)))
+		case modified[key]:
+			b.WriteString(edge(conn, changeAttributes(core.ChangeModified)))
+		default:
+			b.WriteString(edge(conn, ""))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func connectionKey(conn core.Connection) string {
+	return conn.FromComponent + "." + conn.FromPort + " -> " + conn.ToComponent + "." + conn.ToPort
+}
+
+func edge(conn core.Connection, attrs string) string {
+	if attrs != "" {
+		attrs = " [" + strings.TrimPrefix(attrs, ", ") + "]"
+	}
+	return fmt.Sprintf("  \"%s\":%s -> \"%s\":%s%s;\n", conn.FromComponent, conn.FromPort, conn.ToComponent, conn.ToPort, attrs)
+}
+
+func changeAttributes(kind core.ChangeKind) string {
+	switch kind {
+	case core.ChangeAdded:
+		return fmt.Sprintf(", color=%s, fontcolor=%s", addedColor, addedColor)
+	case core.ChangeRemoved:
+		return fmt.Sprintf(", color=%s, fontcolor=%s, style=dashed", removedColor, removedColor)
+	default:
+		return fmt.Sprintf(", color=%s", modifiedColor)
+	}
+}
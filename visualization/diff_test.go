@@ -0,0 +1,69 @@
+package visualization
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/forrest/go-flow/components"
+	"github.com/forrest/go-flow/core"
+)
+
+// newDiffPipelines returns source -> upper -> sink and a 2.0.0 version that
+// replaces upper with grep.
+func newDiffPipelines() (*core.Pipeline, *core.Pipeline) {
+	v1 := core.NewPipeline("text").SetVersion("1.0.0")
+	v1.AddComponent("source", components.NewStringSource("a"))
+	v1.AddComponent("upper", components.NewUpperCase())
+	v1.AddComponent("sink", components.NewStringSink())
+	core.Connect[string](v1, "source", "output", "upper", "input")
+	core.Connect[string](v1, "upper", "output", "sink", "input")
+
+	v2 := core.NewPipeline("text").SetVersion("2.0.0")
+	v2.AddComponent("source", components.NewStringSource("a"))
+	v2.AddComponent("grep", components.NewGrep("a"))
+	v2.AddComponent("sink", components.NewStringSink())
+	core.Connect[string](v2, "source", "output", "grep", "input")
+	core.Connect[string](v2, "grep", "output", "sink", "input")
+	return v1, v2
+}
+
+func TestDiffToDOT(t *testing.T) {
+	v1, v2 := newDiffPipelines()
+	dot := DiffToDOT(v1, v2)
+
+	for _, want := range []string{
+		`digraph "text" {`,
+		`label="text 1.0.0 -> 2.0.0 (breaking)";`,
+		`"grep" [label="{grep|{<input> input (string)|<output> output (string)}}", color=green, fontcolor=green];`,
+		`"upper" [label="{upper|{<input> input (string)|<output> output (string)}}", color=red, fontcolor=red, style=dashed];`,
+		`"sink" [label="{sink|{<input> input (string)|}}"];`,
+		`"source":output -> "upper":input [color=red, fontcolor=red, style=dashed];`,
+		`"upper":output -> "sink":input [color=red, fontcolor=red, style=dashed];`,
+		`"source":output -> "grep":input [color=green, fontcolor=green];`,
+		`"grep":output -> "sink":input [color=green, fontcolor=green];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("Expected DOT to contain %s, got:\n%s", want, dot)
+		}
+	}
+	if !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("Expected DOT to end the graph, got:\n%s", dot)
+	}
+}
+
+func TestDiffToDOTModifiedConnection(t *testing.T) {
+	_, v2 := newDiffPipelines()
+	_, v3 := newDiffPipelines()
+	v3.SetConnectionBufferSize("source", "output", "grep", "input", 5)
+	dot := DiffToDOT(v2, v3)
+
+	if !strings.Contains(dot, `"source":output -> "grep":input [color=orange];`) {
+		t.Errorf("Expected the modified connection in orange, got:\n%s", dot)
+	}
+	if !strings.Contains(dot, `"grep":output -> "sink":input;`) {
+		t.Errorf("Expected the unchanged connection without attributes, got:\n%s", dot)
+	}
+	if strings.Contains(dot, "color=green") || strings.Contains(dot, "color=red") {
+		t.Errorf("Expected no added or removed elements, got:\n%s", dot)
+	}
+}
@@ -3,10 +3,12 @@ package visualization
 import (
 	"fmt"
 	"github.com/forrest/go-flow/core"
+	"sort"
 	"strings"
 )
 
 // ToDOT generates a Graphviz DOT representation of the pipeline.
+// Components are emitted in name order so the output is stable.
 func ToDOT(p *core.Pipeline) string {
 	var b strings.Builder
 	b.WriteString(fmt.Sprintf("digraph \"%s\" {\n", p.Name()))
@@ -14,7 +16,8 @@ func ToDOT(p *core.Pipeline) string {
 	b.WriteString("  node [shape=record];\n")
 
 	components := p.GetComponents()
-	for name, component := range components {
+	for _, name := range sortedNames(components) {
+		component := components[name]
 		label := fmt.Sprintf("{%s|{%s|%s}}", name, getPorts(component.InputPorts()), getPorts(component.OutputPorts()))
 		b.WriteString(fmt.Sprintf("  \"%s\" [label=\"%s\"];\n", name, label))
 	}
@@ -35,3 +38,12 @@ func getPorts(ports []core.Port) string {
 	}
 	return strings.Join(portStrings, "|")
 }
+
+func sortedNames(components map[string]core.Component) []string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
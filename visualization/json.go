@@ -0,0 +1,98 @@
+package visualization
+
+import (
+	"encoding/json"
+
+	"github.com/forrest/go-flow/core"
+)
+
+// Topology is a serializable description of a pipeline's wiring.
+type Topology struct {
+	Name        string               `json:"name"`
+	Version     string               `json:"version"`
+	Components  []TopologyComponent  `json:"components"`
+	Connections []TopologyConnection `json:"connections"`
+}
+
+// TopologyComponent describes a component and its ports.
+type TopologyComponent struct {
+	Name    string         `json:"name"`
+	Version string         `json:"version"`
+	Inputs  []TopologyPort `json:"inputs,omitempty"`
+	Outputs []TopologyPort `json:"outputs,omitempty"`
+}
+
+// TopologyPort describes a component port.
+type TopologyPort struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// TopologyConnection describes a connection between two ports.
+type TopologyConnection struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	BufferSize   int    `json:"buffer_size"`
+	Transform    string `json:"transform,omitempty"`
+	Backpressure string `json:"backpressure,omitempty"`
+}
+
+// BuildTopology describes the pipeline's components and connections.
+// Components are listed in name order so the result is stable.
+func BuildTopology(p *core.Pipeline) *Topology {
+	t := &Topology{
+		Name:        p.Name(),
+		Version:     p.GetVersion(),
+		Components:  make([]TopologyComponent, 0),
+		Connections: make([]TopologyConnection, 0),
+	}
+
+	components := p.GetComponents()
+	for _, name := range sortedNames(components) {
+		component := components[name]
+		t.Components = append(t.Components, TopologyComponent{
+			Name:    name,
+			Version: component.Version(),
+			Inputs:  topologyPorts(component.InputPorts()),
+			Outputs: topologyPorts(component.OutputPorts()),
+		})
+	}
+
+	for _, conn := range p.GetConnections() {
+		tc := TopologyConnection{
+			From:       conn.FromComponent + "." + conn.FromPort,
+			To:         conn.ToComponent + "." + conn.ToPort,
+			BufferSize: conn.BufferSize,
+		}
+		if conn.Transform != nil {
+			tc.Transform = conn.Transform.Name()
+		}
+		if conn.Backpressure != nil {
+			tc.Backpressure = conn.Backpressure.Strategy.String()
+		}
+		t.Connections = append(t.Connections, tc)
+	}
+	return t
+}
+
+// ToJSON generates an indented JSON representation of the pipeline topology.
+func ToJSON(p *core.Pipeline) (string, error) {
+	data, err := json.MarshalIndent(BuildTopology(p), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+func topologyPorts(ports []core.Port) []TopologyPort {
+	var result []TopologyPort
+	for _, p := range ports {
+		typeName := "<nil>"
+		if p.Type() != nil {
+			typeName = p.Type().String()
+		}
+		result = append(result, TopologyPort{Name: p.Name(), Type: typeName, Required: p.Required()})
+	}
+	return result
+}